@@ -11,9 +11,15 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zhenglizhi/policy-fit/internal/auth"
 	"github.com/zhenglizhi/policy-fit/internal/config"
 	"github.com/zhenglizhi/policy-fit/internal/handler"
+	"github.com/zhenglizhi/policy-fit/internal/jobs"
 	"github.com/zhenglizhi/policy-fit/internal/middleware"
+	"github.com/zhenglizhi/policy-fit/internal/repository"
+	"github.com/zhenglizhi/policy-fit/internal/service"
+	"github.com/zhenglizhi/policy-fit/internal/sms"
+	"github.com/zhenglizhi/policy-fit/internal/storage"
 	"github.com/zhenglizhi/policy-fit/pkg/logger"
 )
 
@@ -23,31 +29,82 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if err := cfg.ValidateAuth(); err != nil {
+		log.Fatalf("Invalid login config: %v", err)
+	}
 
-	// 初始化日志
-	logger.Init(cfg.Log.Level, cfg.Log.Format)
+	// 初始化日志，规则已在加载配置时校验
+	redactRules, _ := cfg.Log.RedactRules()
+	logger.InitWithRules(cfg.Log.Level, cfg.Log.Format, redactRules)
 	defer logger.Sync()
 
+	// 初始化数据库
+	db, err := repository.OpenPostgres(cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", "error", err)
+	}
+	defer db.Close()
+
+	// 初始化 Redis
+	rdb, err := repository.OpenRedis(context.Background(), cfg.Redis)
+	if err != nil {
+		logger.Fatal("Failed to connect to redis", "error", err)
+	}
+	defer rdb.Close()
+
+	store := repository.NewPostgresStore(db)
+	taskService := service.NewTaskService(store, jobs.NewQueue(rdb))
+
+	// 初始化对象存储
+	objectStorage, err := storage.New(cfg.Storage)
+	if err != nil {
+		logger.Fatal("Failed to initialize storage", "error", err)
+	}
+	documentService := service.NewDocumentService(store, objectStorage, cfg.Upload.MaxBytes())
+
+	// 初始化 JWT 校验，轮换后的旧密钥继续用于校验未过期的 token
+	currentKey, jwtKeys, err := cfg.Security.JWTKeys()
+	if err != nil {
+		logger.Fatal("Invalid JWT keys", "error", err)
+	}
+	jwt, err := auth.NewJWT(currentKey, jwtKeys, time.Duration(cfg.Security.JWTClockSkew)*time.Second)
+	if err != nil {
+		logger.Fatal("Failed to initialize JWT", "error", err)
+	}
+
+	smsSender, err := sms.New(cfg.OTP, cfg.AppEnv)
+	if err != nil {
+		logger.Fatal("Failed to initialize sms sender", "error", err)
+	}
+	authService := service.NewAuthService(store, auth.NewOTPStore(rdb), smsSender, jwt, cfg)
+
 	// 初始化 Gin
 	if cfg.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	router := gin.New()
-	router.Use(gin.Recovery())
+	// 仅信任已配置代理的 X-Forwarded-For，否则客户端可伪造来源 IP 绕过按 IP 限流
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxyList()); err != nil {
+		logger.Fatal("Invalid trusted proxies", "error", err)
+	}
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Recovery())
 	router.Use(middleware.Logger())
 	router.Use(middleware.CORS())
+	router.Use(middleware.ErrorHandler())
 
 	// 健康检查
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
-	// API 路由
+	// API 路由，除登录外均需鉴权
 	v1 := router.Group("/api/v1")
 	{
-		// TODO: 注册路由
-		handler.RegisterTaskRoutes(v1)
+		handler.RegisterAuthRoutes(v1, handler.NewAuthHandler(authService))
+		authed := v1.Group("", middleware.Auth(jwt))
+		handler.RegisterTaskRoutes(authed, handler.NewTaskHandler(taskService, documentService, cfg.Upload.MaxBytes()))
 	}
 
 	// 启动服务器
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/zhenglizhi/policy-fit/internal/parser/parserfake"
+)
+
+// defaultAddr 与 .env.example 中的 PYTHON_SERVICE_URL 一致
+const defaultAddr = ":8081"
+
+// parser-stub 本地启动 python-service 桩服务，配合 PDF_PARSER=python-service 在没有
+// Python 解析服务的环境下联调流水线。仅用于开发与测试。
+func main() {
+	addr := defaultAddr
+	if len(os.Args) > 1 {
+		addr = os.Args[1]
+	}
+	log.Printf("Parser stub listening on %s", addr)
+	if err := http.ListenAndServe(addr, parserfake.New()); err != nil {
+		log.Fatalf("Parser stub failed: %v", err)
+	}
+}
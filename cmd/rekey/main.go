@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+
+	"github.com/zhenglizhi/policy-fit/internal/config"
+	"github.com/zhenglizhi/policy-fit/internal/storage"
+)
+
+// defaultPrefix 默认重新封装全部任务对象
+const defaultPrefix = "tasks/"
+
+// errEncryptionDisabled 未配置主密钥，存储未加密
+var errEncryptionDisabled = errors.New("storage encryption is not enabled (STORAGE_ENCRYPTION_KEY is empty)")
+
+// rekey 在主密钥轮换后，用 STORAGE_ENCRYPTION_KEY 重新封装所有对象的数据密钥。
+// 旧主密钥需保留在 STORAGE_ENCRYPTION_OLD_KEYS 中，直到本命令执行完成。
+func main() {
+	prefix := defaultPrefix
+	if len(os.Args) > 1 {
+		prefix = os.Args[1]
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	rewrapped, err := rekey(context.Background(), cfg.Storage, prefix)
+	if err != nil {
+		log.Fatalf("Rekey failed after %d objects: %v", rewrapped, err)
+	}
+	log.Printf("Rekey complete: prefix=%s rewrapped=%d key_id=%s", prefix, rewrapped, cfg.Storage.EncryptionKeyID)
+}
+
+// rekey 重新封装 prefix 下全部对象的数据密钥，返回变更数量
+func rekey(ctx context.Context, cfg config.StorageConfig, prefix string) (int, error) {
+	st, err := storage.New(cfg)
+	if err != nil {
+		return 0, err
+	}
+	encrypted, ok := st.(*storage.Encrypted)
+	if !ok {
+		return 0, errEncryptionDisabled
+	}
+	return encrypted.RewrapPrefix(ctx, prefix)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/zhenglizhi/policy-fit/internal/config"
+	"github.com/zhenglizhi/policy-fit/internal/storage"
+)
+
+func masterKey(seed byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{seed}, 32))
+}
+
+func open(t *testing.T, cfg config.StorageConfig) storage.Storage {
+	t.Helper()
+	st, err := storage.New(cfg)
+	if err != nil {
+		t.Fatalf("storage.New: %v", err)
+	}
+	return st
+}
+
+func TestRekey(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	before := config.StorageConfig{Type: "local", Path: dir, EncryptionKey: masterKey(1), EncryptionKeyID: "k1"}
+	rotated := config.StorageConfig{Type: "local", Path: dir, EncryptionKey: masterKey(2), EncryptionKeyID: "k2", EncryptionOldKeys: "k1:" + masterKey(1)}
+	after := config.StorageConfig{Type: "local", Path: dir, EncryptionKey: masterKey(2), EncryptionKeyID: "k2"}
+
+	objects := map[string]string{
+		"tasks/1/report/a.pdf": "report",
+		"tasks/1/policy/b.pdf": "policy",
+		"tasks/2/report/c.pdf": "other task",
+	}
+	st := open(t, before)
+	for key, content := range objects {
+		if _, err := st.Put(ctx, key, strings.NewReader(content)); err != nil {
+			t.Fatalf("Put(%s): %v", key, err)
+		}
+	}
+
+	n, err := rekey(ctx, rotated, defaultPrefix)
+	if err != nil || n != len(objects) {
+		t.Fatalf("rekey = %d, %v, want %d", n, err, len(objects))
+	}
+	if n, err := rekey(ctx, rotated, defaultPrefix); err != nil || n != 0 {
+		t.Fatalf("second rekey = %d, %v, want 0", n, err)
+	}
+
+	// 旧主密钥下线后对象仍可读
+	st = open(t, after)
+	for key, want := range objects {
+		rc, err := st.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("Get(%s) after rekey: %v", key, err)
+		}
+		got, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil || string(got) != want {
+			t.Fatalf("Get(%s) = %q, %v, want %q", key, got, err, want)
+		}
+	}
+}
+
+func TestRekeyWithoutOldKey(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	st := open(t, config.StorageConfig{Type: "local", Path: dir, EncryptionKey: masterKey(1), EncryptionKeyID: "k1"})
+	if _, err := st.Put(ctx, "tasks/1/report/a.pdf", strings.NewReader("report")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := rekey(ctx, config.StorageConfig{Type: "local", Path: dir, EncryptionKey: masterKey(2), EncryptionKeyID: "k2"}, defaultPrefix)
+	if !errors.Is(err, storage.ErrDecrypt) {
+		t.Fatalf("rekey without the old key: err = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestRekeyRequiresEncryption(t *testing.T) {
+	_, err := rekey(context.Background(), config.StorageConfig{Type: "local", Path: t.TempDir()}, defaultPrefix)
+	if !errors.Is(err, errEncryptionDisabled) {
+		t.Fatalf("err = %v, want errEncryptionDisabled", err)
+	}
+}
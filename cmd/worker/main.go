@@ -8,7 +8,16 @@ import (
 	"syscall"
 
 	"github.com/zhenglizhi/policy-fit/internal/config"
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/extract"
 	"github.com/zhenglizhi/policy-fit/internal/jobs"
+	"github.com/zhenglizhi/policy-fit/internal/llm"
+	"github.com/zhenglizhi/policy-fit/internal/parser"
+	"github.com/zhenglizhi/policy-fit/internal/prefilter"
+	"github.com/zhenglizhi/policy-fit/internal/repository"
+	"github.com/zhenglizhi/policy-fit/internal/ruleengine"
+	"github.com/zhenglizhi/policy-fit/internal/service"
+	"github.com/zhenglizhi/policy-fit/internal/storage"
 	"github.com/zhenglizhi/policy-fit/pkg/logger"
 )
 
@@ -19,18 +28,93 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	// 初始化日志
-	logger.Init(cfg.Log.Level, cfg.Log.Format)
+	// 初始化日志，规则已在加载配置时校验
+	redactRules, _ := cfg.Log.RedactRules()
+	logger.InitWithRules(cfg.Log.Level, cfg.Log.Format, redactRules)
 	defer logger.Sync()
 
+	// 加载风险规则，条件表达式有误时拒绝启动
+	rules, err := ruleengine.Load(cfg.Rules.TopicsFile)
+	if err != nil {
+		logger.Fatal("Failed to load rules", "file", cfg.Rules.TopicsFile, "error", err)
+	}
+
+	// 初始化数据库
+	db, err := repository.OpenPostgres(cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", "error", err)
+	}
+	defer db.Close()
+
+	// 初始化 Redis
+	rdb, err := repository.OpenRedis(context.Background(), cfg.Redis)
+	if err != nil {
+		logger.Fatal("Failed to connect to redis", "error", err)
+	}
+	defer rdb.Close()
+
+	// 初始化对象存储、解析器与 LLM 客户端
+	objectStorage, err := storage.New(cfg.Storage)
+	if err != nil {
+		logger.Fatal("Failed to initialize storage", "error", err)
+	}
+	pdfParser, err := parser.New(cfg.Parser)
+	if err != nil {
+		logger.Fatal("Failed to initialize parser", "error", err)
+	}
+	llmClient, err := llm.New(cfg.LLM)
+	if err != nil {
+		logger.Fatal("Failed to initialize llm client", "error", err)
+	}
+
+	store := repository.NewPostgresStore(db)
+	queue := jobs.NewQueue(rdb)
+	taskService := service.NewTaskService(store, queue)
+	documentService := service.NewDocumentService(store, objectStorage, cfg.Upload.MaxBytes())
+	extractor := extract.NewExtractor(llmClient, prefilter.New(rules.Keywords(), cfg.Extract.ContextParagraphs))
+	reports := service.NewReportService(rules)
+
 	// 创建 Worker
-	worker := jobs.NewWorker(cfg)
+	worker := jobs.NewWorker(cfg, taskService, queue)
+	worker.Handle(domain.TaskStatusParsing, func(ctx context.Context, run *jobs.Run) (interface{}, error) {
+		return documentService.ParseDocuments(ctx, run.Task.ID, pdfParser)
+	})
+	worker.Handle(domain.TaskStatusExtracting, func(ctx context.Context, run *jobs.Run) (interface{}, error) {
+		parsed, err := documentService.LoadParseResults(ctx, run.Task.ID)
+		if err != nil {
+			return nil, err
+		}
+		docs := make([]extract.Document, len(parsed))
+		for i, p := range parsed {
+			docs[i] = extract.Document{ID: p.Document.ID, Type: p.Document.DocType, Result: p.Result}
+		}
+		out, err := extractor.Run(ctx, docs)
+		if err == nil {
+			return out, nil
+		}
+		// 最后一次尝试时 LLM 仍不可用，降级为关键词抽取，结果置信度低，不会给出红色结论
+		if run.LastAttempt() && llm.IsUnavailable(err) {
+			logger.FromContext(ctx).Warn("LLM unavailable on last attempt, falling back to keyword extraction", "error", err)
+			return extractor.RunKeywordOnly(ctx, docs)
+		}
+		return nil, err
+	})
+	worker.Handle(domain.TaskStatusMatching, func(ctx context.Context, run *jobs.Run) (interface{}, error) {
+		var facts extract.Output
+		if err := run.Output(ctx, domain.TaskStatusExtracting, &facts); err != nil {
+			return nil, err
+		}
+		// 报告随检查点在同一事务中写入风险发现与风险摘要
+		return reports.Generate(ctx, run.Task.ID, &facts), nil
+	})
 
 	// 启动 Worker
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		logger.Info("Starting worker", "concurrency", cfg.Worker.Concurrency)
 		if err := worker.Start(ctx); err != nil {
 			logger.Fatal("Worker failed", "error", err)
@@ -42,8 +126,11 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	logger.Info("Shutting down worker...")
+	// 停止领取新消息，在途任务最多再执行 WORKER_DRAIN_TIMEOUT，
+	// 超时未完成的任务归还队列，重启后从检查点继续
+	logger.Info("Shutting down worker, draining in-flight jobs...", "drain_timeout", cfg.Worker.DrainTimeout)
 	cancel()
+	<-done
 
 	logger.Info("Worker exited")
 }
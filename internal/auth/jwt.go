@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken token 格式、算法、签名或声明不合法
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrTokenExpired token 已过期
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// algHS256 唯一接受的签名算法，拒绝 none 与非对称算法，防止算法混淆
+const algHS256 = "HS256"
+
+var b64 = base64.RawURLEncoding
+
+// token 用途，写入 use 声明；刷新 token 不能用于访问接口
+const (
+	UseAccess  = "access"
+	UseRefresh = "refresh"
+)
+
+// Claims token 中使用的声明，sub 为用户 ID 的十进制字符串
+type Claims struct {
+	UserID int64
+	// Use token 用途，未携带 use 声明的 token 视为访问 token
+	Use       string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+
+type payload struct {
+	Sub string `json:"sub"`
+	Exp *int64 `json:"exp"`
+	Nbf *int64 `json:"nbf,omitempty"`
+	Iat *int64 `json:"iat,omitempty"`
+	Use string `json:"use,omitempty"`
+}
+
+// JWT HS256 token 签发与校验。current 用于签发，轮换后旧密钥继续用于校验，
+// 直到以其签发的 token 全部过期后再从配置中移除。
+type JWT struct {
+	current string
+	keys    map[string][]byte
+	skew    time.Duration
+}
+
+// NewJWT 创建 token 签发与校验器，keys 必须包含 current，skew 为校验时间声明时容忍的时钟偏差
+func NewJWT(current string, keys map[string][]byte, skew time.Duration) (*JWT, error) {
+	if len(keys[current]) == 0 {
+		return nil, fmt.Errorf("current signing key %q not found", current)
+	}
+	return &JWT{current: current, keys: keys, skew: skew}, nil
+}
+
+// Sign 使用当前密钥签发 token，头部携带 kid
+func (j *JWT) Sign(c Claims) (string, error) {
+	iat, exp := c.IssuedAt.Unix(), c.ExpiresAt.Unix()
+	h, err := json.Marshal(header{Alg: algHS256, Typ: "JWT", Kid: j.current})
+	if err != nil {
+		return "", err
+	}
+	p, err := json.Marshal(payload{Sub: strconv.FormatInt(c.UserID, 10), Exp: &exp, Iat: &iat, Use: c.Use})
+	if err != nil {
+		return "", err
+	}
+	signing := b64.EncodeToString(h) + "." + b64.EncodeToString(p)
+	return signing + "." + b64.EncodeToString(sign(j.keys[j.current], signing)), nil
+}
+
+// Verify 校验签名与时间声明并返回声明。头部没有 kid 时使用当前密钥；
+// exp 必填，exp、nbf、iat 均按 skew 放宽。
+func (j *JWT) Verify(token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	if h.Alg != algHS256 {
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, h.Alg)
+	}
+	kid := h.Kid
+	if kid == "" {
+		kid = j.current
+	}
+	key, ok := j.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown kid %q", ErrInvalidToken, h.Kid)
+	}
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, sign(key, parts[0]+"."+parts[1])) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+	}
+
+	var p payload
+	if err := decodeSegment(parts[1], &p); err != nil {
+		return nil, fmt.Errorf("%w: malformed payload", ErrInvalidToken)
+	}
+	userID, err := strconv.ParseInt(p.Sub, 10, 64)
+	if err != nil || userID <= 0 {
+		return nil, fmt.Errorf("%w: invalid sub", ErrInvalidToken)
+	}
+	if p.Exp == nil {
+		return nil, fmt.Errorf("%w: exp is required", ErrInvalidToken)
+	}
+	exp := time.Unix(*p.Exp, 0)
+	if !now.Before(exp.Add(j.skew)) {
+		return nil, ErrTokenExpired
+	}
+	if p.Nbf != nil && now.Add(j.skew).Before(time.Unix(*p.Nbf, 0)) {
+		return nil, fmt.Errorf("%w: token not yet valid", ErrInvalidToken)
+	}
+	claims := &Claims{UserID: userID, Use: p.Use, ExpiresAt: exp}
+	if claims.Use == "" {
+		claims.Use = UseAccess
+	}
+	if p.Iat != nil {
+		claims.IssuedAt = time.Unix(*p.Iat, 0)
+		if now.Add(j.skew).Before(claims.IssuedAt) {
+			return nil, fmt.Errorf("%w: iat is in the future", ErrInvalidToken)
+		}
+	}
+	return claims, nil
+}
+
+func sign(key []byte, signing string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signing))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	data, err := b64.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testKeys = map[string][]byte{
+	"k1": []byte("first-signing-key-0123456789abcdef"),
+	"k2": []byte("second-signing-key-0123456789abcde"),
+}
+
+func newJWT(t *testing.T, current string, keys map[string][]byte) *JWT {
+	t.Helper()
+	j, err := NewJWT(current, keys, 30*time.Second)
+	if err != nil {
+		t.Fatalf("NewJWT: %v", err)
+	}
+	return j
+}
+
+// craft 用指定头部、载荷与密钥构造 token
+func craft(t *testing.T, h header, p payload, key []byte) string {
+	t.Helper()
+	hb, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pb, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signing := b64.EncodeToString(hb) + "." + b64.EncodeToString(pb)
+	return signing + "." + b64.EncodeToString(sign(key, signing))
+}
+
+func unix(t time.Time) *int64 {
+	v := t.Unix()
+	return &v
+}
+
+func TestNewJWTRequiresCurrentKey(t *testing.T) {
+	if _, err := NewJWT("k3", testKeys, 0); err == nil {
+		t.Fatal("NewJWT accepted a missing current key")
+	}
+}
+
+func TestJWTRoundTrip(t *testing.T) {
+	now := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	j := newJWT(t, "k1", testKeys)
+	token, err := j.Sign(Claims{UserID: 42, Use: UseRefresh, IssuedAt: now, ExpiresAt: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	claims, err := j.Verify(token, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.UserID != 42 || claims.Use != UseRefresh || !claims.IssuedAt.Equal(now) || !claims.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("claims = %+v", claims)
+	}
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	now := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	claims := Claims{UserID: 7, IssuedAt: now, ExpiresAt: now.Add(time.Hour)}
+
+	before := newJWT(t, "k1", map[string][]byte{"k1": testKeys["k1"]})
+	oldToken, err := before.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 轮换：k2 签发，k1 保留用于校验
+	rotated := newJWT(t, "k2", testKeys)
+	newToken, err := rotated.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rotated.Verify(oldToken, now); err != nil {
+		t.Errorf("token signed with the previous key rejected after rotation: %v", err)
+	}
+	if _, err := rotated.Verify(newToken, now); err != nil {
+		t.Errorf("token signed with the current key rejected: %v", err)
+	}
+	if _, err := before.Verify(newToken, now); !errors.Is(err, ErrInvalidToken) || !strings.Contains(err.Error(), `unknown kid "k2"`) {
+		t.Errorf("old verifier: err = %v, want unknown kid", err)
+	}
+
+	// 旧密钥移除后，以其签发的 token 失效
+	retired := newJWT(t, "k2", map[string][]byte{"k2": testKeys["k2"]})
+	if _, err := retired.Verify(oldToken, now); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token signed with a retired key: err = %v, want ErrInvalidToken", err)
+	}
+
+	// 没有 kid 的 token 按当前密钥校验
+	noKid := craft(t, header{Alg: algHS256}, payload{Sub: "7", Exp: unix(now.Add(time.Hour))}, testKeys["k2"])
+	if _, err := rotated.Verify(noKid, now); err != nil {
+		t.Errorf("token without kid: %v", err)
+	}
+	// kid 与签名密钥不一致
+	wrongKid := craft(t, header{Alg: algHS256, Kid: "k1"}, payload{Sub: "7", Exp: unix(now.Add(time.Hour))}, testKeys["k2"])
+	if _, err := rotated.Verify(wrongKid, now); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("kid of another key: err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestJWTTimeClaims(t *testing.T) {
+	now := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	j := newJWT(t, "k1", testKeys)
+	h := header{Alg: algHS256, Kid: "k1"}
+	tests := []struct {
+		name    string
+		payload payload
+		wantErr error
+	}{
+		{"valid", payload{Sub: "1", Exp: unix(now.Add(time.Minute))}, nil},
+		{"expired within skew", payload{Sub: "1", Exp: unix(now.Add(-29 * time.Second))}, nil},
+		{"expired at skew", payload{Sub: "1", Exp: unix(now.Add(-30 * time.Second))}, ErrTokenExpired},
+		{"expired", payload{Sub: "1", Exp: unix(now.Add(-time.Hour))}, ErrTokenExpired},
+		{"missing exp", payload{Sub: "1"}, ErrInvalidToken},
+		{"nbf within skew", payload{Sub: "1", Exp: unix(now.Add(time.Hour)), Nbf: unix(now.Add(30 * time.Second))}, nil},
+		{"nbf in the future", payload{Sub: "1", Exp: unix(now.Add(time.Hour)), Nbf: unix(now.Add(31 * time.Second))}, ErrInvalidToken},
+		{"iat within skew", payload{Sub: "1", Exp: unix(now.Add(time.Hour)), Iat: unix(now.Add(30 * time.Second))}, nil},
+		{"iat in the future", payload{Sub: "1", Exp: unix(now.Add(time.Hour)), Iat: unix(now.Add(time.Minute))}, ErrInvalidToken},
+		{"invalid sub", payload{Sub: "abc", Exp: unix(now.Add(time.Hour))}, ErrInvalidToken},
+		{"non-positive sub", payload{Sub: "0", Exp: unix(now.Add(time.Hour))}, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := j.Verify(craft(t, h, tt.payload, testKeys["k1"]), now)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify: err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWTRejectsMalformed(t *testing.T) {
+	now := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	j := newJWT(t, "k1", testKeys)
+	p := payload{Sub: "1", Exp: unix(now.Add(time.Hour))}
+	valid := craft(t, header{Alg: algHS256, Kid: "k1"}, p, testKeys["k1"])
+	parts := strings.Split(valid, ".")
+
+	tests := map[string]string{
+		"two segments":     parts[0] + "." + parts[1],
+		"alg none":         craft(t, header{Alg: "none", Kid: "k1"}, p, nil),
+		"alg HS512":        craft(t, header{Alg: "HS512", Kid: "k1"}, p, testKeys["k1"]),
+		"tampered payload": parts[0] + "." + b64.EncodeToString([]byte(`{"sub":"2","exp":9999999999}`)) + "." + parts[2],
+		"bad signature":    parts[0] + "." + parts[1] + ".AAAA",
+		"header not json":  b64.EncodeToString([]byte("{")) + "." + parts[1] + "." + parts[2],
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := j.Verify(token, now); !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("Verify: err = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	otpKeyPrefix   = "policyfit:otp:"
+	limitKeyPrefix = "policyfit:rate:"
+)
+
+// OTP 校验结果
+const (
+	OTPMatched = iota
+	// OTPMismatch 验证码错误，仍有剩余次数
+	OTPMismatch
+	// OTPExpired 验证码不存在、已过期或次数已用尽
+	OTPExpired
+)
+
+// verifyScript 原子地校验验证码摘要：匹配或次数用尽时删除，否则累加错误次数。
+// 返回 0 匹配、1 不匹配、2 不存在或已作废。
+var verifyScript = redis.NewScript(`
+local digest = redis.call("HGET", KEYS[1], "digest")
+if not digest then
+	return 2
+end
+if digest == ARGV[1] then
+	redis.call("DEL", KEYS[1])
+	return 0
+end
+local attempts = redis.call("HINCRBY", KEYS[1], "attempts", 1)
+if attempts >= tonumber(ARGV[2]) then
+	redis.call("DEL", KEYS[1])
+	return 2
+end
+return 1`)
+
+// limitScript 固定窗口计数，窗口内首次计数时设置过期；返回当前计数与窗口剩余毫秒
+var limitScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return {n, redis.call("PTTL", KEYS[1])}`)
+
+// OTPStore 基于 Redis 的验证码与限流存储，验证码只保存摘要
+type OTPStore struct {
+	rdb *redis.Client
+}
+
+// NewOTPStore 创建验证码存储
+func NewOTPStore(rdb *redis.Client) *OTPStore {
+	return &OTPStore{rdb: rdb}
+}
+
+// Save 保存验证码摘要并重置错误次数，覆盖该手机号尚未使用的旧验证码
+func (s *OTPStore) Save(ctx context.Context, phone, digest string, ttl time.Duration) error {
+	key := otpKeyPrefix + phone
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.HSet(ctx, key, "digest", digest, "attempts", 0)
+		pipe.PExpire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+// Verify 校验验证码摘要，错误次数达到 maxAttempts 时验证码作废
+func (s *OTPStore) Verify(ctx context.Context, phone, digest string, maxAttempts int) (int, error) {
+	return verifyScript.Run(ctx, s.rdb, []string{otpKeyPrefix + phone}, digest, maxAttempts).Int()
+}
+
+// Exceeded 查询 key 的窗口计数是否已达到 limit，不累加计数；达到时返回窗口剩余时长
+func (s *OTPStore) Exceeded(ctx context.Context, key string, limit int) (bool, time.Duration, error) {
+	key = limitKeyPrefix + key
+	n, err := s.rdb.Get(ctx, key).Int()
+	if errors.Is(err, redis.Nil) {
+		return false, 0, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	if n < limit {
+		return false, 0, nil
+	}
+	ttl, err := s.rdb.PTTL(ctx, key).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	return true, ttl, nil
+}
+
+// Allow 对 key 做固定窗口计数，超过 limit 时返回 false 与窗口剩余时长
+func (s *OTPStore) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	res, err := limitScript.Run(ctx, s.rdb, []string{limitKeyPrefix + key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if res[0] > int64(limit) {
+		return false, time.Duration(res[1]) * time.Millisecond, nil
+	}
+	return true, 0, nil
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/spf13/viper"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
 )
 
 type Config struct {
@@ -17,9 +19,13 @@ type Config struct {
 	Database   DatabaseConfig
 	Redis      RedisConfig
 	Storage    StorageConfig
+	Upload     UploadConfig
 	LLM        LLMConfig
 	Parser     ParserConfig
+	Rules      RulesConfig
+	Extract    ExtractConfig
 	Security   SecurityConfig
+	OTP        OTPConfig
 	Log        LogConfig
 	Worker     WorkerConfig
 }
@@ -27,6 +33,19 @@ type Config struct {
 type ServerConfig struct {
 	Port int
 	Mode string
+	// TrustedProxies 可信反向代理的 IP 或 CIDR，逗号分隔；为空时不信任 X-Forwarded-For，按连接地址识别客户端
+	TrustedProxies string
+}
+
+// TrustedProxyList 解析可信代理列表，未配置时返回 nil
+func (c ServerConfig) TrustedProxyList() []string {
+	var proxies []string
+	for _, entry := range strings.Split(c.TrustedProxies, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			proxies = append(proxies, entry)
+		}
+	}
+	return proxies
 }
 
 type DatabaseConfig struct {
@@ -49,9 +68,73 @@ type StorageConfig struct {
 	Type      string
 	Path      string
 	Endpoint  string
+	Region    string
 	Bucket    string
 	AccessKey string
 	SecretKey string
+
+	// EncryptionKey 应用层加密主密钥（base64 编码的 32 字节），为空时不加密
+	EncryptionKey string
+	// EncryptionKeyID 当前主密钥标识，写入每个对象的数据密钥信封
+	EncryptionKeyID string
+	// EncryptionOldKeys 轮换前的旧主密钥，格式 "kid:base64,kid:base64"，仅用于解密与重新封装
+	EncryptionOldKeys string
+}
+
+// EncryptionKeys 解析主密钥，返回当前密钥标识与全部密钥（含旧密钥）。
+// 未配置 EncryptionKey 时返回空标识与 nil。
+func (c StorageConfig) EncryptionKeys() (string, map[string][]byte, error) {
+	if strings.TrimSpace(c.EncryptionKey) == "" {
+		return "", nil, nil
+	}
+
+	keys := make(map[string][]byte)
+	current, err := decodeMasterKey(c.EncryptionKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid STORAGE_ENCRYPTION_KEY: %w", err)
+	}
+	keys[c.EncryptionKeyID] = current
+
+	for _, entry := range strings.Split(c.EncryptionOldKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, raw, ok := strings.Cut(entry, ":")
+		if !ok || strings.TrimSpace(kid) == "" {
+			return "", nil, errors.New("invalid STORAGE_ENCRYPTION_OLD_KEYS: expected kid:base64")
+		}
+		kid = strings.TrimSpace(kid)
+		if _, exists := keys[kid]; exists {
+			return "", nil, fmt.Errorf("invalid STORAGE_ENCRYPTION_OLD_KEYS: duplicate key id %s", kid)
+		}
+		key, err := decodeMasterKey(raw)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid STORAGE_ENCRYPTION_OLD_KEYS: key %s: %w", kid, err)
+		}
+		keys[kid] = key
+	}
+	return c.EncryptionKeyID, keys, nil
+}
+
+func decodeMasterKey(raw string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, errors.New("key must be base64 encoded")
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+type UploadConfig struct {
+	MaxSizeMB int
+}
+
+// MaxBytes 单文件上传大小上限（字节）
+func (c UploadConfig) MaxBytes() int64 {
+	return int64(c.MaxSizeMB) << 20
 }
 
 type LLMConfig struct {
@@ -65,22 +148,113 @@ type LLMConfig struct {
 type ParserConfig struct {
 	PDFParser        string
 	PythonServiceURL string
+	// Timeout 单份文档解析超时（秒）
+	Timeout int
+	// ServiceTimeout python-service 单次请求超时（秒）
+	ServiceTimeout int
+	// ServiceMaxRetries python-service 5xx 与网络错误的最大重试次数
+	ServiceMaxRetries int
+}
+
+type RulesConfig struct {
+	// TopicsFile 风险主题规则文件
+	TopicsFile string
+}
+
+type ExtractConfig struct {
+	// ContextParagraphs 关键词命中段落前后各随同发送给 LLM 的上下文段落数
+	ContextParagraphs int
 }
 
 type SecurityConfig struct {
-	JWTSecret         string
+	// JWTSecret 当前 HS256 签名密钥
+	JWTSecret string
+	// JWTKeyID 当前签名密钥标识，写入 token 头部的 kid
+	JWTKeyID string
+	// JWTOldKeys 轮换前的旧签名密钥，格式 "kid:secret,kid:secret"，仅用于校验未过期的旧 token
+	JWTOldKeys string
+	// JWTClockSkew 校验 exp、nbf、iat 时容忍的时钟偏差（秒）
+	JWTClockSkew int
+	// JWTAccessTTL 访问 token 有效期（秒）
+	JWTAccessTTL int
+	// JWTRefreshTTL 刷新 token 有效期（秒）
+	JWTRefreshTTL     int
 	DataRetentionDays int
 }
 
+// JWTKeys 解析签名密钥，返回当前密钥标识与全部密钥（含旧密钥）
+func (c SecurityConfig) JWTKeys() (string, map[string][]byte, error) {
+	keys := map[string][]byte{c.JWTKeyID: []byte(c.JWTSecret)}
+	for _, entry := range strings.Split(c.JWTOldKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, secret, ok := strings.Cut(entry, ":")
+		kid, secret = strings.TrimSpace(kid), strings.TrimSpace(secret)
+		if !ok || kid == "" || secret == "" {
+			return "", nil, errors.New("invalid JWT_OLD_KEYS: expected kid:secret")
+		}
+		if _, exists := keys[kid]; exists {
+			return "", nil, fmt.Errorf("invalid JWT_OLD_KEYS: duplicate key id %s", kid)
+		}
+		keys[kid] = []byte(secret)
+	}
+	return c.JWTKeyID, keys, nil
+}
+
+type OTPConfig struct {
+	// TTL 验证码有效期（秒）
+	TTL int
+	// MaxAttempts 单个验证码允许的校验次数，用尽后验证码作废
+	MaxAttempts int
+	// SendInterval 同一手机号两次发送的最短间隔（秒）
+	SendInterval int
+	// PhoneHourlyLimit 同一手机号每小时最多发送次数
+	PhoneHourlyLimit int
+	// IPHourlyLimit 同一 IP 每小时最多发送次数；登录校验上限为该值乘以 MaxAttempts
+	IPHourlyLimit int
+	// PhoneFailureLimit 同一手机号每小时最多校验失败次数，跨验证码累计
+	PhoneFailureLimit int
+	// Pepper 验证码摘要密钥，与 JWT 签名密钥分开配置
+	Pepper string
+	// SMSProvider 短信发送方式，当前仅支持 log（不实际发送，非生产环境在 debug 日志中输出验证码，生产环境不可用）
+	SMSProvider string
+}
+
 type LogConfig struct {
 	Level  string
 	Format string
+	// RedactKeys 敏感字段脱敏规则，格式 key:mode（hash 或 truncate），逗号分隔
+	RedactKeys string
+	// RedactTruncateLen truncate 模式保留的字符数
+	RedactTruncateLen int
+}
+
+// RedactRules 解析日志脱敏规则
+func (c LogConfig) RedactRules() (logger.Rules, error) {
+	rules, err := logger.ParseRules(c.RedactKeys, c.RedactTruncateLen)
+	if err != nil {
+		return logger.Rules{}, fmt.Errorf("invalid LOG_REDACT_KEYS: %w", err)
+	}
+	return rules, nil
 }
 
 type WorkerConfig struct {
 	Concurrency int
+	// VisibilityTimeout 消息处理超时（秒），超过该时长未确认的消息会被其他消费者认领
+	VisibilityTimeout int
+	// MaxRetries 可重试错误的最大自动重试次数，0 表示不自动重试
+	MaxRetries int
+	// RetryBaseDelay 重试退避基准时长（秒），第 n 次重试约等待 base*2^(n-1)
+	RetryBaseDelay int
+	// DrainTimeout 收到退出信号后等待在途任务完成的时长（秒），超时后中断并从检查点恢复
+	DrainTimeout int
 }
 
+// defaultWorkerMaxRetries 未配置 WORKER_MAX_RETRIES 时的自动重试次数
+const defaultWorkerMaxRetries = 2
+
 func Load() (*Config, error) {
 	appEnv := detectAppEnv()
 	configFile, err := resolveConfigFile(appEnv)
@@ -115,6 +289,11 @@ func loadFromFile(path string, appEnv string, useEnvOverride bool) (*Config, err
 	if appEnv == "" {
 		appEnv = "dev"
 	}
+	// WORKER_MAX_RETRIES=0 表示不自动重试，只有未配置时才使用默认值
+	maxRetries := defaultWorkerMaxRetries
+	if v.IsSet("WORKER_MAX_RETRIES") {
+		maxRetries = v.GetInt("WORKER_MAX_RETRIES")
+	}
 
 	cfg := &Config{
 		AppEnv:     appEnv,
@@ -122,6 +301,8 @@ func loadFromFile(path string, appEnv string, useEnvOverride bool) (*Config, err
 		Server: ServerConfig{
 			Port: v.GetInt("API_PORT"),
 			Mode: v.GetString("GIN_MODE"),
+
+			TrustedProxies: v.GetString("API_TRUSTED_PROXIES"),
 		},
 		Database: DatabaseConfig{
 			Host:     v.GetString("DB_HOST"),
@@ -141,9 +322,17 @@ func loadFromFile(path string, appEnv string, useEnvOverride bool) (*Config, err
 			Type:      v.GetString("STORAGE_TYPE"),
 			Path:      v.GetString("STORAGE_PATH"),
 			Endpoint:  v.GetString("S3_ENDPOINT"),
+			Region:    v.GetString("S3_REGION"),
 			Bucket:    v.GetString("S3_BUCKET"),
 			AccessKey: v.GetString("S3_ACCESS_KEY"),
 			SecretKey: v.GetString("S3_SECRET_KEY"),
+
+			EncryptionKey:     v.GetString("STORAGE_ENCRYPTION_KEY"),
+			EncryptionKeyID:   v.GetString("STORAGE_ENCRYPTION_KEY_ID"),
+			EncryptionOldKeys: v.GetString("STORAGE_ENCRYPTION_OLD_KEYS"),
+		},
+		Upload: UploadConfig{
+			MaxSizeMB: v.GetInt("UPLOAD_MAX_SIZE_MB"),
 		},
 		LLM: LLMConfig{
 			Provider: v.GetString("LLM_PROVIDER"),
@@ -153,19 +342,50 @@ func loadFromFile(path string, appEnv string, useEnvOverride bool) (*Config, err
 			Timeout:  v.GetInt("LLM_TIMEOUT"),
 		},
 		Parser: ParserConfig{
-			PDFParser:        v.GetString("PDF_PARSER"),
-			PythonServiceURL: v.GetString("PYTHON_SERVICE_URL"),
+			PDFParser:         v.GetString("PDF_PARSER"),
+			PythonServiceURL:  v.GetString("PYTHON_SERVICE_URL"),
+			Timeout:           v.GetInt("PDF_PARSE_TIMEOUT"),
+			ServiceTimeout:    v.GetInt("PYTHON_SERVICE_TIMEOUT"),
+			ServiceMaxRetries: v.GetInt("PYTHON_SERVICE_MAX_RETRIES"),
+		},
+		Rules: RulesConfig{
+			TopicsFile: v.GetString("RULES_TOPICS_FILE"),
+		},
+		Extract: ExtractConfig{
+			ContextParagraphs: v.GetInt("EXTRACT_CONTEXT_PARAGRAPHS"),
 		},
 		Security: SecurityConfig{
 			JWTSecret:         v.GetString("JWT_SECRET"),
+			JWTKeyID:          v.GetString("JWT_KEY_ID"),
+			JWTOldKeys:        v.GetString("JWT_OLD_KEYS"),
+			JWTClockSkew:      v.GetInt("JWT_CLOCK_SKEW"),
+			JWTAccessTTL:      v.GetInt("JWT_ACCESS_TTL"),
+			JWTRefreshTTL:     v.GetInt("JWT_REFRESH_TTL"),
 			DataRetentionDays: v.GetInt("DATA_RETENTION_DAYS"),
 		},
+		OTP: OTPConfig{
+			TTL:               v.GetInt("OTP_TTL"),
+			MaxAttempts:       v.GetInt("OTP_MAX_ATTEMPTS"),
+			SendInterval:      v.GetInt("OTP_SEND_INTERVAL"),
+			PhoneHourlyLimit:  v.GetInt("OTP_PHONE_HOURLY_LIMIT"),
+			IPHourlyLimit:     v.GetInt("OTP_IP_HOURLY_LIMIT"),
+			PhoneFailureLimit: v.GetInt("OTP_PHONE_FAILURE_LIMIT"),
+			Pepper:            v.GetString("OTP_PEPPER"),
+			SMSProvider:       v.GetString("SMS_PROVIDER"),
+		},
 		Log: LogConfig{
 			Level:  v.GetString("LOG_LEVEL"),
 			Format: v.GetString("LOG_FORMAT"),
+
+			RedactKeys:        v.GetString("LOG_REDACT_KEYS"),
+			RedactTruncateLen: v.GetInt("LOG_REDACT_TRUNCATE_LEN"),
 		},
 		Worker: WorkerConfig{
-			Concurrency: v.GetInt("WORKER_CONCURRENCY"),
+			Concurrency:       v.GetInt("WORKER_CONCURRENCY"),
+			VisibilityTimeout: v.GetInt("WORKER_VISIBILITY_TIMEOUT"),
+			MaxRetries:        maxRetries,
+			RetryBaseDelay:    v.GetInt("WORKER_RETRY_BASE_DELAY"),
+			DrainTimeout:      v.GetInt("WORKER_DRAIN_TIMEOUT"),
 		},
 	}
 
@@ -202,24 +422,96 @@ func applyDefaults(cfg *Config) {
 	if cfg.Storage.Path == "" {
 		cfg.Storage.Path = "./storage"
 	}
+	if cfg.Storage.Region == "" {
+		cfg.Storage.Region = "us-east-1"
+	}
+	if cfg.Storage.EncryptionKeyID == "" {
+		cfg.Storage.EncryptionKeyID = "default"
+	}
+	if cfg.Upload.MaxSizeMB == 0 {
+		cfg.Upload.MaxSizeMB = 30
+	}
 	if cfg.LLM.Timeout == 0 {
 		cfg.LLM.Timeout = 120
 	}
 	if cfg.Parser.PDFParser == "" {
 		cfg.Parser.PDFParser = "pdftotext"
 	}
+	if cfg.Parser.Timeout == 0 {
+		cfg.Parser.Timeout = 60
+	}
+	if cfg.Parser.ServiceTimeout == 0 {
+		cfg.Parser.ServiceTimeout = 20
+	}
+	if cfg.Parser.ServiceMaxRetries == 0 {
+		cfg.Parser.ServiceMaxRetries = 2
+	}
+	if cfg.Rules.TopicsFile == "" {
+		cfg.Rules.TopicsFile = "configs/topics.yaml"
+	}
+	if cfg.Extract.ContextParagraphs == 0 {
+		cfg.Extract.ContextParagraphs = 1
+	}
+	if cfg.Security.JWTKeyID == "" {
+		cfg.Security.JWTKeyID = "default"
+	}
+	if cfg.Security.JWTClockSkew == 0 {
+		cfg.Security.JWTClockSkew = 60
+	}
+	if cfg.Security.JWTAccessTTL == 0 {
+		cfg.Security.JWTAccessTTL = 1800
+	}
+	if cfg.Security.JWTRefreshTTL == 0 {
+		cfg.Security.JWTRefreshTTL = 30 * 24 * 3600
+	}
+	if cfg.OTP.TTL == 0 {
+		cfg.OTP.TTL = 300
+	}
+	if cfg.OTP.MaxAttempts == 0 {
+		cfg.OTP.MaxAttempts = 5
+	}
+	if cfg.OTP.SendInterval == 0 {
+		cfg.OTP.SendInterval = 60
+	}
+	if cfg.OTP.PhoneHourlyLimit == 0 {
+		cfg.OTP.PhoneHourlyLimit = 5
+	}
+	if cfg.OTP.IPHourlyLimit == 0 {
+		cfg.OTP.IPHourlyLimit = 20
+	}
+	if cfg.OTP.PhoneFailureLimit == 0 {
+		cfg.OTP.PhoneFailureLimit = 10
+	}
+	if cfg.OTP.SMSProvider == "" {
+		cfg.OTP.SMSProvider = "log"
+	}
 	if cfg.Security.DataRetentionDays == 0 {
 		cfg.Security.DataRetentionDays = 30
 	}
 	if cfg.Worker.Concurrency == 0 {
 		cfg.Worker.Concurrency = 5
 	}
+	if cfg.Worker.VisibilityTimeout == 0 {
+		cfg.Worker.VisibilityTimeout = 60
+	}
+	if cfg.Worker.RetryBaseDelay == 0 {
+		cfg.Worker.RetryBaseDelay = 10
+	}
+	if cfg.Worker.DrainTimeout == 0 {
+		cfg.Worker.DrainTimeout = 25
+	}
 	if cfg.Log.Level == "" {
 		cfg.Log.Level = "info"
 	}
 	if cfg.Log.Format == "" {
 		cfg.Log.Format = "json"
 	}
+	if cfg.Log.RedactKeys == "" {
+		cfg.Log.RedactKeys = "parsed_text:hash,evidence:hash,storage_key:truncate"
+	}
+	if cfg.Log.RedactTruncateLen == 0 {
+		cfg.Log.RedactTruncateLen = logger.DefaultTruncateLen
+	}
 }
 
 func (c *Config) Validate() error {
@@ -232,6 +524,15 @@ func (c *Config) Validate() error {
 	validateRequired(&missing, c.Redis.Host, "REDIS_HOST")
 	validateRequiredInt(&missing, c.Redis.Port, "REDIS_PORT")
 	validateRequired(&missing, c.Security.JWTSecret, "JWT_SECRET")
+	validateRequiredInt(&missing, c.Security.JWTClockSkew, "JWT_CLOCK_SKEW")
+	validateRequiredInt(&missing, c.Security.JWTAccessTTL, "JWT_ACCESS_TTL")
+	validateRequiredInt(&missing, c.Security.JWTRefreshTTL, "JWT_REFRESH_TTL")
+	validateRequiredInt(&missing, c.OTP.TTL, "OTP_TTL")
+	validateRequiredInt(&missing, c.OTP.MaxAttempts, "OTP_MAX_ATTEMPTS")
+	validateRequiredInt(&missing, c.OTP.SendInterval, "OTP_SEND_INTERVAL")
+	validateRequiredInt(&missing, c.OTP.PhoneHourlyLimit, "OTP_PHONE_HOURLY_LIMIT")
+	validateRequiredInt(&missing, c.OTP.IPHourlyLimit, "OTP_IP_HOURLY_LIMIT")
+	validateRequiredInt(&missing, c.OTP.PhoneFailureLimit, "OTP_PHONE_FAILURE_LIMIT")
 	validateRequiredInt(&missing, c.Security.DataRetentionDays, "DATA_RETENTION_DAYS")
 	validateRequired(&missing, c.LLM.Provider, "LLM_PROVIDER")
 	validateRequired(&missing, c.LLM.APIKey, "LLM_API_KEY")
@@ -239,8 +540,16 @@ func (c *Config) Validate() error {
 	validateRequired(&missing, c.LLM.Model, "LLM_MODEL")
 	validateRequiredInt(&missing, c.LLM.Timeout, "LLM_TIMEOUT")
 	validateRequired(&missing, c.Parser.PDFParser, "PDF_PARSER")
+	validateRequiredInt(&missing, c.Parser.Timeout, "PDF_PARSE_TIMEOUT")
+	validateRequired(&missing, c.Rules.TopicsFile, "RULES_TOPICS_FILE")
+	validateRequiredInt(&missing, c.Extract.ContextParagraphs, "EXTRACT_CONTEXT_PARAGRAPHS")
 	validateRequiredInt(&missing, c.Server.Port, "API_PORT")
 	validateRequiredInt(&missing, c.Worker.Concurrency, "WORKER_CONCURRENCY")
+	validateRequiredInt(&missing, c.Worker.VisibilityTimeout, "WORKER_VISIBILITY_TIMEOUT")
+	validateRequiredInt(&missing, c.Worker.RetryBaseDelay, "WORKER_RETRY_BASE_DELAY")
+	validateRequiredInt(&missing, c.Worker.DrainTimeout, "WORKER_DRAIN_TIMEOUT")
+	validateRequiredInt(&missing, c.Upload.MaxSizeMB, "UPLOAD_MAX_SIZE_MB")
+	validateRequiredInt(&missing, c.Log.RedactTruncateLen, "LOG_REDACT_TRUNCATE_LEN")
 
 	switch c.Storage.Type {
 	case "local":
@@ -254,10 +563,42 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid STORAGE_TYPE: %s (allowed: local, s3)", c.Storage.Type)
 	}
 
+	// 体检报告属于敏感健康数据，生产环境必须开启应用层加密
+	if c.AppEnv == "prod" {
+		validateRequired(&missing, c.Storage.EncryptionKey, "STORAGE_ENCRYPTION_KEY")
+	}
+	if _, _, err := c.Storage.EncryptionKeys(); err != nil {
+		return err
+	}
+	if _, _, err := c.Security.JWTKeys(); err != nil {
+		return err
+	}
+	if _, err := c.Log.RedactRules(); err != nil {
+		return err
+	}
+
+	if c.Worker.MaxRetries < 0 {
+		return fmt.Errorf("invalid WORKER_MAX_RETRIES: %d (must be 0 or greater)", c.Worker.MaxRetries)
+	}
+
+	switch c.LLM.Provider {
+	case "", "openai", "anthropic":
+	default:
+		return fmt.Errorf("invalid LLM_PROVIDER: %s (allowed: openai, anthropic)", c.LLM.Provider)
+	}
+
+	switch c.OTP.SMSProvider {
+	case "log":
+	default:
+		return fmt.Errorf("invalid SMS_PROVIDER: %s (allowed: log)", c.OTP.SMSProvider)
+	}
+
 	switch c.Parser.PDFParser {
 	case "pdftotext":
 	case "python-service":
 		validateRequired(&missing, c.Parser.PythonServiceURL, "PYTHON_SERVICE_URL")
+		validateRequiredInt(&missing, c.Parser.ServiceTimeout, "PYTHON_SERVICE_TIMEOUT")
+		validateRequiredInt(&missing, c.Parser.ServiceMaxRetries, "PYTHON_SERVICE_MAX_RETRIES")
 	default:
 		return fmt.Errorf("invalid PDF_PARSER: %s (allowed: pdftotext, python-service)", c.Parser.PDFParser)
 	}
@@ -275,6 +616,21 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// ValidateAuth 校验手机号登录所需配置，仅在构建 AuthService 的 API 进程中调用，
+// worker 等进程不发送验证码，无需配置 OTP_PEPPER 与短信通道
+func (c *Config) ValidateAuth() error {
+	if strings.TrimSpace(c.OTP.Pepper) == "" {
+		return errors.New("missing required config: OTP_PEPPER")
+	}
+	if c.OTP.Pepper == c.Security.JWTSecret {
+		return errors.New("invalid OTP_PEPPER: must differ from JWT_SECRET")
+	}
+	if c.AppEnv == "prod" && c.OTP.SMSProvider == "log" {
+		return errors.New("invalid SMS_PROVIDER: log is not allowed when APP_ENV=prod")
+	}
+	return nil
+}
+
 func validateRequired(missing *[]string, value, key string) {
 	if strings.TrimSpace(value) == "" {
 		*missing = append(*missing, key)
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// baseEnv 通过校验所需的最小配置
+func baseEnv() map[string]string {
+	return map[string]string{
+		"DB_HOST":                "localhost",
+		"DB_PORT":                "5432",
+		"DB_USER":                "policyfit",
+		"DB_NAME":                "policyfit",
+		"REDIS_HOST":             "localhost",
+		"REDIS_PORT":             "6379",
+		"JWT_SECRET":             "jwt-secret",
+		"OTP_PEPPER":             "otp-pepper",
+		"LLM_PROVIDER":           "openai",
+		"LLM_API_KEY":            "key",
+		"LLM_BASE_URL":           "https://api.openai.com/v1",
+		"LLM_MODEL":              "gpt-4o",
+		"STORAGE_ENCRYPTION_KEY": base64.StdEncoding.EncodeToString(make([]byte, 32)),
+	}
+}
+
+// load 将 env 写入临时配置文件并加载，值为空的键不写入
+func load(t *testing.T, env map[string]string) (*Config, error) {
+	t.Helper()
+	var lines []string
+	for k, v := range env {
+		if v != "" {
+			lines = append(lines, k+"="+v)
+		}
+	}
+	sort.Strings(lines)
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return loadFromFile(path, "", false)
+}
+
+func TestValidateAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"dev with log sender", map[string]string{}, ""},
+		{"missing pepper", map[string]string{"OTP_PEPPER": ""}, "OTP_PEPPER"},
+		{"pepper equals jwt secret", map[string]string{"OTP_PEPPER": "jwt-secret"}, "must differ from JWT_SECRET"},
+		{"log sender in prod", map[string]string{"APP_ENV": "prod"}, "log is not allowed when APP_ENV=prod"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := baseEnv()
+			for k, v := range tt.env {
+				env[k] = v
+			}
+			// 登录配置只由 API 校验，worker 等进程照常加载
+			cfg, err := load(t, env)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			err = cfg.ValidateAuth()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateAuth: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateAuth: err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTrustedProxyList(t *testing.T) {
+	if got := (ServerConfig{}).TrustedProxyList(); got != nil {
+		t.Errorf("empty: %v, want nil (trust no proxy)", got)
+	}
+	got := ServerConfig{TrustedProxies: " 10.0.0.1, ,10.1.0.0/16 "}.TrustedProxyList()
+	if strings.Join(got, "|") != "10.0.0.1|10.1.0.0/16" {
+		t.Errorf("TrustedProxyList = %v", got)
+	}
+}
+
+func TestWorkerMaxRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr string
+	}{
+		{"unset uses default", "", 2, ""},
+		{"zero disables retries", "0", 0, ""},
+		{"explicit", "5", 5, ""},
+		{"negative", "-1", 0, "invalid WORKER_MAX_RETRIES"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := baseEnv()
+			env["WORKER_MAX_RETRIES"] = tt.value
+			cfg, err := load(t, env)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("load: err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if cfg.Worker.MaxRetries != tt.want {
+				t.Errorf("MaxRetries = %d, want %d", cfg.Worker.MaxRetries, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // TaskStatus 任务状态
 type TaskStatus string
@@ -14,14 +17,29 @@ const (
 	TaskStatusFailed     TaskStatus = "failed"
 )
 
+// User 用户账号，以手机号作为登录标识
+type User struct {
+	ID        int64     `json:"id"`
+	Phone     string    `json:"phone"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // AnalysisTask 分析任务
 type AnalysisTask struct {
-	ID          int64                  `json:"id"`
-	UserID      int64                  `json:"user_id"`
-	Status      TaskStatus             `json:"status"`
-	RiskSummary map[string]int         `json:"risk_summary,omitempty"`
-	CreatedAt   time.Time              `json:"created_at"`
-	UpdatedAt   time.Time              `json:"updated_at"`
+	ID          int64          `json:"id"`
+	UserID      int64          `json:"user_id"`
+	RequestID   string         `json:"request_id,omitempty"`
+	Status      TaskStatus     `json:"status"`
+	RiskSummary map[string]int `json:"risk_summary,omitempty"`
+	FailureCode string         `json:"failure_code,omitempty"`
+	RetryCount  int            `json:"retry_count"`
+	// RuleVersion、PromptVersion 生成报告所用的规则集与提示词版本，便于争议时回溯（PRD §12）
+	RuleVersion   string `json:"rule_version,omitempty"`
+	PromptVersion string `json:"prompt_version,omitempty"`
+	// ReportedAt 报告生成时间，任务未完成时为空
+	ReportedAt *time.Time `json:"reported_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
 }
 
 // DocumentType 文档类型
@@ -51,7 +69,9 @@ type Document struct {
 	StorageKey  string       `json:"storage_key"`
 	ParseStatus ParseStatus  `json:"parse_status"`
 	ParsedText  string       `json:"parsed_text,omitempty"`
-	CreatedAt   time.Time    `json:"created_at"`
+	// ParsedJSON 带段落编号与页码映射的版本化解析结果，见 internal/parser
+	ParsedJSON json.RawMessage `json:"parsed_json,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
 }
 
 // RiskLevel 风险等级
@@ -65,17 +85,27 @@ const (
 
 // RiskFinding 风险发现
 type RiskFinding struct {
-	ID              int64       `json:"id"`
-	TaskID          int64       `json:"task_id"`
-	Level           RiskLevel   `json:"level"`
-	Topic           string      `json:"topic"`
-	Summary         string      `json:"summary"`
-	HealthEvidence  []Evidence  `json:"health_evidence"`
-	PolicyEvidence  []Evidence  `json:"policy_evidence"`
-	Questions       []string    `json:"questions"`
-	Actions         []string    `json:"actions,omitempty"`
-	Confidence      float64     `json:"confidence"`
-	CreatedAt       time.Time   `json:"created_at"`
+	ID             int64      `json:"id"`
+	TaskID         int64      `json:"task_id"`
+	Level          RiskLevel  `json:"level"`
+	Topic          string     `json:"topic"`
+	Summary        string     `json:"summary"`
+	HealthEvidence []Evidence `json:"health_evidence"`
+	PolicyEvidence []Evidence `json:"policy_evidence"`
+	Questions      []string   `json:"questions"`
+	Actions        []string   `json:"actions,omitempty"`
+	Confidence     float64    `json:"confidence"`
+	// Downgrades 护栏规则对风险等级的降级记录，按应用顺序
+	Downgrades []Downgrade `json:"downgrades,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// Downgrade 风险等级降级记录，用于向用户解释等级来源
+type Downgrade struct {
+	Rule   string    `json:"rule"`
+	From   RiskLevel `json:"from"`
+	To     RiskLevel `json:"to"`
+	Reason string    `json:"reason"`
 }
 
 // Evidence 证据
@@ -86,14 +116,53 @@ type Evidence struct {
 
 // HealthFact 健康事实
 type HealthFact struct {
-	Category            string            `json:"category"`
-	Label               string            `json:"label"`
-	Evidence            EvidenceDetail    `json:"evidence"`
-	Values              map[string]interface{} `json:"values,omitempty"`
-	Diagnosed           *bool             `json:"diagnosed,omitempty"`
-	LongTermMedication  *bool             `json:"long_term_medication,omitempty"`
-	Confidence          float64           `json:"confidence"`
-	UncertainReason     string            `json:"uncertain_reason,omitempty"`
+	Category           string                 `json:"category"`
+	Label              string                 `json:"label"`
+	Evidence           EvidenceDetail         `json:"evidence"`
+	Values             map[string]interface{} `json:"values,omitempty"`
+	Diagnosed          *bool                  `json:"diagnosed,omitempty"`
+	LongTermMedication *bool                  `json:"long_term_medication,omitempty"`
+	Confidence         float64                `json:"confidence"`
+	UncertainReason    string                 `json:"uncertain_reason,omitempty"`
+	// Measurements 由 Values 归一化得到的检测值，键为标准检测项名（如 sbp、fasting_glucose）
+	Measurements map[string]Measurement `json:"measurements,omitempty"`
+	// Unknown 报告中无法确认的字段（如 diagnosed、evidence.date），对应取值不做推断
+	Unknown []string `json:"unknown,omitempty"`
+}
+
+// MeasurementFlag 检测值相对参考范围的判定
+type MeasurementFlag string
+
+const (
+	MeasurementNormal     MeasurementFlag = "normal"
+	MeasurementBorderline MeasurementFlag = "borderline"
+	MeasurementHigh       MeasurementFlag = "high"
+	MeasurementLow        MeasurementFlag = "low"
+)
+
+// 参考范围来源
+const (
+	RefSourceReport  = "report"
+	RefSourceBuiltin = "builtin"
+)
+
+// Measurement 归一化后的检测值，Value 为标准单位下的数值
+type Measurement struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit,omitempty"`
+	// Raw 报告原文取值，如 "150/95mmHg"
+	Raw     string   `json:"raw,omitempty"`
+	RefLow  *float64 `json:"ref_low,omitempty"`
+	RefHigh *float64 `json:"ref_high,omitempty"`
+	// RefSource 参考范围来源：report 为报告给出，builtin 为内置参考表
+	RefSource string `json:"ref_source,omitempty"`
+	// Flag 无参考范围可比较时为空
+	Flag MeasurementFlag `json:"flag,omitempty"`
+}
+
+// OutOfRange 检测值超出参考范围（偏高或偏低）
+func (m Measurement) OutOfRange() bool {
+	return m.Flag == MeasurementHigh || m.Flag == MeasurementLow
 }
 
 // EvidenceDetail 证据详情
@@ -106,10 +175,46 @@ type EvidenceDetail struct {
 
 // PolicyFact 条款事实
 type PolicyFact struct {
-	Type       string   `json:"type"`
-	Title      string   `json:"title"`
-	Content    string   `json:"content"`
-	Loc        string   `json:"loc"`
-	Confidence float64  `json:"confidence"`
-	Questions  []string `json:"questions,omitempty"`
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Loc     string `json:"loc"`
+	// Clause 所在条款标题，如 "第五条 责任免除"
+	Clause     string  `json:"clause,omitempty"`
+	Confidence float64 `json:"confidence"`
+	// Questions 投保告知问题，或低置信度时需用户向保险公司确认的问题
+	Questions []string `json:"questions,omitempty"`
+}
+
+// AuditLog 审计日志
+type AuditLog struct {
+	ID         int64                  `json:"id"`
+	TaskID     int64                  `json:"task_id,omitempty"`
+	ActorID    int64                  `json:"actor_id,omitempty"`
+	Action     string                 `json:"action"`
+	TargetType string                 `json:"target_type"`
+	TargetID   string                 `json:"target_id,omitempty"`
+	Detail     map[string]interface{} `json:"detail,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// IdempotencyRecord 幂等请求记录，重放时返回首次请求的响应快照
+type IdempotencyRecord struct {
+	UserID      int64           `json:"user_id"`
+	Scope       string          `json:"scope"`
+	Key         string          `json:"key"`
+	RequestHash string          `json:"request_hash"`
+	TaskID      int64           `json:"task_id,omitempty"`
+	Response    json.RawMessage `json:"response"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// TaskCheckpoint 流水线阶段检查点，保存已完成阶段的产出，
+// 任务中断后从下一阶段继续，无需重做解析与抽取
+type TaskCheckpoint struct {
+	TaskID    int64           `json:"task_id"`
+	Stage     TaskStatus      `json:"stage"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
 }
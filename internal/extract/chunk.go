@@ -0,0 +1,56 @@
+package extract
+
+import (
+	"unicode/utf8"
+
+	"github.com/zhenglizhi/policy-fit/internal/parser"
+)
+
+// maxChunkRunes 单次请求的段落文本上限
+const maxChunkRunes = 12000
+
+// chunkParagraphs 按段落边界分批，每批不超过 maxChunkRunes（单个超长段落独占一批）
+func chunkParagraphs(paragraphs []parser.Paragraph) [][]parser.Paragraph {
+	var (
+		chunks [][]parser.Paragraph
+		cur    []parser.Paragraph
+		runes  int
+	)
+	for _, p := range paragraphs {
+		n := utf8.RuneCountInString(p.Text)
+		if len(cur) > 0 && runes+n > maxChunkRunes {
+			chunks = append(chunks, cur)
+			cur, runes = nil, 0
+		}
+		cur = append(cur, p)
+		runes += n
+	}
+	if len(cur) > 0 {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
+
+// locResolver 将模型给出的段落定位解析为本批段落的标准定位（p3/para_12）
+type locResolver map[string]parser.Paragraph
+
+func newLocResolver(paragraphs []parser.Paragraph) locResolver {
+	r := make(locResolver, len(paragraphs))
+	for _, p := range paragraphs {
+		r[p.ID] = p
+	}
+	return r
+}
+
+// resolve 段落不在本批中或页码不符时返回 false
+func (r locResolver) resolve(loc string) (string, bool) {
+	page, id, err := parser.ParseLoc(loc)
+	if err != nil {
+		return "", false
+	}
+	p, ok := r[id]
+	if !ok || (page != 0 && page != p.Page) {
+		return "", false
+	}
+	return p.Loc(), true
+}
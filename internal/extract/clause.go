@@ -0,0 +1,92 @@
+package extract
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zhenglizhi/policy-fit/internal/parser"
+)
+
+// clauseHeading 条款标题行，如 "第五条 责任免除"、"第 12 条"
+var clauseHeading = regexp.MustCompile(`^第\s*[0-9０-９一二三四五六七八九十百零〇两]+\s*条`)
+
+// maxHeadingRunes 条款标题保留的字符上限
+const maxHeadingRunes = 64
+
+// clause 以 "第X条" 开头的一组连续段落；首个条款之前的封面、目录等内容为无标题条款
+type clause struct {
+	Heading string
+	// Loc 条款首段定位，超长条款拆分后各部分保持不变
+	Loc        string
+	Paragraphs []parser.Paragraph
+}
+
+func (c clause) runes() int {
+	n := 0
+	for _, p := range c.Paragraphs {
+		n += utf8.RuneCountInString(p.Text)
+	}
+	return n
+}
+
+// splitClauses 按条款标题切分段落。标题行出现在段落中间时，该段落归入新条款
+func splitClauses(paragraphs []parser.Paragraph) []clause {
+	var clauses []clause
+	for _, p := range paragraphs {
+		if heading, ok := findHeading(p.Text); ok || len(clauses) == 0 {
+			clauses = append(clauses, clause{Heading: heading, Loc: p.Loc()})
+		}
+		last := &clauses[len(clauses)-1]
+		last.Paragraphs = append(last.Paragraphs, p)
+	}
+	return clauses
+}
+
+// findHeading 返回段落中第一个条款标题行
+func findHeading(text string) (string, bool) {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if !clauseHeading.MatchString(line) {
+			continue
+		}
+		if utf8.RuneCountInString(line) > maxHeadingRunes {
+			line = string([]rune(line)[:maxHeadingRunes])
+		}
+		return line, true
+	}
+	return "", false
+}
+
+// chunkClauses 按条款边界分批，每批不超过 maxChunkRunes；超长条款按段落边界拆分，
+// 拆出的各部分保留原条款标题
+func chunkClauses(clauses []clause) [][]clause {
+	var (
+		chunks [][]clause
+		cur    []clause
+		runes  int
+	)
+	flush := func() {
+		if len(cur) > 0 {
+			chunks = append(chunks, cur)
+			cur, runes = nil, 0
+		}
+	}
+	for _, c := range clauses {
+		n := c.runes()
+		if n > maxChunkRunes {
+			flush()
+			for _, part := range chunkParagraphs(c.Paragraphs) {
+				chunks = append(chunks, []clause{{Heading: c.Heading, Loc: c.Loc, Paragraphs: part}})
+			}
+			continue
+		}
+		if runes+n > maxChunkRunes {
+			flush()
+		}
+		cur = append(cur, c)
+		runes += n
+	}
+	flush()
+	return chunks
+}
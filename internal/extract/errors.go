@@ -0,0 +1,57 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+var (
+	// ErrNoHealthFacts 体检报告中没有可用的健康事实
+	ErrNoHealthFacts = errors.New("no supported health facts found in the report")
+	// ErrNoPolicyFacts 条款文档中没有支持的条款类型
+	ErrNoPolicyFacts = errors.New("no supported clause types found in the policy documents")
+)
+
+// maxViolations 错误信息中保留的违规项数量
+const maxViolations = 5
+
+// SchemaError 模型输出未通过 Schema 校验，整批输出被拒绝
+type SchemaError struct {
+	Schema     string
+	Violations []string
+}
+
+func (e *SchemaError) Error() string {
+	shown := e.Violations
+	if len(shown) > maxViolations {
+		shown = shown[:maxViolations]
+	}
+	return fmt.Sprintf("model output violates schema %s (%d violation(s)): %s", e.Schema, len(e.Violations), strings.Join(shown, "; "))
+}
+
+// FailureCode 写入任务的失败码
+func (e *SchemaError) FailureCode() string {
+	return response.CodeLLMSchemaInvalid
+}
+
+// Error 携带失败码的抽取错误
+type Error struct {
+	Code string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// FailureCode 写入任务的失败码
+func (e *Error) FailureCode() string {
+	return e.Code
+}
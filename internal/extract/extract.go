@@ -0,0 +1,110 @@
+// Package extract 实现 extracting 阶段：基于版本化提示词调用 LLM 抽取健康事实与条款事实，
+// 模型输出经 JSON Schema 校验，证据定位必须对应到源文档的真实段落。
+package extract
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/llm"
+	"github.com/zhenglizhi/policy-fit/internal/parser"
+	"github.com/zhenglizhi/policy-fit/internal/prefilter"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+// Document 已解析的文档
+type Document struct {
+	ID     int64
+	Type   domain.DocumentType
+	Result *parser.Result
+}
+
+// Output extracting 阶段产出，写入阶段检查点供 matching 阶段读取
+type Output struct {
+	HealthFacts []domain.HealthFact `json:"health_facts"`
+	PolicyFacts []domain.PolicyFact `json:"policy_facts"`
+	// Prompts 使用的提示词版本，关键词降级抽取时为空
+	Prompts []string `json:"prompts"`
+	// KeywordFallback LLM 不可用，结果来自关键词降级抽取
+	KeywordFallback bool `json:"keyword_fallback,omitempty"`
+}
+
+// Extractor extracting 阶段执行器
+type Extractor struct {
+	health *HealthExtractor
+	policy *PolicyExtractor
+}
+
+// NewExtractor 创建抽取阶段执行器，filter 为体检报告段落预筛器，可为 nil
+func NewExtractor(client llm.Client, filter *prefilter.Filter) *Extractor {
+	return &Extractor{
+		health: NewHealthExtractor(client, filter),
+		policy: NewPolicyExtractor(client),
+	}
+}
+
+// Run 对任务文档执行抽取：体检报告抽取健康事实，保险条款与投保告知书抽取条款事实。
+// 没有可用健康事实时返回 PFIT-3004，没有可用条款事实时返回 PFIT-3005。
+func (e *Extractor) Run(ctx context.Context, docs []Document) (*Output, error) {
+	out := &Output{Prompts: []string{e.health.PromptVersion(), e.policy.PromptVersion()}}
+	if err := e.collect(ctx, docs, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RunKeywordOnly LLM 不可用时的降级抽取，不调用模型：健康事实取自关键词预筛命中的段落，
+// 条款事实按条款标题识别。结果置信度固定为 keywordConfidence，空结果的处理与 Run 相同。
+func (e *Extractor) RunKeywordOnly(ctx context.Context, docs []Document) (*Output, error) {
+	out := &Output{KeywordFallback: true}
+	for _, doc := range docs {
+		switch doc.Type {
+		case domain.DocTypeReport:
+			out.HealthFacts = append(out.HealthFacts, e.health.KeywordFacts(doc.Result)...)
+		case domain.DocTypePolicy, domain.DocTypeDisclosure:
+			out.PolicyFacts = append(out.PolicyFacts, e.policy.KeywordFacts(doc.Result)...)
+		}
+	}
+	logger.FromContext(ctx).Warn("Facts extracted by keyword fallback",
+		"health_facts", len(out.HealthFacts), "policy_facts", len(out.PolicyFacts))
+	if err := checkOutput(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// collect 逐文档调用模型抽取并汇总到 out
+func (e *Extractor) collect(ctx context.Context, docs []Document, out *Output) error {
+	for _, doc := range docs {
+		switch doc.Type {
+		case domain.DocTypeReport:
+			facts, err := e.health.Extract(ctx, doc.Result)
+			if err != nil {
+				return fmt.Errorf("document %d (%s): %w", doc.ID, doc.Type, err)
+			}
+			logger.FromContext(ctx).Info("Health facts extracted", "document_id", doc.ID, "facts", len(facts))
+			out.HealthFacts = append(out.HealthFacts, facts...)
+		case domain.DocTypePolicy, domain.DocTypeDisclosure:
+			facts, err := e.policy.Extract(ctx, doc.Result)
+			if err != nil {
+				return fmt.Errorf("document %d (%s): %w", doc.ID, doc.Type, err)
+			}
+			logger.FromContext(ctx).Info("Policy facts extracted", "document_id", doc.ID, "facts", len(facts))
+			out.PolicyFacts = append(out.PolicyFacts, facts...)
+		}
+	}
+	return checkOutput(out)
+}
+
+// checkOutput 没有可用健康事实时返回 PFIT-3004，没有可用条款事实时返回 PFIT-3005
+func checkOutput(out *Output) error {
+	if len(out.HealthFacts) == 0 {
+		return &Error{Code: response.CodeHealthFactsEmpty, Err: ErrNoHealthFacts}
+	}
+	if len(out.PolicyFacts) == 0 {
+		return &Error{Code: response.CodePolicyFactsEmpty, Err: ErrNoPolicyFacts}
+	}
+	return nil
+}
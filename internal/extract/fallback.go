@@ -0,0 +1,105 @@
+package extract
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/parser"
+)
+
+// keywordConfidence 关键词降级抽取的置信度，低于护栏阈值，不会得出红色结论
+const keywordConfidence = 0.3
+
+// keywordReason 关键词降级抽取结果的 uncertain_reason
+const keywordReason = "LLM 不可用，仅按关键词匹配，未经模型确认"
+
+// maxKeywordEvidenceRunes 降级结果中证据原文的字符上限
+const maxKeywordEvidenceRunes = 500
+
+// policyHeadingKeywords 条款标题关键词到条款类型，用于关键词降级抽取
+var policyHeadingKeywords = []struct {
+	Type     string
+	Keywords []string
+}{
+	{PolicyPreexistingDefinition, []string{"既往症", "既往病史", "投保前已患"}},
+	{PolicyExclusion, []string{"责任免除", "除外责任", "免责"}},
+	{PolicyWaitingPeriod, []string{"等待期", "观察期"}},
+	{PolicyUnderwritingDisclosure, []string{"如实告知", "告知义务", "健康告知"}},
+	{PolicySpecificDiseaseDefinition, []string{"疾病定义", "重大疾病", "释义"}},
+	{PolicyRenewalIncontestability, []string{"续保", "不可抗辩", "合同解除"}},
+}
+
+// KeywordFacts 关键词降级抽取：每个命中段落、每个候选主题生成一条健康事实，
+// 诊断、用药与日期均记为 unknown。未配置预筛器时返回 nil。
+func (e *HealthExtractor) KeywordFacts(report *parser.Result) []domain.HealthFact {
+	if e.filter == nil {
+		return nil
+	}
+	var facts []domain.HealthFact
+	for _, hit := range e.filter.Tag(report.Paragraphs) {
+		p := report.Paragraphs[hit.Index]
+		for _, topic := range hit.TopicNames() {
+			facts = append(facts, domain.HealthFact{
+				Category: topic,
+				Label:    hit.Topics[topic][0],
+				Evidence: domain.EvidenceDetail{
+					Text:   truncateRunes(p.Text, maxKeywordEvidenceRunes),
+					Date:   Unknown,
+					Loc:    p.Loc(),
+					Source: string(domain.DocTypeReport),
+				},
+				Confidence:      keywordConfidence,
+				UncertainReason: keywordReason,
+				Unknown:         []string{FieldDiagnosed, FieldLongTermMedication, FieldEvidenceDate},
+			})
+		}
+	}
+	return facts
+}
+
+// KeywordFacts 关键词降级抽取：按条款标题识别条款类型，每条结果附带向保险公司确认的问题
+func (e *PolicyExtractor) KeywordFacts(policy *parser.Result) []domain.PolicyFact {
+	var facts []domain.PolicyFact
+	for _, c := range splitClauses(policy.Paragraphs) {
+		if c.Heading == "" {
+			continue
+		}
+		for _, entry := range policyHeadingKeywords {
+			if !containsAny(c.Heading, entry.Keywords) {
+				continue
+			}
+			texts := make([]string, len(c.Paragraphs))
+			for i, p := range c.Paragraphs {
+				texts[i] = p.Text
+			}
+			fact := domain.PolicyFact{
+				Type:       entry.Type,
+				Title:      c.Heading,
+				Content:    truncateRunes(strings.Join(texts, "\n"), maxKeywordEvidenceRunes),
+				Loc:        c.Loc,
+				Clause:     c.Heading,
+				Confidence: keywordConfidence,
+			}
+			fact.Questions = []string{confirmQuestion(fact)}
+			facts = append(facts, fact)
+		}
+	}
+	return facts
+}
+
+func containsAny(s string, keywords []string) bool {
+	for _, kw := range keywords {
+		if strings.Contains(s, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,180 @@
+package extract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/labvalue"
+	"github.com/zhenglizhi/policy-fit/internal/llm"
+	"github.com/zhenglizhi/policy-fit/internal/llm/prompts"
+	"github.com/zhenglizhi/policy-fit/internal/parser"
+	"github.com/zhenglizhi/policy-fit/internal/prefilter"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+)
+
+// Unknown 模型无法确认的取值
+const Unknown = "unknown"
+
+// lowConfidence 低于该置信度的事实必须给出 uncertain_reason（PRD §20.1）
+const lowConfidence = 0.6
+
+// 抽取结果中可能为 unknown 的字段，记录在 HealthFact.Unknown 中
+const (
+	FieldDiagnosed          = "diagnosed"
+	FieldLongTermMedication = "long_term_medication"
+	FieldEvidenceDate       = "evidence.date"
+	fieldValuesPrefix       = "values."
+)
+
+// healthSchema 与 prompts.HealthFacts 同版本
+const healthSchema = "health_facts.v2"
+
+// healthFactsOutput 模型输出，已通过 Schema 校验
+type healthFactsOutput struct {
+	Facts []struct {
+		Category           string                 `json:"category"`
+		Label              string                 `json:"label"`
+		Evidence           domain.EvidenceDetail  `json:"evidence"`
+		Values             map[string]interface{} `json:"values"`
+		Diagnosed          interface{}            `json:"diagnosed"`
+		LongTermMedication interface{}            `json:"long_term_medication"`
+		Confidence         float64                `json:"confidence"`
+		UncertainReason    string                 `json:"uncertain_reason"`
+	} `json:"facts"`
+}
+
+// HealthExtractor 体检报告健康事实抽取
+type HealthExtractor struct {
+	client llm.Client
+	filter *prefilter.Filter
+	prompt *prompts.Template
+	schema *Schema
+}
+
+// NewHealthExtractor 创建健康事实抽取器，filter 为 nil 时整份报告送入抽取
+func NewHealthExtractor(client llm.Client, filter *prefilter.Filter) *HealthExtractor {
+	return &HealthExtractor{
+		client: client,
+		filter: filter,
+		prompt: prompts.MustLoad(prompts.HealthFacts),
+		schema: mustLoadSchema(healthSchema),
+	}
+}
+
+// PromptVersion 使用的提示词版本
+func (e *HealthExtractor) PromptVersion() string {
+	return e.prompt.Version
+}
+
+// Extract 从体检报告解析结果中抽取健康事实，段落过多时按段落边界分批请求。
+// 配置了预筛器时只发送命中主题关键词的段落及其上下文，没有命中段落时不调用模型。
+// 任一批输出未通过 Schema 校验即返回 *SchemaError；证据定位不是本批段落的事实被丢弃。
+func (e *HealthExtractor) Extract(ctx context.Context, report *parser.Result) ([]domain.HealthFact, error) {
+	paragraphs := report.Paragraphs
+	if e.filter != nil {
+		paragraphs = e.filter.Select(paragraphs, e.filter.Tag(paragraphs))
+		logger.FromContext(ctx).Info("Report paragraphs pre-filtered", "paragraphs", len(report.Paragraphs), "selected", len(paragraphs))
+	}
+	var facts []domain.HealthFact
+	for _, chunk := range chunkParagraphs(paragraphs) {
+		system, user, err := e.prompt.Render(struct{ Paragraphs []parser.Paragraph }{chunk})
+		if err != nil {
+			return nil, err
+		}
+		resp, err := e.client.Complete(ctx, llm.Request{System: system, Prompt: user})
+		if err != nil {
+			return nil, err
+		}
+		batch, err := e.decode(ctx, resp.Content, chunk)
+		if err != nil {
+			return nil, err
+		}
+		facts = append(facts, batch...)
+	}
+	return facts, nil
+}
+
+// decode 校验并转换模型输出
+func (e *HealthExtractor) decode(ctx context.Context, content json.RawMessage, chunk []parser.Paragraph) ([]domain.HealthFact, error) {
+	if violations := e.schema.Validate(content); len(violations) > 0 {
+		return nil, &SchemaError{Schema: healthSchema, Violations: violations}
+	}
+	var out healthFactsOutput
+	if err := json.Unmarshal(content, &out); err != nil {
+		return nil, &SchemaError{Schema: healthSchema, Violations: []string{"/: " + err.Error()}}
+	}
+
+	var violations []string
+	for i, f := range out.Facts {
+		if f.Confidence < lowConfidence && f.UncertainReason == "" {
+			violations = append(violations, fmt.Sprintf("/facts/%d/uncertain_reason: required when confidence < %v", i, lowConfidence))
+		}
+	}
+	if len(violations) > 0 {
+		return nil, &SchemaError{Schema: healthSchema, Violations: violations}
+	}
+
+	locs := newLocResolver(chunk)
+	facts := make([]domain.HealthFact, 0, len(out.Facts))
+	for _, f := range out.Facts {
+		loc, ok := locs.resolve(f.Evidence.Loc)
+		if !ok {
+			logger.FromContext(ctx).Warn("Dropping health fact with unresolvable evidence loc", "category", f.Category, "loc", f.Evidence.Loc)
+			continue
+		}
+		fact := domain.HealthFact{
+			Category:        f.Category,
+			Label:           f.Label,
+			Evidence:        f.Evidence,
+			Confidence:      f.Confidence,
+			UncertainReason: f.UncertainReason,
+		}
+		fact.Evidence.Loc = loc
+		fact.Diagnosed = tristate(f.Diagnosed, FieldDiagnosed, &fact.Unknown)
+		fact.LongTermMedication = tristate(f.LongTermMedication, FieldLongTermMedication, &fact.Unknown)
+		if fact.Evidence.Date == Unknown {
+			fact.Unknown = append(fact.Unknown, FieldEvidenceDate)
+		}
+		fact.Values = knownValues(f.Values, &fact.Unknown)
+		fact.Measurements = labvalue.Normalize(fact.Values)
+		facts = append(facts, fact)
+	}
+	return facts, nil
+}
+
+// tristate 将 true/false/"unknown" 转为 *bool，unknown 记为 nil 并登记字段名
+func tristate(v interface{}, field string, unknown *[]string) *bool {
+	if b, ok := v.(bool); ok {
+		return &b
+	}
+	*unknown = append(*unknown, field)
+	return nil
+}
+
+// knownValues 去除取值为 unknown 的检测值并登记字段名，不保留占位值
+func knownValues(values map[string]interface{}, unknown *[]string) map[string]interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	known := make(map[string]interface{}, len(values))
+	for _, k := range keys {
+		if s, ok := values[k].(string); ok && s == Unknown {
+			*unknown = append(*unknown, fieldValuesPrefix+k)
+			continue
+		}
+		known[k] = values[k]
+	}
+	if len(known) == 0 {
+		return nil
+	}
+	return known
+}
@@ -0,0 +1,122 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zhenglizhi/policy-fit/internal/prefilter"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+// healthFact 模型输出中的一条健康事实，fields 追加在末尾，同名字段以后出现的为准
+func healthFact(fields string) string {
+	return `{"category": "hypertension", "label": "血压偏高",
+		"evidence": {"text": "血压 152/95 mmHg", "date": "2026-05-01", "loc": "para_2", "source": "report"},
+		"diagnosed": false, "long_term_medication": false, "confidence": 0.9` + fields + `}`
+}
+
+var testReport = document("一般检查", "血压 152/95 mmHg，建议复查", "视力正常")
+
+func TestHealthExtractUnknownValues(t *testing.T) {
+	client := &stubClient{outputs: []string{`{"facts": [
+		{"category": "hypertension", "label": "血压偏高",
+		 "evidence": {"text": "血压 152/95 mmHg", "date": "unknown", "loc": "para_2", "source": "report"},
+		 "values": {"sbp": 152, "dbp": "unknown"},
+		 "diagnosed": "unknown", "long_term_medication": false, "confidence": 0.5, "uncertain_reason": "未注明诊断"},
+		` + healthFact(`, "evidence": {"text": "x", "date": "2026-05-01", "loc": "para_9", "source": "report"}`) + `
+	]}`}}
+
+	facts, err := NewHealthExtractor(client, nil).Extract(context.Background(), testReport)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	// 证据定位不在报告中的事实被丢弃
+	if len(facts) != 1 {
+		t.Fatalf("facts = %+v, want one", facts)
+	}
+	f := facts[0]
+	if f.Evidence.Loc != "p1/para_2" {
+		t.Errorf("loc = %s, want p1/para_2", f.Evidence.Loc)
+	}
+	// unknown 不猜测取值，登记在 Unknown 中
+	if f.Diagnosed != nil || f.LongTermMedication == nil || *f.LongTermMedication {
+		t.Errorf("diagnosed = %v, long_term_medication = %v", f.Diagnosed, f.LongTermMedication)
+	}
+	if want := []string{FieldDiagnosed, FieldEvidenceDate, "values.dbp"}; !reflect.DeepEqual(f.Unknown, want) {
+		t.Errorf("unknown = %v, want %v", f.Unknown, want)
+	}
+	if _, ok := f.Values["dbp"]; ok || len(f.Values) != 1 {
+		t.Errorf("values = %v, want only sbp", f.Values)
+	}
+	if m, ok := f.Measurements["sbp"]; !ok || m.Value != 152 {
+		t.Errorf("measurements = %+v, want sbp 152", f.Measurements)
+	}
+}
+
+func TestHealthExtractRejectsSchemaViolations(t *testing.T) {
+	tests := map[string]string{
+		"guessed tristate":       healthFact(`, "diagnosed": "probably"`),
+		"unsupported category":   healthFact(`, "category": "cancer"`),
+		"invalid date":           healthFact(`, "evidence": {"text": "x", "date": "2026/05/01", "loc": "para_2", "source": "report"}`),
+		"unexpected property":    healthFact(`, "severity": "high"`),
+		"low confidence no note": healthFact(`, "confidence": 0.4`),
+		"missing diagnosed":      `{"category": "hypertension", "label": "x", "evidence": {"text": "x", "date": "unknown", "loc": "para_2", "source": "report"}, "long_term_medication": false, "confidence": 0.9}`,
+	}
+	for name, fact := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := &stubClient{outputs: []string{`{"facts": [` + fact + `]}`}}
+			_, err := NewHealthExtractor(client, nil).Extract(context.Background(), testReport)
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("err = %v, want *SchemaError", err)
+			}
+			if schemaErr.FailureCode() != response.CodeLLMSchemaInvalid {
+				t.Errorf("FailureCode = %s", schemaErr.FailureCode())
+			}
+		})
+	}
+}
+
+func TestHealthExtractPrefilter(t *testing.T) {
+	filter := prefilter.New(map[string][]string{"血压": {"hypertension"}}, 0)
+	client := &stubClient{outputs: []string{`{"facts": [` + healthFact("") + `]}`}}
+
+	// 没有命中段落时不调用模型
+	facts, err := NewHealthExtractor(client, filter).Extract(context.Background(), document("视力正常", "听力正常"))
+	if err != nil || len(facts) != 0 || len(client.requests) != 0 {
+		t.Fatalf("facts = %+v, err = %v, requests = %d, want no model call", facts, err, len(client.requests))
+	}
+
+	// 只发送命中段落
+	if _, err := NewHealthExtractor(client, filter).Extract(context.Background(), testReport); err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if len(client.requests) != 1 {
+		t.Fatalf("requests = %d, want 1", len(client.requests))
+	}
+	prompt := client.requests[0].Prompt
+	if !strings.Contains(prompt, "血压 152/95") || strings.Contains(prompt, "视力正常") {
+		t.Errorf("prompt does not contain only the matched paragraph:\n%s", prompt)
+	}
+}
+
+func TestHealthKeywordFacts(t *testing.T) {
+	filter := prefilter.New(map[string][]string{"血压": {"hypertension"}}, 0)
+	facts := NewHealthExtractor(&stubClient{}, filter).KeywordFacts(testReport)
+	if len(facts) != 1 {
+		t.Fatalf("facts = %+v, want one", facts)
+	}
+	f := facts[0]
+	if f.Category != "hypertension" || f.Evidence.Loc != "p1/para_2" || f.Diagnosed != nil || f.Confidence != keywordConfidence {
+		t.Errorf("fact = %+v", f)
+	}
+	if want := []string{FieldDiagnosed, FieldLongTermMedication, FieldEvidenceDate}; !reflect.DeepEqual(f.Unknown, want) {
+		t.Errorf("unknown = %v, want %v", f.Unknown, want)
+	}
+	if NewHealthExtractor(&stubClient{}, nil).KeywordFacts(testReport) != nil {
+		t.Error("KeywordFacts without a filter returned facts")
+	}
+}
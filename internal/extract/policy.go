@@ -0,0 +1,197 @@
+package extract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/llm"
+	"github.com/zhenglizhi/policy-fit/internal/llm/prompts"
+	"github.com/zhenglizhi/policy-fit/internal/parser"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+)
+
+// 条款类型（PRD §6.2）
+const (
+	PolicyPreexistingDefinition     = "preexisting_definition"
+	PolicyExclusion                 = "exclusion"
+	PolicyWaitingPeriod             = "waiting_period"
+	PolicyUnderwritingDisclosure    = "underwriting_disclosure"
+	PolicySpecificDiseaseDefinition = "specific_disease_definition"
+	PolicyRenewalIncontestability   = "renewal_incontestability"
+)
+
+// policyTypeLabels 条款类型中文名，用于生成待确认问题
+var policyTypeLabels = map[string]string{
+	PolicyPreexistingDefinition:     "既往症定义",
+	PolicyExclusion:                 "责任免除",
+	PolicyWaitingPeriod:             "等待期",
+	PolicyUnderwritingDisclosure:    "投保告知",
+	PolicySpecificDiseaseDefinition: "特定疾病定义",
+	PolicyRenewalIncontestability:   "续保与不可抗辩条款",
+}
+
+// PolicyTypeLabel 条款类型中文名，未知类型返回原值
+func PolicyTypeLabel(t string) string {
+	if label, ok := policyTypeLabels[t]; ok {
+		return label
+	}
+	return t
+}
+
+// IsPolicyType 是否为支持的条款类型
+func IsPolicyType(t string) bool {
+	_, ok := policyTypeLabels[t]
+	return ok
+}
+
+// policySchema 与 prompts.PolicyFacts 同版本
+const policySchema = "policy_facts.v1"
+
+// policyFactsOutput 模型输出，已通过 Schema 校验
+type policyFactsOutput struct {
+	Sections []struct {
+		Type       string   `json:"type"`
+		Title      string   `json:"title"`
+		Content    string   `json:"content"`
+		Loc        string   `json:"loc"`
+		Confidence float64  `json:"confidence"`
+		Questions  []string `json:"questions"`
+	} `json:"sections"`
+}
+
+// PolicyExtractor 保险条款事实抽取
+type PolicyExtractor struct {
+	client llm.Client
+	prompt *prompts.Template
+	schema *Schema
+}
+
+// NewPolicyExtractor 创建条款事实抽取器
+func NewPolicyExtractor(client llm.Client) *PolicyExtractor {
+	return &PolicyExtractor{
+		client: client,
+		prompt: prompts.MustLoad(prompts.PolicyFacts),
+		schema: mustLoadSchema(policySchema),
+	}
+}
+
+// PromptVersion 使用的提示词版本
+func (e *PolicyExtractor) PromptVersion() string {
+	return e.prompt.Version
+}
+
+// Extract 按 "第X条" 切分条款并分批抽取条款事实，Loc 指向事实所在条款的首段。
+// 同一条款可有多条同类型事实（如责任免除中的多项免责情形）；同一条款同一类型且内容相同的事实
+// 只保留置信度最高的一条（超长条款拆分到多批时可能重复抽取）。
+func (e *PolicyExtractor) Extract(ctx context.Context, policy *parser.Result) ([]domain.PolicyFact, error) {
+	var (
+		facts []domain.PolicyFact
+		seen  = make(map[string]int)
+	)
+	for _, chunk := range chunkClauses(splitClauses(policy.Paragraphs)) {
+		system, user, err := e.prompt.Render(struct{ Clauses []clause }{chunk})
+		if err != nil {
+			return nil, err
+		}
+		resp, err := e.client.Complete(ctx, llm.Request{System: system, Prompt: user})
+		if err != nil {
+			return nil, err
+		}
+		batch, err := e.decode(ctx, resp.Content, chunk)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range batch {
+			key := f.Type + "@" + f.Loc + "@" + strings.Join(strings.Fields(f.Content), "")
+			if i, ok := seen[key]; ok {
+				if f.Confidence > facts[i].Confidence {
+					facts[i] = f
+				}
+				continue
+			}
+			seen[key] = len(facts)
+			facts = append(facts, f)
+		}
+	}
+	return facts, nil
+}
+
+// decode 校验并转换模型输出
+func (e *PolicyExtractor) decode(ctx context.Context, content json.RawMessage, chunk []clause) ([]domain.PolicyFact, error) {
+	if violations := e.schema.Validate(content); len(violations) > 0 {
+		return nil, &SchemaError{Schema: policySchema, Violations: violations}
+	}
+	var out policyFactsOutput
+	if err := json.Unmarshal(content, &out); err != nil {
+		return nil, &SchemaError{Schema: policySchema, Violations: []string{"/: " + err.Error()}}
+	}
+
+	clauses := newClauseResolver(chunk)
+	facts := make([]domain.PolicyFact, 0, len(out.Sections))
+	for _, s := range out.Sections {
+		c, ok := clauses.resolve(s.Loc)
+		if !ok {
+			logger.FromContext(ctx).Warn("Dropping policy fact with unresolvable loc", "type", s.Type, "loc", s.Loc)
+			continue
+		}
+		fact := domain.PolicyFact{
+			Type:       s.Type,
+			Title:      s.Title,
+			Content:    s.Content,
+			Loc:        c.Loc,
+			Clause:     c.Heading,
+			Confidence: s.Confidence,
+			Questions:  s.Questions,
+		}
+		if fact.Confidence < lowConfidence && len(fact.Questions) == 0 {
+			fact.Questions = []string{confirmQuestion(fact)}
+		}
+		facts = append(facts, fact)
+	}
+	return facts, nil
+}
+
+// confirmQuestion 模型未给出问题时，为低置信度条款生成默认的确认问题
+func confirmQuestion(f domain.PolicyFact) string {
+	name := f.Clause
+	if name == "" {
+		name = f.Title
+	}
+	return fmt.Sprintf("请向保险公司确认「%s」中关于%s的具体约定", name, policyTypeLabels[f.Type])
+}
+
+// clauseResolver 将模型给出的段落定位解析为所在条款
+type clauseResolver map[string]*clause
+
+func newClauseResolver(chunk []clause) clauseResolver {
+	r := make(clauseResolver)
+	for i := range chunk {
+		for _, p := range chunk[i].Paragraphs {
+			r[p.ID] = &chunk[i]
+		}
+	}
+	return r
+}
+
+// resolve 段落不在本批中或页码不符时返回 false
+func (r clauseResolver) resolve(loc string) (*clause, bool) {
+	page, id, err := parser.ParseLoc(loc)
+	if err != nil {
+		return nil, false
+	}
+	c, ok := r[id]
+	if !ok {
+		return nil, false
+	}
+	if page != 0 {
+		for _, p := range c.Paragraphs {
+			if p.ID == id && p.Page != page {
+				return nil, false
+			}
+		}
+	}
+	return c, true
+}
@@ -0,0 +1,93 @@
+package extract
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/zhenglizhi/policy-fit/internal/llm"
+	"github.com/zhenglizhi/policy-fit/internal/parser"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("error", "json")
+	os.Exit(m.Run())
+}
+
+// stubClient 依次返回预置的模型输出，用尽后重复最后一条
+type stubClient struct {
+	mu       sync.Mutex
+	outputs  []string
+	err      error
+	requests []llm.Request
+}
+
+func (c *stubClient) Complete(ctx context.Context, req llm.Request) (*llm.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+	if c.err != nil {
+		return nil, c.err
+	}
+	out := c.outputs[len(c.outputs)-1]
+	if len(c.requests) <= len(c.outputs) {
+		out = c.outputs[len(c.requests)-1]
+	}
+	return &llm.Response{Content: json.RawMessage(out)}, nil
+}
+
+// document 以 para_1 起编号、位于第 1 页的段落构造解析结果
+func document(texts ...string) *parser.Result {
+	paras := make([]parser.Paragraph, len(texts))
+	for i, text := range texts {
+		paras[i] = parser.Paragraph{ID: "para_" + strconv.Itoa(i+1), Page: 1, Text: text}
+	}
+	return &parser.Result{Paragraphs: paras}
+}
+
+func TestPolicyExtractKeepsDistinctFactsOfOneClause(t *testing.T) {
+	policy := document(
+		"第五条 责任免除",
+		"因下列情形之一导致被保险人身故的，本公司不承担给付保险金责任：（一）投保人对被保险人的故意杀害；（二）被保险人故意犯罪；（三）既往症。",
+		"第六条 等待期 自合同生效之日起 90 日为等待期。",
+	)
+	client := &stubClient{outputs: []string{`{"sections": [
+		{"type": "exclusion", "title": "责任免除", "content": "投保人对被保险人的故意杀害", "loc": "para_2", "confidence": 0.9},
+		{"type": "exclusion", "title": "责任免除", "content": "被保险人故意犯罪", "loc": "para_2", "confidence": 0.8},
+		{"type": "exclusion", "title": "责任免除", "content": "既往症", "loc": "p1/para_2", "confidence": 0.7},
+		{"type": "exclusion", "title": "责任免除", "content": "被保险人 故意犯罪", "loc": "para_2", "confidence": 0.95},
+		{"type": "waiting_period", "title": "等待期", "content": "等待期 90 日", "loc": "para_3", "confidence": 0.9}
+	]}`}}
+
+	facts, err := NewPolicyExtractor(client).Extract(context.Background(), policy)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	type fact struct {
+		typ, content, loc string
+		confidence        float64
+	}
+	var got []fact
+	for _, f := range facts {
+		got = append(got, fact{f.Type, f.Content, f.Loc, f.Confidence})
+	}
+	// 三项免责情形各自保留；仅空白不同的重复项合并，保留置信度较高者
+	want := []fact{
+		{"exclusion", "投保人对被保险人的故意杀害", "p1/para_1", 0.9},
+		{"exclusion", "被保险人 故意犯罪", "p1/para_1", 0.95},
+		{"exclusion", "既往症", "p1/para_1", 0.7},
+		{"waiting_period", "等待期 90 日", "p1/para_3", 0.9},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("facts = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("fact %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package extract
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// Schema JSON Schema（draft 2020-12 子集）：type、enum、properties、required、
+// additionalProperties、items、minItems、maxItems、minimum、maximum、minLength、maxLength、pattern。
+// 模型输出只需这些约束，不支持的关键字在加载时报错，避免误以为已校验。
+type Schema struct {
+	Type                 schemaTypes        `json:"type,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// schemaTypes type 关键字，支持单个类型或类型数组
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// schemaKeywords 支持的关键字，其余关键字（$schema、title、description 除外）拒绝加载
+var schemaKeywords = map[string]bool{
+	"$schema": true, "$id": true, "title": true, "description": true,
+	"type": true, "enum": true, "properties": true, "required": true, "additionalProperties": true,
+	"items": true, "minItems": true, "maxItems": true, "minimum": true, "maximum": true,
+	"minLength": true, "maxLength": true, "pattern": true,
+}
+
+// loadSchema 加载内置 Schema
+func loadSchema(name string) (*Schema, error) {
+	data, err := schemaFiles.ReadFile("schemas/" + name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("schema %s not found", name)
+	}
+	return ParseSchema(data)
+}
+
+func mustLoadSchema(name string) *Schema {
+	s, err := loadSchema(name)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// ParseSchema 解析 Schema 文档
+func ParseSchema(data []byte) (*Schema, error) {
+	if err := checkKeywords(data, ""); err != nil {
+		return nil, err
+	}
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func checkKeywords(data []byte, path string) error {
+	var node map[string]json.RawMessage
+	if err := json.Unmarshal(data, &node); err != nil {
+		return fmt.Errorf("invalid schema at %q: %w", path, err)
+	}
+	for k, v := range node {
+		if !schemaKeywords[k] {
+			return fmt.Errorf("unsupported schema keyword %q at %q", k, path)
+		}
+		switch k {
+		case "items":
+			if err := checkKeywords(v, path+"/items"); err != nil {
+				return err
+			}
+		case "properties":
+			var props map[string]json.RawMessage
+			if err := json.Unmarshal(v, &props); err != nil {
+				return fmt.Errorf("invalid schema at %q: %w", path, err)
+			}
+			for name, prop := range props {
+				if err := checkKeywords(prop, path+"/properties/"+name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid schema pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+	for _, prop := range s.Properties {
+		if err := prop.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// Validate 校验 JSON 文档，返回全部违规项（JSON Pointer 路径 + 原因），通过时返回 nil
+func (s *Schema) Validate(data []byte) []string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return []string{"/: invalid json: " + err.Error()}
+	}
+	var violations []string
+	s.validate(doc, "", &violations)
+	return violations
+}
+
+func (s *Schema) validate(v interface{}, path string, out *[]string) {
+	fail := func(format string, args ...interface{}) {
+		p := path
+		if p == "" {
+			p = "/"
+		}
+		*out = append(*out, p+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(s.Type) > 0 && !s.Type.matches(v) {
+		fail("must be %s, got %s", strings.Join(s.Type, " or "), jsonType(v))
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		fail("must be one of %s", enumString(s.Enum))
+		return
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(val))
+		for name := range val {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					fail("unexpected property %q", name)
+				}
+				continue
+			}
+			prop.validate(val[name], path+"/"+name, out)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range val {
+				s.Items.validate(item, path+"/"+strconv.Itoa(i), out)
+			}
+		}
+	case json.Number:
+		f, _ := val.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			fail("must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			fail("must be <= %v", *s.Maximum)
+		}
+	case string:
+		n := utf8.RuneCountInString(val)
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			fail("must match %q", s.Pattern)
+		}
+	}
+}
+
+func (t schemaTypes) matches(v interface{}) bool {
+	actual := jsonType(v)
+	for _, want := range t {
+		if want == actual || (want == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case json.Number:
+		if f, err := val.Float64(); err == nil && f == math.Trunc(f) && !strings.ContainsAny(val.String(), ".eE") {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if n, ok := v.(json.Number); ok {
+			if f, ok := e.(float64); ok {
+				if nf, err := n.Float64(); err == nil && nf == f {
+					return true
+				}
+			}
+			continue
+		}
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumString(enum []interface{}) string {
+	data, _ := json.Marshal(enum)
+	return string(data)
+}
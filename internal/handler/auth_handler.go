@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/zhenglizhi/policy-fit/internal/service"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+// AuthHandler 登录处理器
+type AuthHandler struct {
+	auth *service.AuthService
+}
+
+// NewAuthHandler 创建登录处理器
+func NewAuthHandler(auth *service.AuthService) *AuthHandler {
+	return &AuthHandler{auth: auth}
+}
+
+// RegisterAuthRoutes 注册登录路由，这些接口无需鉴权
+func RegisterAuthRoutes(r *gin.RouterGroup, h *AuthHandler) {
+	group := r.Group("/auth")
+	{
+		group.POST("/otp", h.SendOTP)
+		group.POST("/login", h.Login)
+		group.POST("/refresh", h.Refresh)
+	}
+}
+
+type sendOTPRequest struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+type loginRequest struct {
+	Phone string `json:"phone" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// SendOTP 发送登录验证码
+func (h *AuthHandler) SendOTP(c *gin.Context) {
+	var req sendOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.NewError(response.CodeInvalidParam, "phone is required"))
+		return
+	}
+	if err := h.auth.SendOTP(c.Request.Context(), req.Phone, c.ClientIP()); err != nil {
+		response.Fail(c, err)
+		return
+	}
+	response.Success(c, gin.H{"sent": true})
+}
+
+// Login 验证码登录，首次登录自动创建账号
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.NewError(response.CodeInvalidParam, "phone and code are required"))
+		return
+	}
+	tokens, err := h.auth.Login(c.Request.Context(), req.Phone, req.Code, c.ClientIP())
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+	response.Success(c, tokens)
+}
+
+// Refresh 使用刷新 token 换取新的 token 对
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Fail(c, response.NewError(response.CodeInvalidParam, "refresh_token is required"))
+		return
+	}
+	tokens, err := h.auth.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+	response.Success(c, tokens)
+}
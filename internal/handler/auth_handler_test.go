@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/zhenglizhi/policy-fit/internal/auth"
+	"github.com/zhenglizhi/policy-fit/internal/config"
+	"github.com/zhenglizhi/policy-fit/internal/middleware"
+	"github.com/zhenglizhi/policy-fit/internal/repository"
+	"github.com/zhenglizhi/policy-fit/internal/service"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "json")
+	os.Exit(m.Run())
+}
+
+// discardSender 不发送验证码
+type discardSender struct{}
+
+func (discardSender) Send(ctx context.Context, phone, code string) error { return nil }
+
+// newAuthRouter 创建只注册登录路由的 router，proxies 为可信代理列表
+func newAuthRouter(t *testing.T, proxies []string, ipLimit int) *gin.Engine {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	jwt, err := auth.NewJWT("k1", map[string][]byte{"k1": []byte("test-signing-key")}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		OTP: config.OTPConfig{
+			TTL:               300,
+			MaxAttempts:       5,
+			SendInterval:      60,
+			PhoneHourlyLimit:  5,
+			IPHourlyLimit:     ipLimit,
+			PhoneFailureLimit: 10,
+			Pepper:            "test-pepper",
+		},
+		Security: config.SecurityConfig{JWTAccessTTL: 1800, JWTRefreshTTL: 3600},
+	}
+	svc := service.NewAuthService(repository.NewMemoryStore(), auth.NewOTPStore(rdb), discardSender{}, jwt, cfg)
+
+	router := gin.New()
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		t.Fatal(err)
+	}
+	router.Use(middleware.ErrorHandler())
+	RegisterAuthRoutes(router.Group("/api/v1"), NewAuthHandler(svc))
+	return router
+}
+
+// sendOTP 以 remoteAddr 连接、携带 forwardedFor 请求头请求验证码，返回状态码
+func sendOTP(router *gin.Engine, phone, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/otp", strings.NewReader(`{"phone":"`+phone+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestSendOTPIPLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	const limit = 3
+	router := newAuthRouter(t, nil, limit)
+
+	// 同一连接地址轮换 X-Forwarded-For，不应获得新的计数窗口
+	for i := 0; i < limit; i++ {
+		phone := fmt.Sprintf("1380000000%d", i)
+		if code := sendOTP(router, phone, "203.0.113.7:40000", fmt.Sprintf("198.51.100.%d", i)); code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i+1, code)
+		}
+	}
+	if code := sendOTP(router, "13800000009", "203.0.113.7:40001", "198.51.100.99"); code != http.StatusTooManyRequests {
+		t.Fatalf("spoofed X-Forwarded-For: status = %d, want 429", code)
+	}
+}
+
+func TestSendOTPIPLimitBehindTrustedProxy(t *testing.T) {
+	const limit = 1
+	router := newAuthRouter(t, []string{"10.0.0.1"}, limit)
+
+	// 可信代理转发的请求按 X-Forwarded-For 中的客户端地址分别计数
+	if code := sendOTP(router, "13800000001", "10.0.0.1:40000", "198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("first client: status = %d, want 200", code)
+	}
+	if code := sendOTP(router, "13800000002", "10.0.0.1:40000", "198.51.100.2"); code != http.StatusOK {
+		t.Fatalf("second client: status = %d, want 200", code)
+	}
+	if code := sendOTP(router, "13800000003", "10.0.0.1:40000", "198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("first client again: status = %d, want 429", code)
+	}
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/zhenglizhi/policy-fit/internal/service"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+// uploadError 将请求体超限与 multipart 格式错误转换为业务错误，其余错误原样返回
+func uploadError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return service.ErrFileTooLarge
+	case errors.Is(err, multipart.ErrMessageTooLarge), errors.Is(err, io.ErrUnexpectedEOF):
+		return response.NewError(response.CodeInvalidParam, "malformed multipart body")
+	default:
+		return err
+	}
+}
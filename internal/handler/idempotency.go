@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhenglizhi/policy-fit/internal/service"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+const (
+	// HeaderIdempotencyKey 幂等键请求头，也可通过请求体 request_id 字段传入
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed 标记响应来自幂等重放
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen = 64
+	maxIdempotentBody    = 64 << 10
+)
+
+// idempotencyFromRequest 解析幂等键并计算请求载荷摘要。
+// 载荷摘要基于去掉 request_id 后的规范化 JSON，因此字段顺序与空白不影响判定。
+func idempotencyFromRequest(c *gin.Context) (service.Idempotency, bool) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBody+1))
+	if err != nil || len(body) > maxIdempotentBody {
+		response.Fail(c, response.NewError(response.CodeInvalidParam, "invalid request body"))
+		return service.Idempotency{}, false
+	}
+
+	payload := map[string]interface{}{}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &payload); err != nil {
+			response.Fail(c, response.NewError(response.CodeInvalidParam, "request body must be a JSON object"))
+			return service.Idempotency{}, false
+		}
+	}
+
+	key := strings.TrimSpace(c.GetHeader(HeaderIdempotencyKey))
+	if raw, ok := payload["request_id"]; ok {
+		bodyKey, isString := raw.(string)
+		if !isString {
+			response.Fail(c, response.NewError(response.CodeInvalidParam, "request_id must be a string"))
+			return service.Idempotency{}, false
+		}
+		bodyKey = strings.TrimSpace(bodyKey)
+		if key != "" && bodyKey != "" && key != bodyKey {
+			response.Fail(c, response.NewError(response.CodeInvalidParam, "Idempotency-Key header and request_id do not match"))
+			return service.Idempotency{}, false
+		}
+		if key == "" {
+			key = bodyKey
+		}
+		delete(payload, "request_id")
+	}
+
+	if key == "" {
+		return service.Idempotency{}, true
+	}
+	if len(key) > maxIdempotencyKeyLen {
+		response.Fail(c, response.NewError(response.CodeInvalidParam, "idempotency key is too long"))
+		return service.Idempotency{}, false
+	}
+
+	// encoding/json 对 map 按 key 排序输出，可作为规范化表示
+	canonical, err := json.Marshal(payload)
+	if err != nil {
+		response.Fail(c, response.NewError(response.CodeInvalidParam, "invalid request body"))
+		return service.Idempotency{}, false
+	}
+	sum := sha256.Sum256(canonical)
+	return service.Idempotency{Key: key, RequestHash: hex.EncodeToString(sum[:])}, true
+}
@@ -1,26 +1,41 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/middleware"
+	"github.com/zhenglizhi/policy-fit/internal/service"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
 	"github.com/zhenglizhi/policy-fit/pkg/response"
 )
 
+// multipartOverhead multipart 边界与表单字段的额外字节预留
+const multipartOverhead = 1 << 20
+
 // TaskHandler 任务处理器
 type TaskHandler struct {
-	// TODO: 注入 service
+	tasks     *service.TaskService
+	documents *service.DocumentService
+	maxUpload int64
 }
 
-// NewTaskHandler 创建任务处理器
-func NewTaskHandler() *TaskHandler {
-	return &TaskHandler{}
+// NewTaskHandler 创建任务处理器，maxUpload 为单文件上传大小上限（字节）
+func NewTaskHandler(tasks *service.TaskService, documents *service.DocumentService, maxUpload int64) *TaskHandler {
+	return &TaskHandler{
+		tasks:     tasks,
+		documents: documents,
+		maxUpload: maxUpload,
+	}
 }
 
 // RegisterTaskRoutes 注册任务路由
-func RegisterTaskRoutes(r *gin.RouterGroup) {
-	h := NewTaskHandler()
-
+func RegisterTaskRoutes(r *gin.RouterGroup, h *TaskHandler) {
 	tasks := r.Group("/tasks")
 	{
 		tasks.POST("", h.CreateTask)
@@ -34,36 +49,195 @@ func RegisterTaskRoutes(r *gin.RouterGroup) {
 
 // CreateTask 创建任务
 func (h *TaskHandler) CreateTask(c *gin.Context) {
-	// TODO: 实现
-	response.Success(c, gin.H{"task_id": 1})
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	idem, ok := idempotencyFromRequest(c)
+	if !ok {
+		return
+	}
+
+	task, replayed, err := h.tasks.CreateTask(c.Request.Context(), userID, idem)
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+	markReplayed(c, replayed)
+	response.Success(c, gin.H{"task_id": task.ID, "status": task.Status})
 }
 
 // GetTask 获取任务详情
 func (h *TaskHandler) GetTask(c *gin.Context) {
-	// TODO: 实现
-	response.Success(c, gin.H{"status": "pending"})
+	taskID, ok := taskIDParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	task, err := h.tasks.GetTaskForUser(c.Request.Context(), taskID, userID)
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+	response.Success(c, task)
 }
 
 // UploadDocument 上传文档
+// multipart 表单字段：doc_type（须位于 file 之前，也可通过查询参数传入）与 file。
+// 文件以流式写入存储，不在内存中整体缓冲。
 func (h *TaskHandler) UploadDocument(c *gin.Context) {
-	// TODO: 实现
-	response.Success(c, gin.H{"document_id": 1})
+	taskID, ok := taskIDParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUpload+multipartOverhead)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		response.Fail(c, response.NewError(response.CodeInvalidParam, "request must be multipart/form-data"))
+		return
+	}
+
+	docType := c.Query("doc_type")
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			response.Fail(c, uploadError(err))
+			return
+		}
+
+		switch part.FormName() {
+		case "doc_type":
+			value, readErr := io.ReadAll(io.LimitReader(part, 64))
+			if readErr != nil {
+				response.Fail(c, uploadError(readErr))
+				return
+			}
+			docType = strings.TrimSpace(string(value))
+		case "file":
+			if docType == "" {
+				response.Fail(c, response.NewError(response.CodeInvalidParam, "doc_type is required and must precede file"))
+				return
+			}
+			doc, uploadErr := h.documents.Upload(c.Request.Context(), taskID, userID, domain.DocumentType(docType), part.FileName(), part)
+			if uploadErr != nil {
+				response.Fail(c, uploadError(uploadErr))
+				return
+			}
+			response.Success(c, gin.H{
+				"document_id":  doc.ID,
+				"doc_type":     doc.DocType,
+				"parse_status": doc.ParseStatus,
+			})
+			return
+		}
+		_ = part.Close()
+	}
+
+	response.Fail(c, response.NewError(response.CodeInvalidParam, "file is required"))
 }
 
 // RunTask 运行任务
 func (h *TaskHandler) RunTask(c *gin.Context) {
-	// TODO: 实现
-	response.Success(c, gin.H{"status": "running"})
+	taskID, ok := taskIDParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	idem, ok := idempotencyFromRequest(c)
+	if !ok {
+		return
+	}
+
+	task, replayed, err := h.tasks.RunTask(c.Request.Context(), taskID, userID, idem)
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+	markReplayed(c, replayed)
+	response.Success(c, gin.H{"task_id": task.ID, "status": task.Status})
 }
 
 // GetFindings 获取风险发现
 func (h *TaskHandler) GetFindings(c *gin.Context) {
-	// TODO: 实现
-	response.Success(c, gin.H{"findings": []interface{}{}})
+	taskID, ok := taskIDParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	findings, err := h.tasks.ListFindings(c.Request.Context(), taskID, userID)
+	if err != nil {
+		response.Fail(c, err)
+		return
+	}
+	if findings == nil {
+		findings = []domain.RiskFinding{}
+	}
+	response.Success(c, gin.H{"findings": findings})
 }
 
 // DeleteTask 删除任务
 func (h *TaskHandler) DeleteTask(c *gin.Context) {
-	// TODO: 实现
+	taskID, ok := taskIDParam(c)
+	if !ok {
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.tasks.DeleteTask(c.Request.Context(), taskID, userID); err != nil {
+		response.Fail(c, err)
+		return
+	}
+	// 任务记录已删除，对象清理失败只记录日志，残留对象由保留期清理兜底
+	if err := h.documents.PurgeTaskObjects(c.Request.Context(), taskID); err != nil {
+		logger.FromContext(c.Request.Context()).Warn("Failed to purge task objects", "task_id", taskID, "error", err)
+	}
 	c.Status(http.StatusNoContent)
 }
+
+func markReplayed(c *gin.Context, replayed bool) {
+	if replayed {
+		c.Header(HeaderIdempotentReplayed, "true")
+	}
+}
+
+func taskIDParam(c *gin.Context) (int64, bool) {
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || taskID <= 0 {
+		response.Fail(c, response.NewError(response.CodeInvalidParam, "invalid task id"))
+		return 0, false
+	}
+	return taskID, true
+}
+
+// currentUserID 获取鉴权中间件解析出的当前用户 ID
+func currentUserID(c *gin.Context) (int64, bool) {
+	userID, ok := middleware.UserID(c)
+	if !ok {
+		response.Fail(c, response.NewError(response.CodeUnauthorized, "missing or invalid user"))
+		return 0, false
+	}
+	return userID, true
+}
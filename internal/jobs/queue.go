@@ -0,0 +1,302 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/service"
+)
+
+const (
+	// StreamKey 分析任务消息流
+	StreamKey = "policyfit:tasks"
+	// ConsumerGroup 分析 worker 消费组
+	ConsumerGroup = "analysis-workers"
+	// RetryKey 待重试任务的有序集合，score 为到期时间（毫秒）
+	RetryKey = "policyfit:tasks:retry"
+	// DeadLetterKey 重试耗尽的任务死信流
+	DeadLetterKey = "policyfit:tasks:dead"
+
+	taskLockKeyPrefix = "policyfit:task-lock:"
+
+	// streamMaxLen 消息流近似保留长度，已确认的历史消息会被裁剪
+	streamMaxLen = 100000
+)
+
+// 仅在持有者匹配时续期或释放任务锁
+var (
+	// lockScript 锁空闲时获取；持有者为同一消息（原消费者崩溃后消息被重新认领）时接管并续期
+	lockScript = redis.NewScript(`
+local owner = redis.call("GET", KEYS[1])
+if not owner then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+if owner == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+return 0`)
+	refreshLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// promoteScript 原子地将到期的重试任务移回消息流，多个 worker 并发执行也不会重复投递
+var promoteScript = redis.NewScript(`
+local due = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+for _, member in ipairs(due) do
+	redis.call("ZREM", KEYS[1], member)
+	local job = cjson.decode(member)
+	redis.call("XADD", KEYS[2], "MAXLEN", "~", ARGV[3], "*",
+		"task_id", job.task_id, "request_id", job.request_id,
+		"retry_count", job.retry_count, "attempts", job.attempts)
+end
+return #due`)
+
+// Attempt 一次失败的执行记录
+type Attempt struct {
+	RetryCount  int               `json:"retry_count"`
+	Stage       domain.TaskStatus `json:"stage"`
+	FailureCode string            `json:"failure_code"`
+	Error       string            `json:"error"`
+	FailedAt    time.Time         `json:"failed_at"`
+}
+
+// DeadLetter 死信消息
+type DeadLetter struct {
+	Job         service.AnalysisJob
+	Stage       domain.TaskStatus
+	FailureCode string
+	LastError   string
+	Attempts    []Attempt
+}
+
+// Message 从消息流读取的分析任务
+type Message struct {
+	ID  string
+	Job service.AnalysisJob
+	// Attempts 此前失败的执行记录，随重试消息传递
+	Attempts []Attempt
+}
+
+// Queue 基于 Redis Streams 的分析任务队列，消费组保证至少一次投递：
+// 消息在确认前保留在 PEL 中，消费者崩溃后由其他消费者认领。
+type Queue struct {
+	rdb *redis.Client
+}
+
+// NewQueue 创建任务队列
+func NewQueue(rdb *redis.Client) *Queue {
+	return &Queue{rdb: rdb}
+}
+
+// Enqueue 投递分析任务，实现 service.JobQueue
+func (q *Queue) Enqueue(ctx context.Context, job service.AnalysisJob) error {
+	return addJob(ctx, q.rdb, job, nil)
+}
+
+// Requeue 将消息重新投递到流尾并确认原消息，两步在同一事务中执行。
+// worker 退出时归还尚未处理完的任务，重启或其他 worker 可立即接手，无需等待可见性超时。
+func (q *Queue) Requeue(ctx context.Context, msg *Message) error {
+	_, err := q.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if err := addJob(ctx, pipe, msg.Job, msg.Attempts); err != nil {
+			return err
+		}
+		return pipe.XAck(ctx, StreamKey, ConsumerGroup, msg.ID).Err()
+	})
+	return err
+}
+
+// addJob 写入一条任务消息，attempts 非空时随消息携带
+func addJob(ctx context.Context, rdb redis.Cmdable, job service.AnalysisJob, attempts []Attempt) error {
+	values := map[string]interface{}{
+		"task_id":     job.TaskID,
+		"request_id":  job.RequestID,
+		"retry_count": job.RetryCount,
+	}
+	if len(attempts) > 0 {
+		history, err := json.Marshal(attempts)
+		if err != nil {
+			return err
+		}
+		values["attempts"] = string(history)
+	}
+	return rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: StreamKey,
+		MaxLen: streamMaxLen,
+		Approx: true,
+		Values: values,
+	}).Err()
+}
+
+// ScheduleRetry 将任务放入重试集合，到期后由 PromoteDue 重新投递
+func (q *Queue) ScheduleRetry(ctx context.Context, job service.AnalysisJob, attempts []Attempt, due time.Time) error {
+	history, err := json.Marshal(attempts)
+	if err != nil {
+		return err
+	}
+	// 字段统一编码为字符串，避免 Lua cjson 将大整数转为浮点
+	member, err := json.Marshal(map[string]string{
+		"task_id":     strconv.FormatInt(job.TaskID, 10),
+		"request_id":  job.RequestID,
+		"retry_count": strconv.Itoa(job.RetryCount),
+		"attempts":    string(history),
+	})
+	if err != nil {
+		return err
+	}
+	return q.rdb.ZAdd(ctx, RetryKey, redis.Z{Score: float64(due.UnixMilli()), Member: member}).Err()
+}
+
+// PromoteDue 将最多 limit 个已到期的重试任务移回消息流，返回移动数量
+func (q *Queue) PromoteDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	return promoteScript.Run(ctx, q.rdb, []string{RetryKey, StreamKey}, now.UnixMilli(), limit, streamMaxLen).Int()
+}
+
+// DeadLetter 写入死信流，保留最后一次错误、失败阶段与全部执行记录
+func (q *Queue) DeadLetter(ctx context.Context, dl DeadLetter) error {
+	history, err := json.Marshal(dl.Attempts)
+	if err != nil {
+		return err
+	}
+	return q.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: DeadLetterKey,
+		MaxLen: streamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"task_id":      dl.Job.TaskID,
+			"request_id":   dl.Job.RequestID,
+			"retry_count":  dl.Job.RetryCount,
+			"stage":        string(dl.Stage),
+			"failure_code": dl.FailureCode,
+			"last_error":   dl.LastError,
+			"attempts":     string(history),
+		},
+	}).Err()
+}
+
+// EnsureGroup 创建消费组，从流的起点消费，避免丢失建组前投递的消息
+func (q *Queue) EnsureGroup(ctx context.Context) error {
+	err := q.rdb.XGroupCreateMkStream(ctx, StreamKey, ConsumerGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+	return nil
+}
+
+// Read 读取一条新消息，block 内没有消息时返回 nil
+func (q *Queue) Read(ctx context.Context, consumer string, block time.Duration) (*Message, error) {
+	streams, err := q.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    ConsumerGroup,
+		Consumer: consumer,
+		Streams:  []string{StreamKey, ">"},
+		Count:    1,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			return decodeMessage(msg), nil
+		}
+	}
+	return nil, nil
+}
+
+// Reclaim 认领一条空闲超过 minIdle 的待确认消息（原消费者已崩溃或失联），没有时返回 nil
+func (q *Queue) Reclaim(ctx context.Context, consumer string, minIdle time.Duration) (*Message, error) {
+	msgs, _, err := q.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   StreamKey,
+		Group:    ConsumerGroup,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    1,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+	return decodeMessage(msgs[0]), nil
+}
+
+// Extend 重置消息的空闲计时，长耗时任务处理期间定期调用，防止被其他消费者认领
+func (q *Queue) Extend(ctx context.Context, consumer, id string) error {
+	return q.rdb.XClaimJustID(ctx, &redis.XClaimArgs{
+		Stream:   StreamKey,
+		Group:    ConsumerGroup,
+		Consumer: consumer,
+		MinIdle:  0,
+		Messages: []string{id},
+	}).Err()
+}
+
+// Ack 确认消息
+func (q *Queue) Ack(ctx context.Context, id string) error {
+	return q.rdb.XAck(ctx, StreamKey, ConsumerGroup, id).Err()
+}
+
+// Lock 获取任务锁，保证同一任务同时只被一个消费者处理。owner 为消息 ID，
+// 锁仍由同一消息持有时视为获取成功，被重新认领的消息无需等待旧锁过期。
+func (q *Queue) Lock(ctx context.Context, taskID int64, owner string, ttl time.Duration) (bool, error) {
+	n, err := lockScript.Run(ctx, q.rdb, []string{taskLockKey(taskID)}, owner, ttl.Milliseconds()).Int()
+	return n == 1, err
+}
+
+// RefreshLock 续期任务锁
+func (q *Queue) RefreshLock(ctx context.Context, taskID int64, owner string, ttl time.Duration) error {
+	return refreshLockScript.Run(ctx, q.rdb, []string{taskLockKey(taskID)}, owner, ttl.Milliseconds()).Err()
+}
+
+// Unlock 释放任务锁
+func (q *Queue) Unlock(ctx context.Context, taskID int64, owner string) error {
+	return releaseLockScript.Run(ctx, q.rdb, []string{taskLockKey(taskID)}, owner).Err()
+}
+
+func taskLockKey(taskID int64) string {
+	return taskLockKeyPrefix + strconv.FormatInt(taskID, 10)
+}
+
+// decodeMessage 解析消息字段，字段缺失或格式错误时 Job.TaskID 为 0
+func decodeMessage(msg redis.XMessage) *Message {
+	field := func(name string) string {
+		v, _ := msg.Values[name].(string)
+		return v
+	}
+	taskID, _ := strconv.ParseInt(field("task_id"), 10, 64)
+	retryCount, _ := strconv.Atoi(field("retry_count"))
+	m := &Message{
+		ID: msg.ID,
+		Job: service.AnalysisJob{
+			TaskID:     taskID,
+			RequestID:  field("request_id"),
+			RetryCount: retryCount,
+		},
+	}
+	if attempts := field("attempts"); attempts != "" {
+		// 执行记录仅用于排查，损坏时忽略
+		_ = json.Unmarshal([]byte(attempts), &m.Attempts)
+	}
+	return m
+}
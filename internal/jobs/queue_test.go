@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/service"
+)
+
+func TestQueueReadAndReclaim(t *testing.T) {
+	ctx := context.Background()
+	w := newTestWorker(t, 0)
+	q := w.queue
+	if err := q.Enqueue(ctx, service.AnalysisJob{TaskID: 42, RequestID: "req-42"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	msg := w.read(t, "c1")
+	if msg.Job.TaskID != 42 || msg.Job.RequestID != "req-42" || msg.Job.RetryCount != 0 {
+		t.Fatalf("job = %+v", msg.Job)
+	}
+	if next, err := q.Read(ctx, "c2", time.Millisecond); err != nil || next != nil {
+		t.Fatalf("second Read = %v, %v, want no message", next, err)
+	}
+
+	// 未确认的消息空闲不足可见性超时时不可认领
+	if got, err := q.Reclaim(ctx, "c2", time.Minute); err != nil || got != nil {
+		t.Fatalf("Reclaim before timeout = %v, %v, want none", got, err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	got, err := q.Reclaim(ctx, "c2", 10*time.Millisecond)
+	if err != nil || got == nil || got.ID != msg.ID || got.Job != msg.Job {
+		t.Fatalf("Reclaim = %+v, %v, want message %s", got, err, msg.ID)
+	}
+
+	// 确认后不再被认领
+	if err := q.Ack(ctx, msg.ID); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got, err := q.Reclaim(ctx, "c3", 10*time.Millisecond); err != nil || got != nil {
+		t.Fatalf("Reclaim after ack = %v, %v, want none", got, err)
+	}
+}
+
+func TestQueueEnsureGroupIsIdempotent(t *testing.T) {
+	w := newTestWorker(t, 0)
+	if err := w.queue.EnsureGroup(context.Background()); err != nil {
+		t.Fatalf("second EnsureGroup: %v", err)
+	}
+}
+
+func TestQueueTaskLock(t *testing.T) {
+	ctx := context.Background()
+	w := newTestWorker(t, 0)
+	q := w.queue
+	lock := func(owner string) bool {
+		t.Helper()
+		ok, err := q.Lock(ctx, 7, owner, time.Minute)
+		if err != nil {
+			t.Fatalf("Lock(%s): %v", owner, err)
+		}
+		return ok
+	}
+
+	if !lock("m1") {
+		t.Fatal("m1 could not take a free lock")
+	}
+	if lock("m2") {
+		t.Fatal("m2 took a lock held by m1")
+	}
+	// 重新认领的同一消息接管自己的锁
+	if !lock("m1") {
+		t.Fatal("m1 could not take over its own lock")
+	}
+	// 只有持有者可以释放
+	if err := q.Unlock(ctx, 7, "m2"); err != nil {
+		t.Fatalf("Unlock(m2): %v", err)
+	}
+	if lock("m2") {
+		t.Fatal("lock released by a non-owner")
+	}
+	if err := q.Unlock(ctx, 7, "m1"); err != nil {
+		t.Fatalf("Unlock(m1): %v", err)
+	}
+	if !lock("m2") {
+		t.Fatal("m2 could not take a released lock")
+	}
+
+	// 持有者崩溃后锁到期释放，续期可延后到期时间
+	if err := q.RefreshLock(ctx, 7, "m2", 2*time.Minute); err != nil {
+		t.Fatalf("RefreshLock: %v", err)
+	}
+	w.mr.FastForward(90 * time.Second)
+	if lock("m3") {
+		t.Fatal("m3 took a refreshed lock")
+	}
+	w.mr.FastForward(time.Minute)
+	if !lock("m3") {
+		t.Fatal("m3 could not take an expired lock")
+	}
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+// maxRetryDelay 单次重试退避上限
+const maxRetryDelay = 10 * time.Minute
+
+// Retryable 可由阶段错误实现，显式声明是否值得重试，优先级高于失败码
+type Retryable interface {
+	Retryable() bool
+}
+
+// RetryDelayer 可由阶段错误实现，给出最短重试等待（如上游 Retry-After），
+// 大于退避时长时以其为准，同样受 maxRetryDelay 限制
+type RetryDelayer interface {
+	RetryAfter() time.Duration
+}
+
+// retryableCodes 可重试的失败码：瞬时故障，重试有望成功。
+// 未列出的失败码（PDF 不可读、LLM 输出未通过 Schema 校验等）视为终态错误，
+// 未携带失败码的错误按 PFIT-1005 处理（存储、数据库等基础设施抖动）。
+var retryableCodes = map[string]bool{
+	response.CodeInternal:       true,
+	response.CodeLLMTimeout:     true,
+	response.CodeLLMInvalidJSON: true,
+	response.CodeLLMUnavailable: true,
+}
+
+// isRetryable 判断阶段错误是否可重试
+func isRetryable(err error) bool {
+	var r Retryable
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	return retryableCodes[failureCode(err)]
+}
+
+// retryDelay 计算重试等待：指数退避与错误给出的最短等待取较大值
+func retryDelay(err error, base time.Duration, retryCount int) time.Duration {
+	d := backoff(base, retryCount)
+	var delayer RetryDelayer
+	if errors.As(err, &delayer) {
+		if after := delayer.RetryAfter(); after > d {
+			d = after
+		}
+	}
+	if d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+	return d
+}
+
+// backoff 计算第 retryCount 次重试（从 1 开始）的等待时长：base*2^(n-1)，
+// 上限 maxRetryDelay，并在 [d/2, d] 内随机抖动，避免同批失败任务同时重试
+func backoff(base time.Duration, retryCount int) time.Duration {
+	d := base
+	for i := 1; i < retryCount && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	if d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,174 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/llm"
+	"github.com/zhenglizhi/policy-fit/internal/parser"
+	"github.com/zhenglizhi/policy-fit/internal/service"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+func TestFailureCodeClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCode  string
+		retryable bool
+	}{
+		{"unclassified error", errors.New("connection reset"), response.CodeInternal, true},
+		{"deadline exceeded", fmt.Errorf("save: %w", context.DeadlineExceeded), response.CodeInternal, true},
+		{"business error", fmt.Errorf("start: %w", service.ErrMissingDocuments), response.CodeTaskMissingDocuments, false},
+		{"parser unreadable", &parser.Error{Kind: parser.KindUnreadable, Err: errors.New("encrypted")}, response.CodeDocumentUnreadable, false},
+		{"parser empty text", &parser.Error{Kind: parser.KindEmptyText, Err: errors.New("no text")}, response.CodeDocumentNoText, false},
+		{"parser command error", &parser.Error{Kind: parser.KindCommand, Err: errors.New("exit status 1")}, response.CodeInternal, true},
+		{"illegal transition", &service.TransitionError{From: domain.TaskStatusSuccess, To: domain.TaskStatusParsing}, response.CodeTaskStateConflict, false},
+		{"llm rate limited", fmt.Errorf("extract: %w", &llm.Error{Kind: llm.KindRateLimited}), response.CodeLLMUnavailable, true},
+		{"llm timeout", &llm.Error{Kind: llm.KindTimeout}, response.CodeLLMTimeout, true},
+		{"llm invalid output", &llm.Error{Kind: llm.KindInvalidOutput}, response.CodeLLMInvalidJSON, true},
+		{"llm auth", &llm.Error{Kind: llm.KindAuth, StatusCode: 401}, response.CodeInternal, false},
+		{"llm request", &llm.Error{Kind: llm.KindRequest, StatusCode: 400}, response.CodeInternal, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := failureCode(tt.err); got != tt.wantCode {
+				t.Errorf("failureCode = %s, want %s", got, tt.wantCode)
+			}
+			if got := isRetryable(tt.err); got != tt.retryable {
+				t.Errorf("isRetryable = %v, want %v", got, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestRetryDelayHonoursRetryAfter(t *testing.T) {
+	base := time.Second
+	limited := fmt.Errorf("extract: %w", &llm.Error{Kind: llm.KindRateLimited, RetryAfterDelay: 7 * time.Second})
+	if got := retryDelay(limited, base, 1); got != 7*time.Second {
+		t.Errorf("retryDelay = %s, want Retry-After 7s", got)
+	}
+	// 退避时长更长时以退避为准
+	if got := retryDelay(&llm.Error{Kind: llm.KindRateLimited, RetryAfterDelay: time.Millisecond}, base, 1); got < base/2 || got > base {
+		t.Errorf("retryDelay = %s, want backoff within [%s, %s]", got, base/2, base)
+	}
+	// Retry-After 同样受 maxRetryDelay 限制
+	if got := retryDelay(&llm.Error{Kind: llm.KindRateLimited, RetryAfterDelay: time.Hour}, base, 1); got != maxRetryDelay {
+		t.Errorf("retryDelay = %s, want %s", got, maxRetryDelay)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base := 10 * time.Second
+	for retry := 1; retry <= 10; retry++ {
+		want := base << (retry - 1)
+		if want > maxRetryDelay || want <= 0 {
+			want = maxRetryDelay
+		}
+		for i := 0; i < 20; i++ {
+			if d := backoff(base, retry); d < want/2 || d > want {
+				t.Fatalf("backoff(%s, %d) = %s, want within [%s, %s]", base, retry, d, want/2, want)
+			}
+		}
+	}
+}
+
+func TestRetryThenDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	w := newTestWorker(t, 1)
+	w.Handle(domain.TaskStatusParsing, func(ctx context.Context, run *Run) (interface{}, error) {
+		return nil, &llm.Error{Kind: llm.KindTimeout, Err: context.DeadlineExceeded}
+	})
+	taskID := w.enqueue(t)
+
+	// 第一次失败：按退避时间放入重试集合，任务停留在失败阶段
+	if w.handle(ctx, "c1", w.read(t, "c1")) {
+		t.Fatal("handle reported an interruption")
+	}
+	task := w.status(t, taskID)
+	if task.Status != domain.TaskStatusParsing || task.RetryCount != 1 {
+		t.Fatalf("task = %s retry %d, want parsing retry 1", task.Status, task.RetryCount)
+	}
+	if n := w.pending(t); n != 0 {
+		t.Fatalf("pending = %d, want the failed message acked", n)
+	}
+	now := time.Now()
+	if n, err := w.queue.PromoteDue(ctx, now, 10); err != nil || n != 0 {
+		t.Fatalf("PromoteDue before the backoff = %d, %v, want 0", n, err)
+	}
+	if n, err := w.queue.PromoteDue(ctx, now.Add(time.Hour), 10); err != nil || n != 1 {
+		t.Fatalf("PromoteDue after the backoff = %d, %v, want 1", n, err)
+	}
+
+	msg := w.read(t, "c1")
+	if msg.Job.TaskID != taskID || msg.Job.RequestID != "req-1" || msg.Job.RetryCount != 1 {
+		t.Fatalf("retried job = %+v", msg.Job)
+	}
+	if len(msg.Attempts) != 1 || msg.Attempts[0].FailureCode != response.CodeLLMTimeout || msg.Attempts[0].Stage != domain.TaskStatusParsing {
+		t.Fatalf("attempts = %+v", msg.Attempts)
+	}
+
+	// 重试耗尽：写入死信流并置为 failed
+	if w.handle(ctx, "c1", msg) {
+		t.Fatal("handle reported an interruption")
+	}
+	task = w.status(t, taskID)
+	if task.Status != domain.TaskStatusFailed || task.FailureCode != response.CodeLLMTimeout || task.RetryCount != 1 {
+		t.Fatalf("task = %s %s retry %d, want failed %s retry 1", task.Status, task.FailureCode, task.RetryCount, response.CodeLLMTimeout)
+	}
+	dead, err := w.rdb.XRange(ctx, DeadLetterKey, "-", "+").Result()
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("dead letters = %v, %v, want one", dead, err)
+	}
+	if v := dead[0].Values; v["stage"] != string(domain.TaskStatusParsing) || v["failure_code"] != response.CodeLLMTimeout || v["retry_count"] != "1" {
+		t.Errorf("dead letter = %v", v)
+	}
+	var attempts []Attempt
+	if err := json.Unmarshal([]byte(dead[0].Values["attempts"].(string)), &attempts); err != nil || len(attempts) != 2 {
+		t.Errorf("dead letter attempts = %+v, %v, want 2", attempts, err)
+	}
+}
+
+func TestTerminalFailureSkipsRetry(t *testing.T) {
+	ctx := context.Background()
+	w := newTestWorker(t, 2)
+	w.Handle(domain.TaskStatusParsing, func(ctx context.Context, run *Run) (interface{}, error) {
+		return nil, &parser.Error{Kind: parser.KindUnreadable, Err: errors.New("encrypted")}
+	})
+	taskID := w.enqueue(t)
+
+	if w.handle(ctx, "c1", w.read(t, "c1")) {
+		t.Fatal("handle reported an interruption")
+	}
+	task := w.status(t, taskID)
+	if task.Status != domain.TaskStatusFailed || task.FailureCode != response.CodeDocumentUnreadable || task.RetryCount != 0 {
+		t.Fatalf("task = %s %s retry %d, want failed %s", task.Status, task.FailureCode, task.RetryCount, response.CodeDocumentUnreadable)
+	}
+	// 终态错误既不重试也不进入死信流
+	if n, _ := w.rdb.ZCard(ctx, RetryKey).Result(); n != 0 {
+		t.Errorf("retries scheduled = %d, want 0", n)
+	}
+	if n, _ := w.rdb.XLen(ctx, DeadLetterKey).Result(); n != 0 {
+		t.Errorf("dead letters = %d, want 0", n)
+	}
+}
+
+func TestLastAttempt(t *testing.T) {
+	w := newTestWorker(t, 0)
+	var last []bool
+	w.Handle(domain.TaskStatusParsing, func(ctx context.Context, run *Run) (interface{}, error) {
+		last = append(last, run.LastAttempt())
+		return nil, nil
+	})
+	w.enqueue(t)
+	w.handle(context.Background(), "c1", w.read(t, "c1"))
+	// WORKER_MAX_RETRIES=0 时首次执行即为最后一次
+	if len(last) != 1 || !last[0] {
+		t.Fatalf("LastAttempt = %v, want [true]", last)
+	}
+}
@@ -2,32 +2,485 @@ package jobs
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/zhenglizhi/policy-fit/internal/config"
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/service"
 	"github.com/zhenglizhi/policy-fit/pkg/logger"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
 )
 
+// pipeline 分析流水线的阶段顺序，与任务状态一一对应
+var pipeline = []domain.TaskStatus{
+	domain.TaskStatusParsing,
+	domain.TaskStatusExtracting,
+	domain.TaskStatusMatching,
+}
+
+const (
+	// readBlock 单次阻塞读取消息的最长等待时间
+	readBlock = 5 * time.Second
+	// retryPollInterval 重试集合轮询间隔
+	retryPollInterval = time.Second
+	// retryPromoteBatch 每次轮询最多移回的重试任务数
+	retryPromoteBatch = 100
+)
+
+// StageFunc 阶段处理函数。返回的产出与状态推进在同一事务中写入检查点，
+// 后续阶段（包括 worker 重启后恢复执行的阶段）通过 Run.Output 读取；返回 nil 时不写检查点。
+type StageFunc func(ctx context.Context, run *Run) (interface{}, error)
+
+// Run 阶段执行上下文
+type Run struct {
+	Task       *domain.AnalysisTask
+	tasks      *service.TaskService
+	maxRetries int
+}
+
+// LastAttempt 本次执行失败后不再重试，阶段可据此启用降级处理
+func (r *Run) LastAttempt() bool {
+	return r.Task.RetryCount >= r.maxRetries
+}
+
+// Output 读取已完成阶段的产出并解码到 v，阶段未完成时返回 service.ErrCheckpointNotFound
+func (r *Run) Output(ctx context.Context, stage domain.TaskStatus, v interface{}) error {
+	return r.tasks.StageOutput(ctx, r.Task.ID, stage, v)
+}
+
+// FailureCoder 可由阶段错误实现，用于指定写入任务的失败码
+type FailureCoder interface {
+	FailureCode() string
+}
+
+// StageError 阶段执行失败且任务已置为 failed
+type StageError struct {
+	Stage domain.TaskStatus
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("stage %s failed: %v", e.Stage, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
 // Worker 任务处理器
 type Worker struct {
-	cfg *config.Config
+	cfg    *config.Config
+	tasks  *service.TaskService
+	queue  *Queue
+	stages map[domain.TaskStatus]StageFunc
+
+	// consumer 消费者名前缀，每个并发协程使用独立的消费者名
+	consumer     string
+	visibility   time.Duration
+	retryBase    time.Duration
+	drainTimeout time.Duration
+	// inflight 正在处理的任务数
+	inflight atomic.Int64
 }
 
 // NewWorker 创建 Worker
-func NewWorker(cfg *config.Config) *Worker {
+func NewWorker(cfg *config.Config, tasks *service.TaskService, queue *Queue) *Worker {
+	hostname, _ := os.Hostname()
 	return &Worker{
-		cfg: cfg,
+		cfg:          cfg,
+		tasks:        tasks,
+		queue:        queue,
+		stages:       make(map[domain.TaskStatus]StageFunc),
+		consumer:     fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		visibility:   time.Duration(cfg.Worker.VisibilityTimeout) * time.Second,
+		retryBase:    time.Duration(cfg.Worker.RetryBaseDelay) * time.Second,
+		drainTimeout: time.Duration(cfg.Worker.DrainTimeout) * time.Second,
 	}
 }
 
-// Start 启动 Worker
+// Handle 注册阶段处理函数，未注册的阶段直接跳过
+func (w *Worker) Handle(stage domain.TaskStatus, fn StageFunc) {
+	w.stages[stage] = fn
+}
+
+// Start 启动 WORKER_CONCURRENCY 个消费协程，阻塞直到 ctx 取消且所有协程退出。
+// ctx 取消后停止领取新消息，在途任务最多再执行 WORKER_DRAIN_TIMEOUT；
+// 超时仍未完成的任务被中断，停留在最后完成阶段的下一阶段，消息归还队列后从该阶段恢复。
 func (w *Worker) Start(ctx context.Context) error {
-	logger.Info("Worker started")
+	if err := w.queue.EnsureGroup(ctx); err != nil {
+		return err
+	}
+	logger.Info("Worker started", "consumer", w.consumer, "concurrency", w.cfg.Worker.Concurrency)
 
-	// TODO: 实现任务队列消费逻辑
-	// 1. 连接 Redis
-	// 2. 监听任务队列
-	// 3. 处理任务（解析、抽取、匹配）
+	// 在途任务使用独立的 jobCtx，退出信号不会立即中断正在执行的阶段
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
 
-	<-ctx.Done()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.promoteRetries(ctx)
+	}()
+	for i := 0; i < w.cfg.Worker.Concurrency; i++ {
+		wg.Add(1)
+		go func(consumer string) {
+			defer wg.Done()
+			w.consume(ctx, jobCtx, consumer)
+		}(fmt.Sprintf("%s-%d", w.consumer, i))
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+	}
+	logger.Info("Worker draining in-flight jobs", "timeout", w.drainTimeout)
+
+	timer := time.NewTimer(w.drainTimeout)
+	defer timer.Stop()
+	select {
+	case <-drained:
+		logger.Info("Worker drained")
+	case <-timer.C:
+		// 空闲消费者仍可能阻塞在读取上，仅在确有在途任务时中断
+		if n := w.inflight.Load(); n > 0 {
+			logger.Warn("Drain timeout reached, interrupting in-flight jobs", "jobs", n)
+		}
+		cancelJobs()
+		<-drained
+	}
 	return nil
 }
+
+// promoteRetries 定期将到期的重试任务移回消息流
+func (w *Worker) promoteRetries(ctx context.Context) {
+	ticker := time.NewTicker(retryPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			n, err := w.queue.PromoteDue(ctx, now, retryPromoteBatch)
+			if err != nil && ctx.Err() == nil {
+				logger.Error("Failed to promote due retries", "error", err)
+			}
+			if n > 0 {
+				logger.Info("Promoted due retries", "count", n)
+			}
+		}
+	}
+}
+
+// consume 消费循环：优先认领超时未确认的消息，其次读取新消息。
+// ctx 取消后不再领取消息；jobCtx 控制在途任务，被中断的任务归还队列。
+func (w *Worker) consume(ctx, jobCtx context.Context, consumer string) {
+	var lastReclaim time.Time
+	for ctx.Err() == nil {
+		var (
+			msg *Message
+			err error
+		)
+		if time.Since(lastReclaim) >= w.visibility/2 {
+			lastReclaim = time.Now()
+			msg, err = w.queue.Reclaim(ctx, consumer, w.visibility)
+			if msg != nil {
+				logger.FromContext(jobContext(ctx, msg.Job)).Warn("Reclaimed stale job", "consumer", consumer, "message_id", msg.ID)
+			}
+		}
+		if msg == nil && err == nil {
+			msg, err = w.queue.Read(ctx, consumer, readBlock)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Error("Failed to read job", "consumer", consumer, "error", err)
+			sleep(ctx, time.Second)
+			continue
+		}
+		if msg == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			// 读取与退出信号同时发生，尚未开始处理，直接归还
+			w.requeue(jobCtx, msg)
+			return
+		}
+		w.inflight.Add(1)
+		interrupted := w.handle(jobCtx, consumer, msg)
+		w.inflight.Add(-1)
+		if interrupted {
+			// 任务锁已释放，归还后重启的 worker 可立即接手
+			w.requeue(jobCtx, msg)
+		}
+	}
+}
+
+// handle 处理单条消息，流水线提交或失败处理完成后才确认；
+// 基础设施错误不计入重试，消息不确认，等待超时后重新认领。
+// ctx 被取消导致中断时返回 true，由调用方在释放任务锁后归还消息。
+func (w *Worker) handle(ctx context.Context, consumer string, msg *Message) (interrupted bool) {
+	job := msg.Job
+	if job.TaskID == 0 {
+		logger.Error("Dropping malformed job", "message_id", msg.ID)
+		w.ack(ctx, msg)
+		return false
+	}
+	ctx = jobContext(ctx, job)
+	log := logger.FromContext(ctx)
+
+	locked, err := w.queue.Lock(ctx, job.TaskID, msg.ID, w.lockTTL())
+	if err != nil {
+		log.Error("Failed to lock task", "error", err)
+		return false
+	}
+	if !locked {
+		// 任务正由其他消息处理，不确认：可见性超时后重新认领时再判断，
+		// 届时任务已结束则按状态跳过，持有者已崩溃则锁已过期可获取
+		log.Info("Task is being processed by another job, leaving message pending", "message_id", msg.ID)
+		return false
+	}
+	defer func() {
+		if err := w.queue.Unlock(context.WithoutCancel(ctx), job.TaskID, msg.ID); err != nil {
+			log.Warn("Failed to release task lock", "error", err)
+		}
+	}()
+
+	stop := w.heartbeat(ctx, consumer, msg)
+	err = w.Process(ctx, job.TaskID)
+	stop()
+
+	var stageErr *StageError
+	switch {
+	case err == nil:
+		w.ack(ctx, msg)
+	case ctx.Err() != nil:
+		log.Warn("Job interrupted by shutdown, resuming from checkpoint later", "message_id", msg.ID, "error", err)
+		return true
+	case errors.As(err, &stageErr):
+		if settleErr := w.settleFailure(ctx, msg, stageErr); settleErr != nil {
+			log.Error("Failed to settle stage failure, leaving job pending", "error", settleErr)
+			return false
+		}
+		w.ack(ctx, msg)
+	case errors.Is(err, service.ErrTaskNotFound):
+		log.Info("Task deleted, dropping job", "message_id", msg.ID)
+		w.ack(ctx, msg)
+	default:
+		log.Error("Job processing failed, leaving it pending", "message_id", msg.ID, "error", err)
+	}
+	return false
+}
+
+// requeue 归还消息，失败时消息留在 PEL 中，等待超时后由其他消费者认领
+func (w *Worker) requeue(ctx context.Context, msg *Message) {
+	log := logger.FromContext(jobContext(ctx, msg.Job))
+	if err := w.queue.Requeue(context.WithoutCancel(ctx), msg); err != nil {
+		log.Error("Failed to requeue job, leaving it pending", "message_id", msg.ID, "error", err)
+		return
+	}
+	log.Info("Job requeued", "message_id", msg.ID)
+}
+
+// jobContext 在 ctx 上记录任务 ID 与发起分析的请求 ID，worker 日志可按 request_id 与 API 日志串联
+func jobContext(ctx context.Context, job service.AnalysisJob) context.Context {
+	return logger.WithTaskID(logger.WithRequestID(ctx, job.RequestID), job.TaskID)
+}
+
+// heartbeat 处理期间定期重置消息空闲时间并续期任务锁，返回停止函数
+func (w *Worker) heartbeat(ctx context.Context, consumer string, msg *Message) func() {
+	hbCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(w.visibility / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-hbCtx.Done():
+				return
+			case <-ticker.C:
+				if err := w.queue.Extend(hbCtx, consumer, msg.ID); err != nil && hbCtx.Err() == nil {
+					logger.FromContext(ctx).Warn("Failed to extend job visibility", "message_id", msg.ID, "error", err)
+				}
+				if err := w.queue.RefreshLock(hbCtx, msg.Job.TaskID, msg.ID, w.lockTTL()); err != nil && hbCtx.Err() == nil {
+					logger.FromContext(ctx).Warn("Failed to refresh task lock", "error", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// lockTTL 任务锁有效期，严格短于可见性超时：持有者崩溃后，
+// 其他消息在下一次认领前锁已过期
+func (w *Worker) lockTTL() time.Duration {
+	return w.visibility * 3 / 4
+}
+
+func (w *Worker) ack(ctx context.Context, msg *Message) {
+	if err := w.queue.Ack(context.WithoutCancel(ctx), msg.ID); err != nil {
+		logger.Error("Failed to ack job", "message_id", msg.ID, "error", err)
+	}
+}
+
+// Process 按阶段执行分析流水线，所有状态流转均经由 TaskService。
+// 每个阶段完成时其产出与下一状态在同一事务中提交，任务处于某个中间阶段时
+// 说明此前阶段均已完成，直接从该阶段继续执行；已结束的任务直接跳过。
+// 阶段失败时返回 *StageError，任务停留在失败阶段，由调用方决定重试或置为 failed。
+func (w *Worker) Process(ctx context.Context, taskID int64) error {
+	ctx = logger.WithTaskID(ctx, taskID)
+	log := logger.FromContext(ctx)
+	task, err := w.tasks.GetTask(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if task.Status == domain.TaskStatusSuccess || task.Status == domain.TaskStatusFailed {
+		// 重复或过期的消息
+		log.Info("Task already settled, skipping", "status", task.Status)
+		return nil
+	}
+
+	start, err := resumeIndex(task.Status)
+	if err != nil {
+		return err
+	}
+	if task.Status == domain.TaskStatusPending {
+		if task, err = w.tasks.Transition(ctx, taskID, pipeline[0]); err != nil {
+			return err
+		}
+	} else if start > 0 {
+		logger.FromContext(logger.WithStage(ctx, string(task.Status))).Info("Resuming task from checkpoint")
+	}
+
+	for i, stage := range pipeline[start:] {
+		next := domain.TaskStatusSuccess
+		if start+i+1 < len(pipeline) {
+			next = pipeline[start+i+1]
+		}
+
+		stageCtx := logger.WithStage(ctx, string(stage))
+		logger.FromContext(stageCtx).Info("Task stage started")
+		var output interface{}
+		if fn := w.stages[stage]; fn != nil {
+			var stageErr error
+			if output, stageErr = fn(stageCtx, &Run{Task: task, tasks: w.tasks, maxRetries: w.cfg.Worker.MaxRetries}); stageErr != nil {
+				return &StageError{Stage: stage, Err: stageErr}
+			}
+		}
+		if task, err = w.tasks.CompleteStage(ctx, taskID, stage, next, output); err != nil {
+			return err
+		}
+	}
+
+	log.Info("Task completed")
+	return nil
+}
+
+// settleFailure 处理阶段失败：可重试且未超过 WORKER_MAX_RETRIES 时按退避时间重新调度，
+// 否则将任务置为 failed；重试耗尽的任务同时写入死信流。
+func (w *Worker) settleFailure(ctx context.Context, msg *Message, stageErr *StageError) error {
+	job := msg.Job
+	code := failureCode(stageErr.Err)
+	retryable := isRetryable(stageErr.Err)
+	attempts := append(msg.Attempts, Attempt{
+		RetryCount:  job.RetryCount,
+		Stage:       stageErr.Stage,
+		FailureCode: code,
+		Error:       stageErr.Err.Error(),
+		FailedAt:    time.Now().UTC(),
+	})
+	log := logger.FromContext(logger.WithStage(ctx, string(stageErr.Stage)))
+	log.Error("Task stage failed",
+		"failure_code", code,
+		"retry_count", job.RetryCount,
+		"retryable", retryable,
+		"error", stageErr.Err,
+	)
+
+	if retryable && job.RetryCount < w.cfg.Worker.MaxRetries {
+		next := job
+		next.RetryCount++
+		delay := retryDelay(stageErr.Err, w.retryBase, next.RetryCount)
+		if _, err := w.tasks.RecordRetry(ctx, job.TaskID, next.RetryCount, code); err != nil {
+			return fmt.Errorf("failed to record retry: %w", err)
+		}
+		if err := w.queue.ScheduleRetry(ctx, next, attempts, time.Now().Add(delay)); err != nil {
+			return fmt.Errorf("failed to schedule retry: %w", err)
+		}
+		log.Warn("Task retry scheduled", "retry_count", next.RetryCount, "delay", delay)
+		return nil
+	}
+
+	if retryable {
+		// 先写死信再置为失败：中途崩溃时消息会被重新认领，宁可重复也不丢失死信
+		err := w.queue.DeadLetter(ctx, DeadLetter{
+			Job:         job,
+			Stage:       stageErr.Stage,
+			FailureCode: code,
+			LastError:   stageErr.Err.Error(),
+			Attempts:    attempts,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to dead-letter job: %w", err)
+		}
+		log.Error("Task retries exhausted, moved to dead-letter stream", "retry_count", job.RetryCount)
+	}
+
+	if _, err := w.tasks.MarkFailed(ctx, job.TaskID, code, job.RetryCount); err != nil {
+		return fmt.Errorf("failed to mark task failed: %w", err)
+	}
+	return nil
+}
+
+// resumeIndex 计算流水线的起始阶段
+func resumeIndex(status domain.TaskStatus) (int, error) {
+	if status == domain.TaskStatusPending {
+		return 0, nil
+	}
+	for i, stage := range pipeline {
+		if stage == status {
+			return i, nil
+		}
+	}
+	return 0, &service.TransitionError{From: status, To: domain.TaskStatusParsing}
+}
+
+// failureCode 优先取 FailureCoder 给出的失败码，其次取错误链中业务错误（如 service 哨兵错误）的错误码
+func failureCode(err error) string {
+	var coder FailureCoder
+	if errors.As(err, &coder) {
+		return coder.FailureCode()
+	}
+	var coded *response.Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	return response.CodeInternal
+}
+
+// sleep 等待 d 或 ctx 取消
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
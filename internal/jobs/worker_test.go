@@ -0,0 +1,397 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/zhenglizhi/policy-fit/internal/config"
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/llm"
+	"github.com/zhenglizhi/policy-fit/internal/llm/llmfake"
+	"github.com/zhenglizhi/policy-fit/internal/repository"
+	"github.com/zhenglizhi/policy-fit/internal/service"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("error", "json")
+	os.Exit(m.Run())
+}
+
+// testWorker 连接 miniredis 与内存存储的 worker
+type testWorker struct {
+	*Worker
+	mr    *miniredis.Miniredis
+	rdb   *redis.Client
+	store *repository.MemoryStore
+}
+
+func newTestWorker(t *testing.T, maxRetries int) *testWorker {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	queue := NewQueue(rdb)
+	if err := queue.EnsureGroup(context.Background()); err != nil {
+		t.Fatalf("EnsureGroup: %v", err)
+	}
+	store := repository.NewMemoryStore()
+	cfg := &config.Config{Worker: config.WorkerConfig{
+		Concurrency:       1,
+		VisibilityTimeout: 60,
+		MaxRetries:        maxRetries,
+		RetryBaseDelay:    10,
+		DrainTimeout:      1,
+	}}
+	return &testWorker{
+		Worker: NewWorker(cfg, service.NewTaskService(store, queue), queue),
+		mr:     mr,
+		rdb:    rdb,
+		store:  store,
+	}
+}
+
+// enqueue 创建任务并投递分析消息，返回任务 ID
+func (w *testWorker) enqueue(t *testing.T) int64 {
+	t.Helper()
+	ctx := context.Background()
+	task, _, err := w.tasks.CreateTask(ctx, 1, service.Idempotency{})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	if err := w.queue.Enqueue(ctx, service.AnalysisJob{TaskID: task.ID, RequestID: "req-1"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	return task.ID
+}
+
+// read 以 consumer 读取一条新消息
+func (w *testWorker) read(t *testing.T, consumer string) *Message {
+	t.Helper()
+	msg, err := w.queue.Read(context.Background(), consumer, time.Millisecond)
+	if err != nil || msg == nil {
+		t.Fatalf("Read = %v, %v, want a message", msg, err)
+	}
+	return msg
+}
+
+// status 任务当前状态
+func (w *testWorker) status(t *testing.T, taskID int64) *domain.AnalysisTask {
+	t.Helper()
+	task, err := w.tasks.GetTask(context.Background(), taskID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	return task
+}
+
+// captureLogs 将全局日志改为写入临时文件，返回读取输出的函数，测试结束后恢复
+func captureLogs(t *testing.T) func() string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "worker.log")
+	if err := logger.InitWithOutputs("debug", "json", logger.DefaultRules(), path); err != nil {
+		t.Fatalf("InitWithOutputs: %v", err)
+	}
+	t.Cleanup(func() { logger.Init("error", "json") })
+	return func() string {
+		logger.Sync()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read log output: %v", err)
+		}
+		return string(data)
+	}
+}
+
+func TestStageFailureKeepsReportTextOutOfLogsAndDeadLetters(t *testing.T) {
+	const reportText = "受检者张三 电话 13812345678 收缩压 152 mmHg 诊断高血压"
+	raw := []string{"张三", "13812345678", "收缩压", "诊断高血压"}
+
+	fake, err := llmfake.New()
+	if err != nil {
+		t.Fatalf("llmfake.New: %v", err)
+	}
+	// 模型原样回显报告内容；供应商错误描述引用提示词
+	fake.Add(llmfake.Fixture{Path: llmfake.PathChatCompletions, Match: "__echo__", Status: http.StatusOK, Body: json.RawMessage(
+		`{"model":"m","choices":[{"message":{"role":"assistant","content":"` + reportText + `"},"finish_reason":"stop"}]}`)})
+	fake.Add(llmfake.Fixture{Path: llmfake.PathChatCompletions, Match: "__quote__", Status: http.StatusServiceUnavailable, Body: json.RawMessage(
+		`{"error":{"type":"server_error","message":"failed on prompt: ` + reportText + `"}}`)})
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	client := llm.NewOpenAI(llm.Options{APIKey: "test-key", BaseURL: srv.URL + "/v1", Model: "test-model", Timeout: 5 * time.Second})
+
+	for _, marker := range []string{"__echo__", "__quote__"} {
+		t.Run(marker, func(t *testing.T) {
+			output := captureLogs(t)
+			w := newTestWorker(t, 0)
+			w.Handle(domain.TaskStatusParsing, func(ctx context.Context, run *Run) (interface{}, error) {
+				_, err := client.Complete(ctx, llm.Request{Prompt: reportText + marker})
+				if err != nil {
+					return nil, fmt.Errorf("extract health facts: %w", err)
+				}
+				return nil, nil
+			})
+			taskID := w.enqueue(t)
+
+			if w.handle(context.Background(), "c1", w.read(t, "c1")) {
+				t.Fatal("handle reported an interruption")
+			}
+			if task := w.status(t, taskID); task.Status != domain.TaskStatusFailed {
+				t.Fatalf("status = %s, want failed", task.Status)
+			}
+			dead, err := w.rdb.XRange(context.Background(), DeadLetterKey, "-", "+").Result()
+			if err != nil || len(dead) != 1 {
+				t.Fatalf("dead letters = %v, %v, want one", dead, err)
+			}
+			logs := output()
+			if !strings.Contains(logs, "Task stage failed") {
+				t.Fatalf("stage failure not logged:\n%s", logs)
+			}
+			for _, s := range raw {
+				if strings.Contains(logs, s) {
+					t.Errorf("log output contains %q:\n%s", s, logs)
+				}
+				for field, v := range dead[0].Values {
+					if strings.Contains(fmt.Sprint(v), s) {
+						t.Errorf("dead letter %s contains %q: %v", field, s, v)
+					}
+				}
+			}
+		})
+	}
+}
+
+// pending 消费组中未确认的消息数
+func (w *testWorker) pending(t *testing.T) int64 {
+	t.Helper()
+	p, err := w.rdb.XPending(context.Background(), StreamKey, ConsumerGroup).Result()
+	if err != nil {
+		t.Fatalf("XPending: %v", err)
+	}
+	return p.Count
+}
+
+func TestHandleRunsPipelineAndAcks(t *testing.T) {
+	w := newTestWorker(t, 2)
+	var ran []domain.TaskStatus
+	for _, stage := range pipeline {
+		stage := stage
+		w.Handle(stage, func(ctx context.Context, run *Run) (interface{}, error) {
+			ran = append(ran, stage)
+			if stage == domain.TaskStatusParsing {
+				return map[string]int{"paragraphs": 3}, nil
+			}
+			if stage == domain.TaskStatusExtracting {
+				// 后续阶段读取前一阶段的产出
+				var parsed map[string]int
+				if err := run.Output(ctx, domain.TaskStatusParsing, &parsed); err != nil || parsed["paragraphs"] != 3 {
+					return nil, fmt.Errorf("parsing output = %v, %v", parsed, err)
+				}
+			}
+			return nil, nil
+		})
+	}
+	taskID := w.enqueue(t)
+
+	if w.handle(context.Background(), "c1", w.read(t, "c1")) {
+		t.Fatal("handle reported an interruption")
+	}
+	if task := w.status(t, taskID); task.Status != domain.TaskStatusSuccess {
+		t.Fatalf("status = %s, want success", task.Status)
+	}
+	if len(ran) != len(pipeline) {
+		t.Fatalf("stages run = %v, want %v", ran, pipeline)
+	}
+	if n := w.pending(t); n != 0 {
+		t.Errorf("pending = %d, want the message acked", n)
+	}
+	if w.mr.Exists(taskLockKey(taskID)) {
+		t.Error("task lock not released")
+	}
+}
+
+func TestHandleLeavesLockedTaskPending(t *testing.T) {
+	ctx := context.Background()
+	w := newTestWorker(t, 2)
+	ran := false
+	w.Handle(domain.TaskStatusParsing, func(ctx context.Context, run *Run) (interface{}, error) {
+		ran = true
+		return nil, nil
+	})
+	taskID := w.enqueue(t)
+	// 同一任务的另一条消息正在处理
+	if ok, err := w.queue.Lock(ctx, taskID, "other-message", time.Minute); err != nil || !ok {
+		t.Fatalf("Lock = %v, %v", ok, err)
+	}
+
+	if w.handle(ctx, "c1", w.read(t, "c1")) {
+		t.Fatal("handle reported an interruption")
+	}
+	if ran {
+		t.Fatal("stage ran while another message held the task lock")
+	}
+	if task := w.status(t, taskID); task.Status != domain.TaskStatusPending {
+		t.Fatalf("status = %s, want pending", task.Status)
+	}
+	// 消息不确认，等待可见性超时后重新认领
+	if n := w.pending(t); n != 1 {
+		t.Errorf("pending = %d, want 1", n)
+	}
+}
+
+func TestHandleDropsJobOfSettledTask(t *testing.T) {
+	ctx := context.Background()
+	w := newTestWorker(t, 2)
+	taskID := w.enqueue(t)
+	if _, err := w.tasks.MarkFailed(ctx, taskID, "", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重复或过期的消息直接确认
+	if w.handle(ctx, "c1", w.read(t, "c1")) {
+		t.Fatal("handle reported an interruption")
+	}
+	if n := w.pending(t); n != 0 {
+		t.Errorf("pending = %d, want the message acked", n)
+	}
+}
+
+func TestProcessResumesFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	w := newTestWorker(t, 2)
+	taskID := w.enqueue(t)
+	// 上次运行完成 parsing 后中断
+	if _, err := w.tasks.Transition(ctx, taskID, domain.TaskStatusParsing); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.tasks.CompleteStage(ctx, taskID, domain.TaskStatusParsing, domain.TaskStatusExtracting, []string{"para_1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	w.Handle(domain.TaskStatusParsing, func(ctx context.Context, run *Run) (interface{}, error) {
+		t.Error("completed stage ran again")
+		return nil, nil
+	})
+	var paras []string
+	w.Handle(domain.TaskStatusExtracting, func(ctx context.Context, run *Run) (interface{}, error) {
+		return nil, run.Output(ctx, domain.TaskStatusParsing, &paras)
+	})
+	if err := w.Process(ctx, taskID); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if task := w.status(t, taskID); task.Status != domain.TaskStatusSuccess {
+		t.Fatalf("status = %s, want success", task.Status)
+	}
+	if len(paras) != 1 || paras[0] != "para_1" {
+		t.Errorf("parsing output = %v, want the checkpoint", paras)
+	}
+}
+
+// startWorker 启动 worker，返回取消函数与 Start 结束信号
+func startWorker(t *testing.T, w *testWorker) (context.CancelFunc, <-chan error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Start(ctx) }()
+	t.Cleanup(cancel)
+	return cancel, done
+}
+
+// wait 等待 Start 结束
+func wait(t *testing.T, done <-chan error) {
+	t.Helper()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not stop")
+	}
+}
+
+func TestDrainFinishesInflightJob(t *testing.T) {
+	w := newTestWorker(t, 2)
+	started, release := make(chan struct{}), make(chan struct{})
+	w.Handle(domain.TaskStatusParsing, func(ctx context.Context, run *Run) (interface{}, error) {
+		close(started)
+		<-release
+		return nil, ctx.Err()
+	})
+	taskID := w.enqueue(t)
+	cancel, done := startWorker(t, w)
+
+	<-started
+	// 退出信号不中断在途任务，排空时限内完成的任务正常提交
+	cancel()
+	close(release)
+	wait(t, done)
+	if task := w.status(t, taskID); task.Status != domain.TaskStatusSuccess {
+		t.Fatalf("status = %s, want success", task.Status)
+	}
+	if n := w.pending(t); n != 0 {
+		t.Errorf("pending = %d, want the message acked", n)
+	}
+}
+
+func TestDrainTimeoutRequeuesInterruptedJob(t *testing.T) {
+	ctx := context.Background()
+	w := newTestWorker(t, 2)
+	w.drainTimeout = 50 * time.Millisecond
+	started := make(chan struct{})
+	w.Handle(domain.TaskStatusParsing, func(ctx context.Context, run *Run) (interface{}, error) {
+		return []string{"para_1"}, nil
+	})
+	w.Handle(domain.TaskStatusExtracting, func(ctx context.Context, run *Run) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	taskID := w.enqueue(t)
+	cancel, done := startWorker(t, w)
+
+	<-started
+	cancel()
+	wait(t, done)
+
+	// 中断的任务停留在 extracting，不计入重试，消息归还队列
+	task := w.status(t, taskID)
+	if task.Status != domain.TaskStatusExtracting || task.RetryCount != 0 {
+		t.Fatalf("task = %s retry %d, want extracting retry 0", task.Status, task.RetryCount)
+	}
+	if n := w.pending(t); n != 0 {
+		t.Fatalf("pending = %d, want the interrupted message acked after requeue", n)
+	}
+	if w.mr.Exists(taskLockKey(taskID)) {
+		t.Fatal("task lock not released")
+	}
+	msg := w.read(t, "c2")
+	if msg.Job.TaskID != taskID || msg.Job.RequestID != "req-1" || msg.Job.RetryCount != 0 {
+		t.Fatalf("requeued job = %+v", msg.Job)
+	}
+
+	// 重启后从 extracting 继续，不重做 parsing
+	w.Handle(domain.TaskStatusParsing, func(ctx context.Context, run *Run) (interface{}, error) {
+		t.Error("parsing ran again after restart")
+		return nil, nil
+	})
+	w.Handle(domain.TaskStatusExtracting, func(ctx context.Context, run *Run) (interface{}, error) {
+		return nil, nil
+	})
+	if w.handle(ctx, "c2", msg) {
+		t.Fatal("handle reported an interruption")
+	}
+	if task := w.status(t, taskID); task.Status != domain.TaskStatusSuccess {
+		t.Fatalf("status after restart = %s, want success", task.Status)
+	}
+}
@@ -0,0 +1,276 @@
+// Package labvalue 将抽取出的检测值归一化为标准键名与单位，
+// 并按报告给出的参考范围（缺失时使用内置参考表）判定偏高、偏低或临界。
+package labvalue
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+var (
+	numberPattern        = regexp.MustCompile(`\d+(?:\.\d+)?`)
+	bloodPressurePattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*/\s*(\d+(?:\.\d+)?)\s*([a-zA-Z]*)`)
+	plusPattern          = regexp.MustCompile(`^(\d)?\s*(\++)$|^(\d)\s*\+`)
+	unitPattern          = regexp.MustCompile(`^(?:[a-zA-Zμµ²]+(?:/[a-zA-Z0-9.²]+)*|%)$`)
+)
+
+// refMarkers 取值原文中参考范围的起始标记，如 "56 U/L（参考 9-50）"
+var refMarkers = []string{"（", "(", "[", "【", "参考"}
+
+// qualitative 定性结果的数值编码，与 configs/topics.yaml 中阈值一致
+var qualitative = map[string]map[string]float64{
+	KeyUrineProtein: {"阴性": 0, "-": 0, "neg": 0, "negative": 0, "±": 0.5, "+-": 0.5, "微量": 0.5, "trace": 0.5},
+	KeyGrade:        {"轻度": 1, "中度": 2, "重度": 3, "mild": 1, "moderate": 2, "severe": 3},
+}
+
+// Normalize 归一化 HealthFact.Values。键名按别名表归一，血压合并写法拆分为 sbp 与 dbp；
+// 无法解析为数值或单位无法换算的项跳过，原值仍保留在 Values 中。
+func Normalize(values map[string]interface{}) map[string]domain.Measurement {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make(map[string]domain.Measurement)
+	add := func(key string, m domain.Measurement) {
+		// 同一检测项出现多次（如 sbp 与 收缩压）时保留先出现的
+		if _, ok := out[key]; !ok {
+			out[key] = m
+		}
+	}
+	for _, k := range keys {
+		raw, refText := entry(values[k])
+		if raw == "" {
+			continue
+		}
+		key := CanonicalKey(k)
+		if key == keyBloodPressure {
+			sbp, dbp, ok := bloodPressure(raw)
+			if ok {
+				add(KeySystolic, sbp)
+				add(KeyDiastolic, dbp)
+			}
+			continue
+		}
+		if m, ok := measure(key, raw, refText); ok {
+			add(key, m)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// CanonicalKey 返回检测项的标准键名，不在别名表中的名称仅做小写与分隔符归一
+func CanonicalKey(name string) string {
+	k := strings.ToLower(strings.TrimSpace(name))
+	k = strings.NewReplacer("-", "_", " ", "_").Replace(k)
+	if alias, ok := aliases[k]; ok {
+		return alias
+	}
+	return k
+}
+
+// entry 取出检测值原文与参考范围原文，支持数值、字符串与 {"value", "unit", "ref"} 对象
+func entry(v interface{}) (raw, ref string) {
+	switch x := v.(type) {
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), ""
+	case string:
+		return strings.TrimSpace(x), ""
+	case map[string]interface{}:
+		raw, _ = entry(x["value"])
+		if unit, ok := x["unit"].(string); ok && raw != "" {
+			raw += " " + unit
+		}
+		ref, _ = x["ref"].(string)
+		return raw, strings.TrimSpace(ref)
+	}
+	return "", ""
+}
+
+// measure 解析单个检测值并判定参考范围
+func measure(key, raw, refText string) (domain.Measurement, bool) {
+	text := raw
+	if refText == "" {
+		text, refText = splitRef(raw)
+	}
+	v, unit, ok := parseValue(key, text)
+	if !ok {
+		return domain.Measurement{}, false
+	}
+	v, ok = convert(key, v, unit)
+	if !ok {
+		return domain.Measurement{}, false
+	}
+	m := domain.Measurement{Value: round(v), Unit: unit, Raw: raw}
+	ref, known := references[key]
+	if known && ref.Unit != "" {
+		m.Unit = ref.Unit
+	}
+	if low, high, ok := parseRange(key, refText, unit); ok {
+		m.RefLow, m.RefHigh, m.RefSource = low, high, domain.RefSourceReport
+	} else if known && (ref.RefLow != nil || ref.RefHigh != nil) {
+		m.RefLow, m.RefHigh, m.RefSource = ref.RefLow, ref.RefHigh, domain.RefSourceBuiltin
+	}
+	m.Flag = flag(key, m)
+	return m, true
+}
+
+// bloodPressure 拆分 "150/95mmHg" 形式的血压，参考范围使用内置参考表
+func bloodPressure(raw string) (sbp, dbp domain.Measurement, ok bool) {
+	match := bloodPressurePattern.FindStringSubmatch(raw)
+	if match == nil {
+		return sbp, dbp, false
+	}
+	sbp, ok1 := measure(KeySystolic, match[1]+" "+match[3], "")
+	dbp, ok2 := measure(KeyDiastolic, match[2]+" "+match[3], "")
+	sbp.Raw, dbp.Raw = raw, raw
+	return sbp, dbp, ok1 && ok2
+}
+
+// splitRef 从取值原文中分离参考范围，如 "56 U/L（参考 9-50）" 拆为 "56 U/L" 与 "9-50"
+func splitRef(raw string) (value, ref string) {
+	idx := -1
+	for _, marker := range refMarkers {
+		// 标记须出现在数值之后，避免将 "(空腹) 6.8" 之类的前缀当作参考范围
+		if i := strings.Index(raw, marker); i > 0 && (idx < 0 || i < idx) && numberPattern.MatchString(raw[:i]) {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return raw, ""
+	}
+	ref = strings.Trim(raw[idx:], "（）()[]【】 ")
+	for _, prefix := range []string{"参考范围", "参考值", "参考"} {
+		ref = strings.TrimPrefix(ref, prefix)
+	}
+	return strings.TrimSpace(raw[:idx]), strings.TrimLeft(ref, ":： ")
+}
+
+// parseValue 解析数值与单位，尿蛋白、脂肪肝分度等定性结果按 qualitative 编码
+func parseValue(key, text string) (float64, string, bool) {
+	text = strings.TrimSpace(strings.NewReplacer("↑", "", "↓", "").Replace(text))
+	if codes, ok := qualitative[key]; ok {
+		if v, ok := codes[strings.ToLower(text)]; ok {
+			return v, "", true
+		}
+		for label, v := range codes {
+			if len([]rune(label)) > 1 && strings.Contains(text, label) {
+				return v, "", true
+			}
+		}
+		if key == KeyUrineProtein {
+			if m := plusPattern.FindStringSubmatch(text); m != nil {
+				switch {
+				case m[3] != "":
+					v, _ := strconv.ParseFloat(m[3], 64)
+					return v, "", true
+				case m[1] != "":
+					v, _ := strconv.ParseFloat(m[1], 64)
+					return v, "", true
+				default:
+					return float64(len(m[2])), "", true
+				}
+			}
+		}
+	}
+	loc := numberPattern.FindStringIndex(text)
+	if loc == nil {
+		return 0, "", false
+	}
+	v, err := strconv.ParseFloat(text[loc[0]:loc[1]], 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return v, unitOf(text[loc[1]:]), true
+}
+
+// unitOf 取数值后的单位，"4a类" 中的分级后缀等不是单位的文字返回空
+func unitOf(rest string) string {
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || !unitPattern.MatchString(fields[0]) {
+		return ""
+	}
+	return fields[0]
+}
+
+// parseRange 解析参考范围原文："9-50"、"3.9~6.1 mmol/L"、"<40"、"≥90"。
+// 范围未写单位时与检测值同单位，换算失败视为没有参考范围。
+func parseRange(key, text, valueUnit string) (low, high *float64, ok bool) {
+	locs := numberPattern.FindAllStringIndex(text, -1)
+	if len(locs) == 0 || len(locs) > 2 {
+		return nil, nil, false
+	}
+	last := locs[len(locs)-1]
+	unit := unitOf(text[last[1]:])
+	if unit == "" {
+		unit = valueUnit
+	}
+	num := func(loc []int) (*float64, bool) {
+		v, err := strconv.ParseFloat(text[loc[0]:loc[1]], 64)
+		if err != nil {
+			return nil, false
+		}
+		v, ok := convert(key, v, unit)
+		if !ok {
+			return nil, false
+		}
+		v = round(v)
+		return &v, true
+	}
+
+	if len(locs) == 2 {
+		l, ok1 := num(locs[0])
+		h, ok2 := num(locs[1])
+		if !ok1 || !ok2 || *l > *h {
+			return nil, nil, false
+		}
+		return l, h, true
+	}
+	v, ok := num(locs[0])
+	if !ok {
+		return nil, nil, false
+	}
+	prefix := text[:locs[0][0]]
+	switch {
+	case strings.ContainsAny(prefix, "<≤＜") || strings.Contains(prefix, "小于") || strings.Contains(prefix, "低于"):
+		return nil, v, true
+	case strings.ContainsAny(prefix, ">≥＞") || strings.Contains(prefix, "大于") || strings.Contains(prefix, "高于"):
+		return v, nil, true
+	}
+	return nil, nil, false
+}
+
+// flag 判定检测值：达到内置异常值为 high，低于参考下限为 low，
+// 达到内置临界值为 borderline，超过参考上限为 high；没有任何可比较的界值时为空。
+func flag(key string, m domain.Measurement) domain.MeasurementFlag {
+	ref := references[key]
+	if m.RefLow == nil && m.RefHigh == nil && ref.Borderline == nil && ref.Abnormal == nil {
+		return ""
+	}
+	v := m.Value
+	switch {
+	case ref.Abnormal != nil && v >= *ref.Abnormal:
+		return domain.MeasurementHigh
+	case m.RefLow != nil && v < *m.RefLow:
+		return domain.MeasurementLow
+	case ref.Borderline != nil && v >= *ref.Borderline:
+		return domain.MeasurementBorderline
+	case m.RefHigh != nil && v > *m.RefHigh:
+		return domain.MeasurementHigh
+	}
+	return domain.MeasurementNormal
+}
+
+// round 保留两位小数，避免换算后的浮点误差影响阈值比较
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,104 @@
+package labvalue
+
+import (
+	"testing"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		field     string
+		value     interface{}
+		key       string
+		wantValue float64
+		wantFlag  domain.MeasurementFlag
+		wantRef   string
+	}{
+		{"blood pressure split", "血压", "150/95mmHg", KeySystolic, 150, domain.MeasurementHigh, domain.RefSourceBuiltin},
+		{"kPa to mmHg", "收缩压", "18.7 kPa", KeySystolic, 140.25, domain.MeasurementHigh, domain.RefSourceBuiltin},
+		{"borderline sbp", "sbp", 132.0, KeySystolic, 132, domain.MeasurementBorderline, domain.RefSourceBuiltin},
+		{"glucose mg/dL", "空腹血糖", "126 mg/dL", KeyFastingGlucose, 7, domain.MeasurementHigh, domain.RefSourceBuiltin},
+		{"glucose borderline", "GLU", "6.5", KeyFastingGlucose, 6.5, domain.MeasurementBorderline, domain.RefSourceBuiltin},
+		{"glucose low", "FPG", "3.2 mmol/L", KeyFastingGlucose, 3.2, domain.MeasurementLow, domain.RefSourceBuiltin},
+		{"prefix is not a reference range", "血糖", "(空腹) 5.1", KeyFastingGlucose, 5.1, domain.MeasurementNormal, domain.RefSourceBuiltin},
+		{"hba1c IFCC", "糖化血红蛋白", "48 mmol/mol", KeyHbA1c, 6.54, domain.MeasurementHigh, domain.RefSourceBuiltin},
+		{"uric acid mg/dL", "尿酸", "7 mg/dL", KeyUricAcid, 416.36, domain.MeasurementHigh, domain.RefSourceBuiltin},
+		{"uric acid female limit", "UA", "380 μmol/L", KeyUricAcid, 380, domain.MeasurementHigh, domain.RefSourceBuiltin},
+		{"creatinine object", "肌酐", map[string]interface{}{"value": 1.0, "unit": "mg/dL"}, KeyCreatinine, 88.4, domain.MeasurementNormal, domain.RefSourceBuiltin},
+		{"report range wins", "ALT", "45 U/L（参考 9-50）", KeyALT, 45, domain.MeasurementNormal, domain.RefSourceReport},
+		{"report range exceeded", "谷丙转氨酶", "56 IU/L (参考范围: 9~50)", KeyALT, 56, domain.MeasurementHigh, domain.RefSourceReport},
+		{"object ref upper bound", "AST", map[string]interface{}{"value": "45↑", "unit": "U/L", "ref": "<40"}, KeyAST, 45, domain.MeasurementHigh, domain.RefSourceReport},
+		{"builtin range", "ALT", "45 U/L", KeyALT, 45, domain.MeasurementHigh, domain.RefSourceBuiltin},
+		{"lower bound only", "HDL-C", "0.8", KeyHDL, 0.8, domain.MeasurementLow, domain.RefSourceBuiltin},
+		{"egfr low", "eGFR", "85", KeyEGFR, 85, domain.MeasurementLow, domain.RefSourceBuiltin},
+		{"urine protein plus", "尿蛋白", "+", KeyUrineProtein, 1, domain.MeasurementHigh, domain.RefSourceBuiltin},
+		{"urine protein 2+", "PRO", "2+", KeyUrineProtein, 2, domain.MeasurementHigh, domain.RefSourceBuiltin},
+		{"urine protein trace", "尿蛋白", "±", KeyUrineProtein, 0.5, domain.MeasurementBorderline, domain.RefSourceBuiltin},
+		{"urine protein negative", "尿蛋白", "阴性", KeyUrineProtein, 0, domain.MeasurementNormal, domain.RefSourceBuiltin},
+		{"qualitative grade", "脂肪肝分度", "中度脂肪肝", KeyGrade, 2, "", ""},
+		{"size cm to mm", "结节大小", "1.2 cm", KeySizeMM, 12, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Normalize(map[string]interface{}{tt.field: tt.value})
+			m, ok := got[tt.key]
+			if !ok {
+				t.Fatalf("Normalize = %+v, want key %s", got, tt.key)
+			}
+			if m.Value != tt.wantValue || m.Flag != tt.wantFlag || m.RefSource != tt.wantRef {
+				t.Fatalf("%s = %v %q (ref %q), want %v %q (ref %q)", tt.key, m.Value, m.Flag, m.RefSource, tt.wantValue, tt.wantFlag, tt.wantRef)
+			}
+		})
+	}
+}
+
+func TestNormalizeBloodPressure(t *testing.T) {
+	got := Normalize(map[string]interface{}{"BP": "138/92 mmHg"})
+	sbp, dbp := got[KeySystolic], got[KeyDiastolic]
+	if sbp.Value != 138 || sbp.Flag != domain.MeasurementBorderline {
+		t.Errorf("sbp = %v %q, want 138 borderline", sbp.Value, sbp.Flag)
+	}
+	if dbp.Value != 92 || dbp.Flag != domain.MeasurementHigh {
+		t.Errorf("dbp = %v %q, want 92 high", dbp.Value, dbp.Flag)
+	}
+	if sbp.Raw != "138/92 mmHg" || sbp.Unit != "mmHg" {
+		t.Errorf("sbp raw/unit = %q %q", sbp.Raw, sbp.Unit)
+	}
+}
+
+func TestNormalizeSkipsUnusable(t *testing.T) {
+	got := Normalize(map[string]interface{}{
+		"甘油三酯": "2.1 g/L",
+		"血糖":   "未见异常",
+		"备注":   "",
+		"sbp":  true,
+	})
+	if got != nil {
+		t.Fatalf("Normalize = %+v, want nil", got)
+	}
+}
+
+func TestNormalizeKeepsFirstDuplicate(t *testing.T) {
+	// 键名排序后 sbp 先于 收缩压
+	got := Normalize(map[string]interface{}{"sbp": 128.0, "收缩压": "150"})
+	if got[KeySystolic].Value != 128 {
+		t.Fatalf("sbp = %v, want the first value 128", got[KeySystolic].Value)
+	}
+}
+
+func TestCanonicalKey(t *testing.T) {
+	tests := map[string]string{
+		"收缩压":           KeySystolic,
+		" Systolic BP ": KeySystolic,
+		"LDL-C":         KeyLDL,
+		"TI-RADS":       KeyTIRADS,
+		"Custom Item":   "custom_item",
+	}
+	for name, want := range tests {
+		if got := CanonicalKey(name); got != want {
+			t.Errorf("CanonicalKey(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
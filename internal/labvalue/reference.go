@@ -0,0 +1,87 @@
+package labvalue
+
+// 标准检测项键名，与 configs/topics.yaml 中 values.* 一致
+const (
+	KeySystolic       = "sbp"
+	KeyDiastolic      = "dbp"
+	KeyFastingGlucose = "fasting_glucose"
+	KeyHbA1c          = "hba1c"
+	KeyTC             = "tc"
+	KeyTG             = "tg"
+	KeyLDL            = "ldl"
+	KeyHDL            = "hdl"
+	KeyBMI            = "bmi"
+	KeyALT            = "alt"
+	KeyAST            = "ast"
+	KeyUricAcid       = "uric_acid"
+	KeyCreatinine     = "creatinine"
+	KeyEGFR           = "egfr"
+	KeyTIRADS         = "ti_rads"
+	KeySizeMM         = "size_mm"
+	KeyGrade          = "grade"
+	KeyUrineProtein   = "urine_protein"
+
+	// keyBloodPressure 血压合并写法（如 "150/95 mmHg"），拆分为 sbp 与 dbp
+	keyBloodPressure = "blood_pressure"
+)
+
+// reference 内置参考表项。RefLow/RefHigh 为正常参考范围，报告给出参考范围时以报告为准；
+// Borderline、Abnormal 为主题判定用的边界值与异常值（>=），不随报告参考范围变化。
+type reference struct {
+	Unit       string
+	RefLow     *float64
+	RefHigh    *float64
+	Borderline *float64
+	Abnormal   *float64
+}
+
+func f(v float64) *float64 {
+	return &v
+}
+
+// references 内置参考表（成人），阈值对应 PRD §19。抽取结果不含性别，
+// 尿酸、肌酐取较严格的女性上限，与 configs/topics.yaml 的 *_female 阈值一致（超过即偏高）
+var references = map[string]reference{
+	KeySystolic:       {Unit: "mmHg", RefLow: f(90), RefHigh: f(139), Borderline: f(130), Abnormal: f(140)},
+	KeyDiastolic:      {Unit: "mmHg", RefLow: f(60), RefHigh: f(89), Abnormal: f(90)},
+	KeyFastingGlucose: {Unit: "mmol/L", RefLow: f(3.9), RefHigh: f(6.1), Borderline: f(6.1), Abnormal: f(7.0)},
+	KeyHbA1c:          {Unit: "%", RefLow: f(4.0), RefHigh: f(6.0), Borderline: f(5.7), Abnormal: f(6.5)},
+	KeyTC:             {Unit: "mmol/L", RefHigh: f(5.2), Borderline: f(5.2), Abnormal: f(6.2)},
+	KeyTG:             {Unit: "mmol/L", RefHigh: f(1.7), Borderline: f(1.7), Abnormal: f(2.3)},
+	KeyLDL:            {Unit: "mmol/L", RefHigh: f(3.4), Borderline: f(3.4), Abnormal: f(4.1)},
+	KeyHDL:            {Unit: "mmol/L", RefLow: f(1.0)},
+	KeyBMI:            {Unit: "kg/m2", RefLow: f(18.5), RefHigh: f(23.9), Borderline: f(24), Abnormal: f(28)},
+	KeyALT:            {Unit: "U/L", RefHigh: f(40)},
+	KeyAST:            {Unit: "U/L", RefHigh: f(40)},
+	KeyUricAcid:       {Unit: "μmol/L", RefLow: f(150), RefHigh: f(360)},
+	KeyCreatinine:     {Unit: "μmol/L", RefLow: f(41), RefHigh: f(97)},
+	KeyEGFR:           {Unit: "mL/min/1.73m2", RefLow: f(90)},
+	KeyUrineProtein:   {RefHigh: f(0), Borderline: f(0.5), Abnormal: f(1)},
+	KeyTIRADS:         {},
+	KeySizeMM:         {Unit: "mm"},
+	KeyGrade:          {},
+}
+
+// aliases 检测项名称归一，键为小写、空格与连字符替换为下划线后的名称
+var aliases = map[string]string{
+	"收缩压": KeySystolic, "systolic": KeySystolic, "systolic_bp": KeySystolic,
+	"舒张压": KeyDiastolic, "diastolic": KeyDiastolic, "diastolic_bp": KeyDiastolic,
+	"血压": keyBloodPressure, "bp": keyBloodPressure,
+	"空腹血糖": KeyFastingGlucose, "血糖": KeyFastingGlucose, "glucose": KeyFastingGlucose,
+	"glu": KeyFastingGlucose, "fpg": KeyFastingGlucose, "fbg": KeyFastingGlucose,
+	"糖化血红蛋白": KeyHbA1c, "糖化": KeyHbA1c,
+	"总胆固醇": KeyTC, "胆固醇": KeyTC, "cholesterol": KeyTC, "chol": KeyTC,
+	"甘油三酯": KeyTG, "triglycerides": KeyTG, "triglyceride": KeyTG,
+	"低密度脂蛋白": KeyLDL, "低密度脂蛋白胆固醇": KeyLDL, "ldl_c": KeyLDL,
+	"高密度脂蛋白": KeyHDL, "高密度脂蛋白胆固醇": KeyHDL, "hdl_c": KeyHDL,
+	"体重指数":  KeyBMI,
+	"谷丙转氨酶": KeyALT, "丙氨酸氨基转移酶": KeyALT, "gpt": KeyALT,
+	"谷草转氨酶": KeyAST, "天门冬氨酸氨基转移酶": KeyAST, "got": KeyAST,
+	"尿酸": KeyUricAcid, "血尿酸": KeyUricAcid, "ua": KeyUricAcid,
+	"肌酐": KeyCreatinine, "血肌酐": KeyCreatinine, "cr": KeyCreatinine, "scr": KeyCreatinine, "crea": KeyCreatinine,
+	"肾小球滤过率": KeyEGFR, "估算肾小球滤过率": KeyEGFR,
+	"tirads": KeyTIRADS, "ti_rads_grade": KeyTIRADS,
+	"size": KeySizeMM, "大小": KeySizeMM, "直径": KeySizeMM, "结节大小": KeySizeMM,
+	"分度": KeyGrade, "程度": KeyGrade, "脂肪肝分度": KeyGrade,
+	"尿蛋白": KeyUrineProtein, "pro": KeyUrineProtein, "protein": KeyUrineProtein,
+}
@@ -0,0 +1,56 @@
+package labvalue
+
+import "strings"
+
+// conversions 非标准单位到参考表标准单位的换算，键为 normUnit 归一后的单位
+var conversions = map[string]map[string]func(float64) float64{
+	KeySystolic:       {"kpa": kPaToMmHg},
+	KeyDiastolic:      {"kpa": kPaToMmHg},
+	KeyFastingGlucose: {"mg/dl": func(v float64) float64 { return v / 18 }},
+	KeyHbA1c:          {"mmol/mol": func(v float64) float64 { return v/10.929 + 2.15 }},
+	KeyTC:             {"mg/dl": cholesterolMgToMmol},
+	KeyLDL:            {"mg/dl": cholesterolMgToMmol},
+	KeyHDL:            {"mg/dl": cholesterolMgToMmol},
+	KeyTG:             {"mg/dl": func(v float64) float64 { return v / 88.57 }},
+	KeyUricAcid: {
+		"mg/dl":  func(v float64) float64 { return v * 59.48 },
+		"mmol/l": func(v float64) float64 { return v * 1000 },
+	},
+	KeyCreatinine: {"mg/dl": func(v float64) float64 { return v * 88.4 }},
+	KeySizeMM:     {"cm": func(v float64) float64 { return v * 10 }},
+}
+
+func kPaToMmHg(v float64) float64 {
+	return v * 7.5
+}
+
+func cholesterolMgToMmol(v float64) float64 {
+	return v / 38.67
+}
+
+// normUnit 单位归一：小写、去空白，μ/µ 统一为 u，上标 ² 统一为 2，IU/L 视同 U/L
+func normUnit(unit string) string {
+	u := strings.ToLower(strings.Join(strings.Fields(unit), ""))
+	u = strings.NewReplacer("μ", "u", "µ", "u", "²", "2", "／", "/").Replace(u)
+	if u == "iu/l" {
+		u = "u/l"
+	}
+	return u
+}
+
+// convert 将 unit 单位的 v 换算为 key 的标准单位。unit 为空时视为标准单位；
+// 无法识别的单位返回 false，此时数值不可与阈值比较。
+func convert(key string, v float64, unit string) (float64, bool) {
+	ref, ok := references[key]
+	if !ok || unit == "" {
+		return v, true
+	}
+	u := normUnit(unit)
+	if u == normUnit(ref.Unit) || ref.Unit == "" {
+		return v, true
+	}
+	if fn, ok := conversions[key][u]; ok {
+		return fn(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// anthropicVersion Messages API 版本头
+const anthropicVersion = "2023-06-01"
+
+// Anthropic Messages API 客户端，LLM_BASE_URL 为 https://api.anthropic.com/v1。
+// 该接口没有 JSON 模式，结构约束由提示词给出，输出统一经 extractJSON 校验。
+type Anthropic struct {
+	opts Options
+}
+
+// NewAnthropic 创建 Anthropic 客户端
+func NewAnthropic(opts Options) *Anthropic {
+	opts.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+	return &Anthropic{opts: opts}
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float64            `json:"temperature"`
+}
+
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+type anthropicError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *Anthropic) Complete(ctx context.Context, req Request) (*Response, error) {
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxTokens
+	}
+
+	header := http.Header{}
+	header.Set("x-api-key", c.opts.APIKey)
+	header.Set("anthropic-version", anthropicVersion)
+	res, err := postJSON(ctx, ProviderAnthropic, c.opts, c.opts.BaseURL+"/messages", header, anthropicRequest{
+		Model:     c.opts.Model,
+		System:    req.System,
+		Messages:  []anthropicMessage{{Role: "user", Content: req.Prompt}},
+		MaxTokens: maxTokens,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.status != http.StatusOK {
+		var apiErr anthropicError
+		// 只保留错误类型，供应商的错误描述可能引用请求内容
+		message := "error response (" + describe(res.body) + ")"
+		if json.Unmarshal(res.body, &apiErr) == nil && apiErr.Error.Type != "" {
+			message = apiErr.Error.Type + " " + message
+		}
+		return nil, statusError(ProviderAnthropic, res, message)
+	}
+
+	var out anthropicResponse
+	if err := json.Unmarshal(res.body, &out); err != nil {
+		return nil, &Error{Kind: KindUnavailable, Provider: ProviderAnthropic, StatusCode: res.status, Err: errors.New("malformed messages response")}
+	}
+	if out.StopReason == "max_tokens" {
+		return nil, &Error{Kind: KindInvalidOutput, Provider: ProviderAnthropic, Err: errors.New("output truncated at max_tokens")}
+	}
+	var text strings.Builder
+	for _, block := range out.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	content, err := extractJSON(ProviderAnthropic, text.String())
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		Content:      content,
+		Model:        out.Model,
+		InputTokens:  out.Usage.InputTokens,
+		OutputTokens: out.Usage.OutputTokens,
+	}, nil
+}
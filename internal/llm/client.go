@@ -0,0 +1,168 @@
+// Package llm 提供与供应商无关的结构化 JSON 补全客户端。
+package llm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/config"
+)
+
+const (
+	// ProviderOpenAI OpenAI 及兼容 chat-completions 协议的网关
+	ProviderOpenAI = "openai"
+	// ProviderAnthropic Anthropic Messages API
+	ProviderAnthropic = "anthropic"
+
+	// defaultMaxTokens 未指定 MaxTokens 时的输出上限
+	defaultMaxTokens = 4096
+	// maxResponseBytes 响应体上限
+	maxResponseBytes = 16 << 20
+)
+
+// Client 结构化 JSON 补全客户端
+type Client interface {
+	// Complete 发起一次补全，模型输出必须是 JSON 对象或数组，失败时返回 *Error
+	Complete(ctx context.Context, req Request) (*Response, error)
+}
+
+// Request 补全请求
+type Request struct {
+	System string
+	Prompt string
+	// MaxTokens 输出上限，0 使用默认值
+	MaxTokens int
+}
+
+// Response 补全结果
+type Response struct {
+	// Content 模型输出的 JSON，已去除代码块包裹并校验为合法 JSON
+	Content      json.RawMessage
+	Model        string
+	InputTokens  int
+	OutputTokens int
+}
+
+// Options 客户端参数
+type Options struct {
+	APIKey  string
+	BaseURL string
+	Model   string
+	// Timeout 单次请求超时，对应 LLM_TIMEOUT
+	Timeout time.Duration
+	// HTTPClient 默认 http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// New 按配置创建客户端
+func New(cfg config.LLMConfig) (Client, error) {
+	opts := Options{
+		APIKey:  cfg.APIKey,
+		BaseURL: cfg.BaseURL,
+		Model:   cfg.Model,
+		Timeout: time.Duration(cfg.Timeout) * time.Second,
+	}
+	switch cfg.Provider {
+	case ProviderOpenAI:
+		return NewOpenAI(opts), nil
+	case ProviderAnthropic:
+		return NewAnthropic(opts), nil
+	default:
+		return nil, fmt.Errorf("unsupported llm provider: %s", cfg.Provider)
+	}
+}
+
+func (o Options) httpClient() *http.Client {
+	if o.HTTPClient != nil {
+		return o.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// httpResult 原始 HTTP 响应
+type httpResult struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// postJSON 在 opts.Timeout 内发送 JSON 请求，传输层错误按超时或不可用分类
+func postJSON(ctx context.Context, provider string, opts Options, url string, header http.Header, payload interface{}) (*httpResult, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, &Error{Kind: KindRequest, Provider: provider, Err: err}
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := opts.httpClient().Do(req)
+	if err != nil {
+		return nil, transportError(ctx, provider, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, transportError(ctx, provider, err)
+	}
+	return &httpResult{status: resp.StatusCode, header: resp.Header, body: data}, nil
+}
+
+func transportError(ctx context.Context, provider string, err error) error {
+	var netErr net.Error
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &Error{Kind: KindTimeout, Provider: provider, Err: err}
+	}
+	if ctx.Err() != nil {
+		// 调用方取消，不做分类
+		return ctx.Err()
+	}
+	return &Error{Kind: KindUnavailable, Provider: provider, Err: err}
+}
+
+// extractJSON 去除模型常见的代码块包裹，要求结果为 JSON 对象或数组
+func extractJSON(provider, text string) (json.RawMessage, error) {
+	s := strings.TrimSpace(text)
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s, "```")
+		if nl := strings.IndexByte(s, '\n'); nl >= 0 {
+			// 去掉 ```json 语言标记
+			s = s[nl+1:]
+		}
+		s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+		s = strings.TrimSpace(s)
+	}
+	if s == "" || (s[0] != '{' && s[0] != '[') || !json.Valid([]byte(s)) {
+		return nil, &Error{Kind: KindInvalidOutput, Provider: provider, Err: fmt.Errorf("model output is not a json object (%s)", describe([]byte(text)))}
+	}
+	return json.RawMessage(s), nil
+}
+
+// describe 以长度与摘要描述响应体或模型输出。原文可能回显报告内容，
+// 错误信息随日志与死信消息持久化，只记录长度与摘要
+func describe(body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf("%d bytes, sha256 %s", len(body), hex.EncodeToString(sum[:8]))
+}
@@ -0,0 +1,96 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/llm"
+	"github.com/zhenglizhi/policy-fit/internal/llm/llmfake"
+)
+
+// newFakeClients 返回连接到 llmfake 的两种客户端
+func newFakeClients(t *testing.T, fake *llmfake.Server, apiKey string) map[string]llm.Client {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	opts := llm.Options{APIKey: apiKey, BaseURL: srv.URL + "/v1", Model: "test-model", Timeout: 5 * time.Second}
+	return map[string]llm.Client{
+		llm.ProviderOpenAI:    llm.NewOpenAI(opts),
+		llm.ProviderAnthropic: llm.NewAnthropic(opts),
+	}
+}
+
+func newFake(t *testing.T) *llmfake.Server {
+	t.Helper()
+	fake, err := llmfake.New()
+	if err != nil {
+		t.Fatalf("llmfake.New: %v", err)
+	}
+	return fake
+}
+
+func TestErrorsOmitResponseBodies(t *testing.T) {
+	const reportText = "受检者张三 收缩压 152 mmHg"
+	fake := newFake(t)
+	// 模型输出回显报告原文且不是 JSON
+	fake.Add(llmfake.Fixture{Path: llmfake.PathChatCompletions, Match: "__echo__", Body: json.RawMessage(
+		`{"model":"m","choices":[{"message":{"role":"assistant","content":"` + reportText + `，建议复查"},"finish_reason":"stop"}]}`)})
+	fake.Add(llmfake.Fixture{Path: llmfake.PathMessages, Match: "__echo__", Body: json.RawMessage(
+		`{"model":"m","content":[{"type":"text","text":"` + reportText + `，建议复查"}],"stop_reason":"end_turn"}`)})
+	// 供应商错误描述引用请求内容
+	errorBody := json.RawMessage(`{"error":{"type":"invalid_request_error","message":"prompt contains ` + reportText + `"}}`)
+	fake.Add(llmfake.Fixture{Path: llmfake.PathChatCompletions, Match: "__quote__", Status: http.StatusBadRequest, Body: errorBody})
+	fake.Add(llmfake.Fixture{Path: llmfake.PathMessages, Match: "__quote__", Status: http.StatusBadRequest, Body: errorBody})
+
+	for provider, client := range newFakeClients(t, fake, "test-key") {
+		for marker, kind := range map[string]llm.Kind{"__echo__": llm.KindInvalidOutput, "__quote__": llm.KindRequest} {
+			t.Run(provider+"/"+marker, func(t *testing.T) {
+				_, err := client.Complete(context.Background(), llm.Request{Prompt: reportText + marker})
+				var llmErr *llm.Error
+				if !errors.As(err, &llmErr) || llmErr.Kind != kind {
+					t.Fatalf("err = %v, want %s", err, kind)
+				}
+				msg := err.Error()
+				if strings.Contains(msg, "收缩压") || strings.Contains(msg, "张三") {
+					t.Fatalf("error echoes the response body: %s", msg)
+				}
+				if !strings.Contains(msg, "sha256 ") {
+					t.Errorf("error = %s, want body length and digest", msg)
+				}
+				if kind == llm.KindRequest && !strings.Contains(msg, "invalid_request_error") {
+					t.Errorf("error = %s, want the provider error type", msg)
+				}
+			})
+		}
+	}
+}
+
+func TestFixtureSuccess(t *testing.T) {
+	tests := []struct {
+		provider string
+		marker   string
+		want     string
+	}{
+		{llm.ProviderOpenAI, "", `{"ok": true}`},
+		{llm.ProviderAnthropic, "", `{"ok": true}`},
+		{llm.ProviderAnthropic, "__fenced__", `{"ok": true, "fenced": true}`},
+	}
+	clients := newFakeClients(t, newFake(t), "test-key")
+	for _, tt := range tests {
+		t.Run(tt.provider+"/"+tt.marker, func(t *testing.T) {
+			resp, err := clients[tt.provider].Complete(context.Background(), llm.Request{Prompt: "extract " + tt.marker})
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+			if string(resp.Content) != tt.want {
+				t.Fatalf("content = %s, want %s", resp.Content, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+// Kind 调用失败分类
+type Kind string
+
+const (
+	// KindRateLimited 触发限流（429）
+	KindRateLimited Kind = "rate_limited"
+	// KindTimeout 请求超过 LLM_TIMEOUT
+	KindTimeout Kind = "timeout"
+	// KindAuth 密钥无效或无权限（401/403）
+	KindAuth Kind = "auth"
+	// KindInvalidOutput 模型输出不是合法 JSON 或被截断
+	KindInvalidOutput Kind = "invalid_output"
+	// KindUnavailable 服务端错误、过载或网络错误
+	KindUnavailable Kind = "unavailable"
+	// KindRequest 请求被拒绝（其他 4xx），如模型名错误、上下文超长
+	KindRequest Kind = "request"
+)
+
+// Error LLM 调用失败，实现 jobs 包的 FailureCoder、Retryable 与 RetryDelayer
+type Error struct {
+	Kind       Kind
+	Provider   string
+	StatusCode int
+	// RetryAfterDelay 供应商通过 Retry-After 建议的等待时长
+	RetryAfterDelay time.Duration
+	Err             error
+}
+
+func (e *Error) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("llm %s %s (status %d): %v", e.Provider, e.Kind, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("llm %s %s: %v", e.Provider, e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// FailureCode 写入任务的失败码
+func (e *Error) FailureCode() string {
+	switch e.Kind {
+	case KindTimeout:
+		return response.CodeLLMTimeout
+	case KindInvalidOutput:
+		return response.CodeLLMInvalidJSON
+	case KindRateLimited, KindUnavailable:
+		return response.CodeLLMUnavailable
+	default:
+		return response.CodeInternal
+	}
+}
+
+// Is 与失败码相同的业务错误等同，如限流与服务不可用的错误满足
+// errors.Is(err, service.ErrLLMUnavailable)
+func (e *Error) Is(target error) bool {
+	coded, ok := target.(*response.Error)
+	return ok && coded.Code != response.CodeInternal && coded.Code == e.FailureCode()
+}
+
+// Retryable 限流、超时、服务不可用与非法输出可重试；鉴权与请求错误重试不会成功
+func (e *Error) Retryable() bool {
+	switch e.Kind {
+	case KindRateLimited, KindTimeout, KindUnavailable, KindInvalidOutput:
+		return true
+	default:
+		return false
+	}
+}
+
+// RetryAfter 最短重试等待
+func (e *Error) RetryAfter() time.Duration {
+	return e.RetryAfterDelay
+}
+
+// IsUnavailable 错误是否表示 LLM 暂时无法提供服务（限流、超时、服务端错误或网络错误）
+func IsUnavailable(err error) bool {
+	var e *Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch e.Kind {
+	case KindRateLimited, KindTimeout, KindUnavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// statusError 按 HTTP 状态码分类供应商错误
+func statusError(provider string, res *httpResult, message string) *Error {
+	e := &Error{Provider: provider, StatusCode: res.status, Err: errors.New(message)}
+	switch {
+	case res.status == http.StatusUnauthorized || res.status == http.StatusForbidden:
+		e.Kind = KindAuth
+	case res.status == http.StatusTooManyRequests:
+		e.Kind = KindRateLimited
+		e.RetryAfterDelay = parseRetryAfter(res.header.Get("Retry-After"))
+	case res.status == http.StatusRequestTimeout || res.status == http.StatusGatewayTimeout:
+		e.Kind = KindTimeout
+	case res.status >= http.StatusInternalServerError:
+		e.Kind = KindUnavailable
+	default:
+		e.Kind = KindRequest
+	}
+	return e
+}
+
+// parseRetryAfter 解析秒数或 HTTP 日期格式的 Retry-After
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
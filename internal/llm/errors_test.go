@@ -0,0 +1,128 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/llm"
+	"github.com/zhenglizhi/policy-fit/internal/llm/llmfake"
+	"github.com/zhenglizhi/policy-fit/internal/service"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+func TestFixtureClassification(t *testing.T) {
+	tests := []struct {
+		provider   string
+		marker     string
+		wantKind   llm.Kind
+		wantStatus int
+		wantCode   string
+		retryAfter time.Duration
+	}{
+		{llm.ProviderOpenAI, "__rate_limited__", llm.KindRateLimited, http.StatusTooManyRequests, response.CodeLLMUnavailable, 7 * time.Second},
+		{llm.ProviderOpenAI, "__server_error__", llm.KindUnavailable, http.StatusServiceUnavailable, response.CodeLLMUnavailable, 0},
+		{llm.ProviderOpenAI, "__invalid_json__", llm.KindInvalidOutput, 0, response.CodeLLMInvalidJSON, 0},
+		{llm.ProviderOpenAI, "__truncated__", llm.KindInvalidOutput, 0, response.CodeLLMInvalidJSON, 0},
+		{llm.ProviderAnthropic, "__rate_limited__", llm.KindRateLimited, http.StatusTooManyRequests, response.CodeLLMUnavailable, 12 * time.Second},
+		{llm.ProviderAnthropic, "__overloaded__", llm.KindUnavailable, 529, response.CodeLLMUnavailable, 0},
+		{llm.ProviderAnthropic, "__server_error__", llm.KindUnavailable, http.StatusInternalServerError, response.CodeLLMUnavailable, 0},
+		{llm.ProviderAnthropic, "__invalid_json__", llm.KindInvalidOutput, 0, response.CodeLLMInvalidJSON, 0},
+		{llm.ProviderAnthropic, "__truncated__", llm.KindInvalidOutput, 0, response.CodeLLMInvalidJSON, 0},
+	}
+	clients := newFakeClients(t, newFake(t), "test-key")
+	for _, tt := range tests {
+		t.Run(tt.provider+"/"+tt.marker, func(t *testing.T) {
+			_, err := clients[tt.provider].Complete(context.Background(), llm.Request{Prompt: "extract " + tt.marker})
+			var llmErr *llm.Error
+			if !errors.As(err, &llmErr) {
+				t.Fatalf("Complete: err = %v, want *llm.Error", err)
+			}
+			if llmErr.Kind != tt.wantKind || llmErr.Provider != tt.provider || llmErr.StatusCode != tt.wantStatus {
+				t.Fatalf("error = %s/%s/%d, want %s/%s/%d", llmErr.Provider, llmErr.Kind, llmErr.StatusCode, tt.provider, tt.wantKind, tt.wantStatus)
+			}
+			if got := llmErr.FailureCode(); got != tt.wantCode {
+				t.Errorf("FailureCode = %s, want %s", got, tt.wantCode)
+			}
+			if !llmErr.Retryable() {
+				t.Error("Retryable = false, want true")
+			}
+			if got := llmErr.RetryAfter(); got != tt.retryAfter {
+				t.Errorf("RetryAfter = %s, want %s", got, tt.retryAfter)
+			}
+			// 包装后仍与同失败码的业务错误等同
+			wrapped := fmt.Errorf("extract health facts: %w", err)
+			if got := errors.Is(wrapped, service.ErrLLMUnavailable); got != (tt.wantCode == response.CodeLLMUnavailable) {
+				t.Errorf("errors.Is(ErrLLMUnavailable) = %v", got)
+			}
+			if got := llm.IsUnavailable(wrapped); got != (tt.wantKind != llm.KindInvalidOutput) {
+				t.Errorf("IsUnavailable = %v", got)
+			}
+		})
+	}
+}
+
+func TestAuthErrorNotRetryable(t *testing.T) {
+	for provider, client := range newFakeClients(t, newFake(t), llmfake.InvalidKey) {
+		t.Run(provider, func(t *testing.T) {
+			_, err := client.Complete(context.Background(), llm.Request{Prompt: "extract"})
+			var llmErr *llm.Error
+			if !errors.As(err, &llmErr) || llmErr.Kind != llm.KindAuth || llmErr.StatusCode != http.StatusUnauthorized {
+				t.Fatalf("err = %v, want 401 auth error", err)
+			}
+			if llmErr.Retryable() {
+				t.Error("auth error classified as retryable")
+			}
+			if got := llmErr.FailureCode(); got != response.CodeInternal {
+				t.Errorf("FailureCode = %s, want %s", got, response.CodeInternal)
+			}
+			if errors.Is(err, service.ErrLLMUnavailable) || llm.IsUnavailable(err) {
+				t.Error("auth error classified as unavailable")
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+	opts := llm.Options{APIKey: "test-key", BaseURL: srv.URL + "/v1", Timeout: 50 * time.Millisecond}
+	for provider, client := range map[string]llm.Client{
+		llm.ProviderOpenAI:    llm.NewOpenAI(opts),
+		llm.ProviderAnthropic: llm.NewAnthropic(opts),
+	} {
+		t.Run(provider, func(t *testing.T) {
+			_, err := client.Complete(context.Background(), llm.Request{Prompt: "extract"})
+			var llmErr *llm.Error
+			if !errors.As(err, &llmErr) || llmErr.Kind != llm.KindTimeout {
+				t.Fatalf("err = %v, want timeout", err)
+			}
+			if !llmErr.Retryable() || llmErr.FailureCode() != response.CodeLLMTimeout {
+				t.Errorf("Retryable = %v, FailureCode = %s, want retryable %s", llmErr.Retryable(), llmErr.FailureCode(), response.CodeLLMTimeout)
+			}
+		})
+	}
+}
+
+func TestCallerCancellationNotClassified(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	_, err := llm.NewOpenAI(llm.Options{APIKey: "test-key", BaseURL: srv.URL, Timeout: time.Second}).Complete(ctx, llm.Request{Prompt: "extract"})
+	var llmErr *llm.Error
+	if !errors.Is(err, context.Canceled) || errors.As(err, &llmErr) {
+		t.Fatalf("err = %v, want context.Canceled without classification", err)
+	}
+}
@@ -0,0 +1,193 @@
+// Package llmfake 提供基于录制样例（fixture）的 LLM 桩服务，同时支持 OpenAI chat-completions
+// （POST .../chat/completions）与 Anthropic Messages（POST .../messages）协议，用于离线测试。
+//
+// 每个样例按协议路径与请求体子串匹配，返回录制的状态码、响应头与响应体。
+// 内置样例通过提示词中的标记触发，如 "__rate_limited__"、"__invalid_json__"。
+// 内置样例见 fixtures 目录，可通过 Recorder 从真实供应商录制新的样例。
+package llmfake
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed fixtures/*.json
+var builtin embed.FS
+
+const (
+	// PathChatCompletions OpenAI 兼容协议路径后缀
+	PathChatCompletions = "/chat/completions"
+	// PathMessages Anthropic 协议路径后缀
+	PathMessages = "/messages"
+
+	// InvalidKey 使用该密钥的请求返回 401，便于验证鉴权错误分类
+	InvalidKey = "invalid-key"
+)
+
+// Fixture 一条录制样例
+type Fixture struct {
+	Name string `json:"name"`
+	// Path 协议路径后缀：PathChatCompletions 或 PathMessages
+	Path string `json:"path"`
+	// Match 请求体需包含的子串，为空时匹配该路径的任意请求
+	Match   string            `json:"match,omitempty"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body"`
+	// Once 命中一次后移除，用于构造先失败后成功的序列
+	Once bool `json:"once,omitempty"`
+}
+
+// Server LLM 桩服务，实现 http.Handler，可配合 httptest.NewServer 使用
+type Server struct {
+	mu       sync.Mutex
+	fixtures []Fixture
+	requests []json.RawMessage
+}
+
+// New 创建加载了内置样例的桩服务
+func New() (*Server, error) {
+	s := &Server{}
+	entries, err := builtin.ReadDir("fixtures")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		data, err := builtin.ReadFile("fixtures/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if err := s.addJSON(entry.Name(), data); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// LoadDir 加载目录下的全部 *.json 样例，优先于已有样例匹配
+func (s *Server) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := s.addJSON(path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add 添加样例，优先于已有样例匹配
+func (s *Server) Add(f Fixture) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.Status == 0 {
+		f.Status = http.StatusOK
+	}
+	s.fixtures = append([]Fixture{f}, s.fixtures...)
+}
+
+// Requests 返回收到的全部请求体
+func (s *Server) Requests() []json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]json.RawMessage(nil), s.requests...)
+}
+
+func (s *Server) addJSON(name string, data []byte) error {
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("invalid fixture %s: %w", name, err)
+	}
+	if f.Path != PathChatCompletions && f.Path != PathMessages {
+		return fmt.Errorf("invalid fixture %s: unknown path %q", name, f.Path)
+	}
+	s.Add(f)
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var path string
+	switch {
+	case strings.HasSuffix(r.URL.Path, PathChatCompletions):
+		path = PathChatCompletions
+	case strings.HasSuffix(r.URL.Path, PathMessages):
+		path = PathMessages
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil || r.Method != http.MethodPost {
+		writeError(w, path, http.StatusBadRequest, "invalid_request_error", "invalid request")
+		return
+	}
+
+	key := r.Header.Get("x-api-key")
+	if path == PathChatCompletions {
+		key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if key == "" || key == InvalidKey {
+		writeError(w, path, http.StatusUnauthorized, "authentication_error", "invalid api key")
+		return
+	}
+
+	f, ok := s.match(path, body)
+	if !ok {
+		writeError(w, path, http.StatusInternalServerError, "api_error", "no fixture matched the request")
+		return
+	}
+	for k, v := range f.Headers {
+		w.Header().Set(k, v)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.Status)
+	_, _ = w.Write(f.Body)
+}
+
+// match 记录请求并返回匹配的样例：设置了 Match 的样例优先，其次为同路径的通用样例，
+// 同类样例中后添加的优先
+func (s *Server) match(path string, body []byte) (Fixture, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, append(json.RawMessage(nil), body...))
+	for _, specific := range []bool{true, false} {
+		for i, f := range s.fixtures {
+			if f.Path != path || (f.Match != "") != specific || !strings.Contains(string(body), f.Match) {
+				continue
+			}
+			if f.Once {
+				s.fixtures = append(s.fixtures[:i:i], s.fixtures[i+1:]...)
+			}
+			return f, true
+		}
+	}
+	return Fixture{}, false
+}
+
+// writeError 按协议返回错误响应
+func writeError(w http.ResponseWriter, path string, status int, errType, message string) {
+	body := map[string]interface{}{
+		"error": map[string]string{"type": errType, "message": message},
+	}
+	if path == PathMessages {
+		body["type"] = "error"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,77 @@
+package llmfake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder 包装真实传输层，将每次请求的响应保存为样例文件，用于录制新的离线样例。
+// 录制结果的 Match 为空，落盘后需按请求内容手工补充。
+//
+//	client := &http.Client{Transport: &llmfake.Recorder{Dir: "testdata/llm"}}
+type Recorder struct {
+	Dir string
+	// Transport 默认 http.DefaultTransport
+	Transport http.RoundTripper
+
+	mu  sync.Mutex
+	seq int
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := r.save(req, resp, body); err != nil {
+		return nil, fmt.Errorf("failed to record fixture: %w", err)
+	}
+	return resp, nil
+}
+
+func (r *Recorder) save(req *http.Request, resp *http.Response, body []byte) error {
+	path := PathChatCompletions
+	if strings.HasSuffix(req.URL.Path, PathMessages) {
+		path = PathMessages
+	}
+	if !json.Valid(body) {
+		body, _ = json.Marshal(string(body))
+	}
+	f := Fixture{Path: path, Status: resp.StatusCode, Body: body}
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		f.Headers = map[string]string{"Retry-After": v}
+	}
+
+	r.mu.Lock()
+	r.seq++
+	f.Name = fmt.Sprintf("recorded_%s_%03d", time.Now().UTC().Format("20060102T150405"), r.seq)
+	r.mu.Unlock()
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.Dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(r.Dir, f.Name+".json"), data, 0o644)
+}
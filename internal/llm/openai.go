@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// OpenAI chat-completions 协议客户端，LLM_BASE_URL 指向兼容网关时同样适用（如 https://api.openai.com/v1）。
+// 使用 JSON 模式（response_format=json_object），temperature 固定为 0。
+type OpenAI struct {
+	opts Options
+}
+
+// NewOpenAI 创建 OpenAI 兼容客户端
+func NewOpenAI(opts Options) *OpenAI {
+	opts.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+	return &OpenAI{opts: opts}
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIRequest struct {
+	Model          string            `json:"model"`
+	Messages       []openAIMessage   `json:"messages"`
+	Temperature    float64           `json:"temperature"`
+	MaxTokens      int               `json:"max_tokens"`
+	ResponseFormat map[string]string `json:"response_format"`
+}
+
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+type openAIError struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+func (c *OpenAI) Complete(ctx context.Context, req Request) (*Response, error) {
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxTokens
+	}
+	var messages []openAIMessage
+	if req.System != "" {
+		messages = append(messages, openAIMessage{Role: "system", Content: req.System})
+	}
+	messages = append(messages, openAIMessage{Role: "user", Content: req.Prompt})
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+c.opts.APIKey)
+	res, err := postJSON(ctx, ProviderOpenAI, c.opts, c.opts.BaseURL+"/chat/completions", header, openAIRequest{
+		Model:          c.opts.Model,
+		Messages:       messages,
+		MaxTokens:      maxTokens,
+		ResponseFormat: map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.status != http.StatusOK {
+		var apiErr openAIError
+		// 只保留错误类型，供应商的错误描述可能引用请求内容
+		message := "error response (" + describe(res.body) + ")"
+		if json.Unmarshal(res.body, &apiErr) == nil && apiErr.Error.Type != "" {
+			message = apiErr.Error.Type + " " + message
+		}
+		return nil, statusError(ProviderOpenAI, res, message)
+	}
+
+	var out openAIResponse
+	if err := json.Unmarshal(res.body, &out); err != nil || len(out.Choices) == 0 {
+		return nil, &Error{Kind: KindUnavailable, Provider: ProviderOpenAI, StatusCode: res.status, Err: errors.New("malformed chat completion response")}
+	}
+	choice := out.Choices[0]
+	if choice.FinishReason == "length" {
+		return nil, &Error{Kind: KindInvalidOutput, Provider: ProviderOpenAI, Err: errors.New("output truncated at max_tokens")}
+	}
+	content, err := extractJSON(ProviderOpenAI, choice.Message.Content)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		Content:      content,
+		Model:        out.Model,
+		InputTokens:  out.Usage.PromptTokens,
+		OutputTokens: out.Usage.CompletionTokens,
+	}, nil
+}
@@ -0,0 +1,70 @@
+// Package prompts 管理版本化的 LLM 提示词模板。
+//
+// 模板文件名为 {name}.v{N}.tmpl，修改提示词时新增版本文件而不是原地修改，
+// 抽取结果记录所用版本，便于回溯与对比评估。
+package prompts
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+//go:embed *.tmpl
+var files embed.FS
+
+// HealthFacts 体检报告健康事实抽取（PRD §20.1）
+const HealthFacts = "health_facts.v2"
+
+// PolicyFacts 保险条款事实抽取（PRD §20.2）
+const PolicyFacts = "policy_facts.v1"
+
+// Template 提示词模板，首个 "---" 行之前为 system 提示词，之后为 user 提示词
+type Template struct {
+	// Version 模板版本，如 "health_facts.v1"
+	Version string
+	system  *template.Template
+	user    *template.Template
+}
+
+// Load 加载指定版本的模板
+func Load(version string) (*Template, error) {
+	data, err := files.ReadFile(version + ".tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("prompt template %s not found", version)
+	}
+	system, user, ok := strings.Cut(string(data), "\n---\n")
+	if !ok {
+		return nil, fmt.Errorf("prompt template %s has no system/user separator", version)
+	}
+	t := &Template{Version: version}
+	if t.system, err = template.New(version + ".system").Option("missingkey=error").Parse(strings.TrimSpace(system)); err != nil {
+		return nil, fmt.Errorf("failed to parse prompt template %s: %w", version, err)
+	}
+	if t.user, err = template.New(version + ".user").Option("missingkey=error").Parse(strings.TrimSpace(user)); err != nil {
+		return nil, fmt.Errorf("failed to parse prompt template %s: %w", version, err)
+	}
+	return t, nil
+}
+
+// MustLoad 加载内置模板，模板缺失属于编程错误
+func MustLoad(version string) *Template {
+	t, err := Load(version)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render 渲染 system 与 user 提示词
+func (t *Template) Render(data interface{}) (system, user string, err error) {
+	var sb, ub strings.Builder
+	if err := t.system.Execute(&sb, data); err != nil {
+		return "", "", fmt.Errorf("failed to render prompt %s: %w", t.Version, err)
+	}
+	if err := t.user.Execute(&ub, data); err != nil {
+		return "", "", fmt.Errorf("failed to render prompt %s: %w", t.Version, err)
+	}
+	return sb.String(), ub.String(), nil
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhenglizhi/policy-fit/internal/auth"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+// contextUserID gin 上下文中当前用户 ID 的键
+const contextUserID = "auth.user_id"
+
+// Auth 鉴权中间件：校验 Authorization: Bearer 中的访问 token，将用户 ID 写入上下文
+func Auth(jwt *auth.JWT) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			abortUnauthorized(c, "missing bearer token")
+			return
+		}
+		claims, err := jwt.Verify(token, time.Now())
+		if errors.Is(err, auth.ErrTokenExpired) {
+			abortUnauthorized(c, "token expired")
+			return
+		}
+		if err != nil || claims.Use != auth.UseAccess {
+			abortUnauthorized(c, "invalid token")
+			return
+		}
+		c.Set(contextUserID, claims.UserID)
+		c.Next()
+	}
+}
+
+// UserID 获取鉴权中间件写入的当前用户 ID
+func UserID(c *gin.Context) (int64, bool) {
+	userID, ok := c.Get(contextUserID)
+	if !ok {
+		return 0, false
+	}
+	id, ok := userID.(int64)
+	return id, ok && id > 0
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="policy-fit"`)
+	response.Fail(c, response.NewError(response.CodeUnauthorized, message))
+}
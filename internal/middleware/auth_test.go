@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhenglizhi/policy-fit/internal/auth"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logger.Init("error", "json")
+	os.Exit(m.Run())
+}
+
+// serve 发送请求并解析统一响应结构
+func serve(t *testing.T, router *gin.Engine, req *http.Request) (*httptest.ResponseRecorder, response.Response) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp response.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+	return w, resp
+}
+
+func newTestJWT(t *testing.T, current string) *auth.JWT {
+	t.Helper()
+	jwt, err := auth.NewJWT(current, map[string][]byte{
+		"k1": []byte("first-signing-key-0123456789abcdef"),
+		"k2": []byte("second-signing-key-0123456789abcde"),
+	}, 30*time.Second)
+	if err != nil {
+		t.Fatalf("NewJWT: %v", err)
+	}
+	return jwt
+}
+
+func sign(t *testing.T, jwt *auth.JWT, use string, expiresAt time.Time) string {
+	t.Helper()
+	token, err := jwt.Sign(auth.Claims{UserID: 42, Use: use, IssuedAt: expiresAt.Add(-time.Hour), ExpiresAt: expiresAt})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return token
+}
+
+func TestAuth(t *testing.T) {
+	jwt := newTestJWT(t, "k1")
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/me", Auth(jwt), func(c *gin.Context) {
+		userID, ok := UserID(c)
+		if !ok {
+			t.Error("UserID not set after Auth")
+		}
+		response.Success(c, strconv.FormatInt(userID, 10))
+	})
+
+	valid := sign(t, jwt, auth.UseAccess, time.Now().Add(time.Hour))
+	// 轮换后旧密钥签发的 token 在保留期内仍可校验
+	rotated := sign(t, newTestJWT(t, "k2"), auth.UseAccess, time.Now().Add(time.Hour))
+	other, err := auth.NewJWT("k1", map[string][]byte{"k1": []byte("another-signing-key-0123456789abc")}, 0)
+	if err != nil {
+		t.Fatalf("NewJWT: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		detail string
+	}{
+		{"valid", "Bearer " + valid, ""},
+		{"lowercase scheme", "bearer  " + valid, ""},
+		{"rotated key", "Bearer " + rotated, ""},
+		{"missing header", "", "missing bearer token"},
+		{"basic scheme", "Basic " + valid, "missing bearer token"},
+		{"empty token", "Bearer ", "missing bearer token"},
+		{"expired", "Bearer " + sign(t, jwt, auth.UseAccess, time.Now().Add(-time.Minute)), "token expired"},
+		{"refresh token", "Bearer " + sign(t, jwt, auth.UseRefresh, time.Now().Add(time.Hour)), "invalid token"},
+		{"unknown signing key", "Bearer " + sign(t, other, auth.UseAccess, time.Now().Add(time.Hour)), "invalid token"},
+		{"malformed", "Bearer not.a.token", "invalid token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w, resp := serve(t, router, req)
+
+			if tt.detail == "" {
+				if w.Code != http.StatusOK || resp.Data != "42" {
+					t.Fatalf("status = %d, body = %s, want user 42", w.Code, w.Body.String())
+				}
+				return
+			}
+			if w.Code != http.StatusUnauthorized || resp.Code != response.CodeUnauthorized || resp.Detail != tt.detail {
+				t.Fatalf("status = %d, body = %s, want 401 %q", w.Code, w.Body.String(), tt.detail)
+			}
+			if w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("missing WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestUserIDWithoutAuth(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if _, ok := UserID(c); ok {
+		t.Fatal("UserID reported a user without Auth")
+	}
+	c.Set(contextUserID, int64(0))
+	if _, ok := UserID(c); ok {
+		t.Fatal("UserID accepted user 0")
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+// ErrorHandler 统一错误渲染：handler 通过 response.Fail 记录错误，
+// 处理结束后按错误码目录渲染最后一个错误。未携带业务错误码的错误记录日志并按 PFIT-1005 返回。
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+		var coded *response.Error
+		if !errors.As(last.Err, &coded) {
+			logger.FromContext(c.Request.Context()).Error("Request failed", "method", c.Request.Method, "path", c.FullPath(), "error", last.Err)
+		}
+		response.Render(c, last.Err)
+	}
+}
+
+// Recovery 捕获 panic 并以统一响应结构返回 PFIT-1005，堆栈由 logger 记录
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered interface{}) {
+		logger.FromContext(c.Request.Context()).Error("Request panicked", "method", c.Request.Method, "path", c.FullPath(), "panic", fmt.Sprint(recovered))
+		response.Render(c, fmt.Errorf("panic: %v", recovered))
+	})
+}
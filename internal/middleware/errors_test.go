@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+// waitError 携带重试等待时长的业务错误
+type waitError struct {
+	err  *response.Error
+	wait time.Duration
+}
+
+func (e *waitError) Error() string             { return e.err.Error() }
+func (e *waitError) Unwrap() error             { return e.err }
+func (e *waitError) RetryAfter() time.Duration { return e.wait }
+
+func TestErrorHandler(t *testing.T) {
+	router := gin.New()
+	router.Use(ErrorHandler(), Recovery())
+	fail := func(err error) gin.HandlerFunc {
+		return func(c *gin.Context) { response.Fail(c, err) }
+	}
+	router.GET("/ok", func(c *gin.Context) { response.Success(c, "done") })
+	router.GET("/coded", fail(response.NewError(response.CodeFileTooLarge, "file exceeds 10 MB")))
+	router.GET("/wrapped", fail(fmt.Errorf("upload report: %w", response.NewError(response.CodeFileTooLarge, "file exceeds 10 MB"))))
+	router.GET("/unavailable", fail(response.NewError(response.CodeLLMUnavailable, "provider returned 503 for prompt")))
+	router.GET("/plain", fail(errors.New("connect to db: password authentication failed")))
+	router.GET("/throttled", fail(&waitError{response.NewError(response.CodeRateLimited, "too many requests"), 1500 * time.Millisecond}))
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	tests := []struct {
+		path    string
+		lang    string
+		status  int
+		code    string
+		message string
+		detail  string
+	}{
+		{"/ok", "", http.StatusOK, response.CodeOK, "成功", ""},
+		{"/coded", "", http.StatusRequestEntityTooLarge, response.CodeFileTooLarge, "文件大小超过限制，请压缩后重传", "file exceeds 10 MB"},
+		{"/coded", "en-US,en;q=0.9", http.StatusRequestEntityTooLarge, response.CodeFileTooLarge, "The file is too large, please compress it and upload again", "file exceeds 10 MB"},
+		{"/wrapped", "", http.StatusRequestEntityTooLarge, response.CodeFileTooLarge, "文件大小超过限制，请压缩后重传", "upload report: file exceeds 10 MB"},
+		// 5xx 不返回错误详情
+		{"/unavailable", "", http.StatusServiceUnavailable, response.CodeLLMUnavailable, "分析服务暂时不可用，请稍后重试", ""},
+		{"/plain", "", http.StatusInternalServerError, response.CodeInternal, "系统繁忙，请稍后重试", ""},
+		{"/panic", "", http.StatusInternalServerError, response.CodeInternal, "系统繁忙，请稍后重试", ""},
+		{"/throttled", "", http.StatusTooManyRequests, response.CodeRateLimited, "请求过于频繁，请稍后重试", "too many requests"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.lang, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.lang != "" {
+				req.Header.Set("Accept-Language", tt.lang)
+			}
+			w, resp := serve(t, router, req)
+			if w.Code != tt.status || resp.Code != tt.code || resp.Message != tt.message || resp.Detail != tt.detail {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+			}
+			// 成功与失败共用同一结构，始终带请求 ID 与时间戳
+			if resp.RequestID == "" {
+				t.Error("missing request_id")
+			}
+			if _, err := time.Parse(time.RFC3339, resp.Timestamp); err != nil {
+				t.Errorf("timestamp = %q: %v", resp.Timestamp, err)
+			}
+		})
+	}
+
+	// Retry-After 向上取整到秒
+	w, _ := serve(t, router, httptest.NewRequest(http.MethodGet, "/throttled", nil))
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+}
+
+func TestErrorHandlerKeepsWrittenResponse(t *testing.T) {
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/written", func(c *gin.Context) {
+		response.Success(c, "partial")
+		_ = c.Error(response.NewError(response.CodeInvalidParam, "late error"))
+	})
+
+	w, resp := serve(t, router, httptest.NewRequest(http.MethodGet, "/written", nil))
+	if w.Code != http.StatusOK || resp.Code != response.CodeOK || resp.Data != "partial" {
+		t.Fatalf("status = %d, body = %s, want the written success response", w.Code, w.Body.String())
+	}
+}
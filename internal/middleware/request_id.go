@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+// HeaderRequestID 请求 ID 请求头与响应头
+const HeaderRequestID = "X-Request-ID"
+
+// requestIDPattern 接受的外部请求 ID，不合法时重新生成，避免日志注入
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// RequestID 请求 ID 中间件：沿用客户端或网关传入的 X-Request-ID，缺失或不合法时生成 UUID。
+// 请求 ID 写入 gin 上下文（统一响应结构）与请求 ctx（日志、队列消息），并在响应头中回传。
+// 须在其他中间件之前注册。
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderRequestID)
+		if !requestIDPattern.MatchString(id) {
+			id = uuid.NewString()
+		}
+		c.Set(response.ContextRequestID, id)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), id))
+		c.Header(HeaderRequestID, id)
+		c.Next()
+	}
+}
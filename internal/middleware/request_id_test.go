@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+func TestRequestID(t *testing.T) {
+	router := gin.New()
+	router.Use(RequestID(), ErrorHandler())
+	router.GET("/ok", func(c *gin.Context) {
+		// 请求 ctx 与 gin 上下文中的请求 ID 一致
+		response.Success(c, logger.RequestID(c.Request.Context()))
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		response.Fail(c, response.NewError(response.CodeInvalidParam, "bad input"))
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"client id", "gw-2026.10:abc_123", true},
+		{"max length", strings.Repeat("a", 64), true},
+		{"missing", "", false},
+		{"too long", strings.Repeat("a", 65), false},
+		{"log injection", "abc\" level=error", false},
+		{"non-ascii", "请求-1", false},
+	}
+	for _, tt := range tests {
+		for _, path := range []string{"/ok", "/fail"} {
+			t.Run(tt.name+" "+path, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				if tt.header != "" {
+					req.Header.Set(HeaderRequestID, tt.header)
+				}
+				w, resp := serve(t, router, req)
+
+				id := w.Header().Get(HeaderRequestID)
+				if tt.keep && id != tt.header {
+					t.Fatalf("%s = %q, want %q", HeaderRequestID, id, tt.header)
+				}
+				if _, err := uuid.Parse(id); !tt.keep && err != nil {
+					t.Fatalf("%s = %q, want a generated UUID", HeaderRequestID, id)
+				}
+				if resp.RequestID != id {
+					t.Errorf("request_id = %q, want %q", resp.RequestID, id)
+				}
+				if path == "/ok" && resp.Data != id {
+					t.Errorf("context request id = %v, want %q", resp.Data, id)
+				}
+			})
+		}
+	}
+}
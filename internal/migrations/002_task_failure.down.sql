@@ -0,0 +1,2 @@
+ALTER TABLE analysis_task DROP COLUMN IF EXISTS retry_count;
+ALTER TABLE analysis_task DROP COLUMN IF EXISTS failure_code;
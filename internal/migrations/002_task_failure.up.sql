@@ -0,0 +1,5 @@
+ALTER TABLE analysis_task
+    ADD COLUMN IF NOT EXISTS failure_code VARCHAR(32);
+
+ALTER TABLE analysis_task
+    ADD COLUMN IF NOT EXISTS retry_count INTEGER NOT NULL DEFAULT 0;
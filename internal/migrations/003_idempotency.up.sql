@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS idempotency_record (
+    user_id BIGINT NOT NULL REFERENCES user_account(id) ON DELETE CASCADE,
+    scope VARCHAR(64) NOT NULL,
+    idem_key VARCHAR(64) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    task_id BIGINT REFERENCES analysis_task(id) ON DELETE CASCADE,
+    response JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, scope, idem_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_record_created_at ON idempotency_record(created_at);
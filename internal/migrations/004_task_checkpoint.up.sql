@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS task_checkpoint (
+    task_id BIGINT NOT NULL REFERENCES analysis_task(id) ON DELETE CASCADE,
+    stage VARCHAR(32) NOT NULL,
+    data JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, stage)
+);
+
+DROP TRIGGER IF EXISTS trg_task_checkpoint_updated_at ON task_checkpoint;
+
+CREATE TRIGGER trg_task_checkpoint_updated_at
+    BEFORE UPDATE ON task_checkpoint
+    FOR EACH ROW
+    EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,2 @@
+ALTER TABLE document
+    ADD COLUMN IF NOT EXISTS parsed_json JSONB;
@@ -0,0 +1 @@
+ALTER TABLE risk_finding DROP COLUMN IF EXISTS downgrades;
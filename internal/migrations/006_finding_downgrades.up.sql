@@ -0,0 +1,2 @@
+ALTER TABLE risk_finding
+    ADD COLUMN IF NOT EXISTS downgrades JSONB;
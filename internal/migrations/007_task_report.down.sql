@@ -0,0 +1,3 @@
+ALTER TABLE analysis_task DROP COLUMN IF EXISTS reported_at;
+ALTER TABLE analysis_task DROP COLUMN IF EXISTS prompt_version;
+ALTER TABLE analysis_task DROP COLUMN IF EXISTS rule_version;
@@ -0,0 +1,8 @@
+ALTER TABLE analysis_task
+    ADD COLUMN IF NOT EXISTS rule_version VARCHAR(64);
+
+ALTER TABLE analysis_task
+    ADD COLUMN IF NOT EXISTS prompt_version VARCHAR(128);
+
+ALTER TABLE analysis_task
+    ADD COLUMN IF NOT EXISTS reported_at TIMESTAMP;
@@ -0,0 +1,6 @@
+-- 回滚前须确认不存在不同用户重复的 request_id，否则建索引失败
+DROP INDEX IF EXISTS idx_task_user_request_id_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_request_id_unique
+    ON analysis_task(request_id)
+    WHERE request_id IS NOT NULL;
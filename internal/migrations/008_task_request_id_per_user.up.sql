@@ -0,0 +1,6 @@
+-- 幂等键按用户隔离：不同用户可使用相同的 request_id
+DROP INDEX IF EXISTS idx_task_request_id_unique;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_user_request_id_unique
+    ON analysis_task(user_id, request_id)
+    WHERE request_id IS NOT NULL;
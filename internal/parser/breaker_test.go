@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	b := newBreaker(2, 30*time.Millisecond)
+
+	// 关闭：未达阈值前全部放行
+	for i := 0; i < 2; i++ {
+		if !b.allow() {
+			t.Fatalf("attempt %d rejected before reaching the threshold", i+1)
+		}
+		b.failure()
+	}
+
+	// 打开：冷却期内拒绝
+	if b.allow() {
+		t.Fatal("open breaker allowed a request")
+	}
+
+	// 半开：冷却结束后只放行一个试探请求
+	time.Sleep(40 * time.Millisecond)
+	if !b.allow() {
+		t.Fatal("half-open breaker rejected the probe")
+	}
+	if b.allow() {
+		t.Fatal("half-open breaker allowed a second probe")
+	}
+
+	// 试探失败：重新打开
+	b.failure()
+	if b.allow() {
+		t.Fatal("breaker not reopened after the probe failed")
+	}
+
+	// 调用方取消的试探不计入统计，冷却后可再次试探
+	time.Sleep(40 * time.Millisecond)
+	if !b.allow() {
+		t.Fatal("half-open breaker rejected the probe")
+	}
+	b.release()
+	if !b.allow() {
+		t.Fatal("released probe blocked the next probe")
+	}
+
+	// 试探成功：关闭并清零计数
+	b.success()
+	if !b.allow() {
+		t.Fatal("breaker not closed after the probe succeeded")
+	}
+	b.failure()
+	if !b.allow() {
+		t.Fatal("failure count not reset after closing")
+	}
+}
@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	paraPrefix = "para_"
+
+	// maxParagraphRunes 单个段落的字符上限。表格类页面（如体检指标）往往没有空行分隔，
+	// 超过上限时在行边界切开，保证证据定位的粒度。
+	maxParagraphRunes = 800
+)
+
+// segmenter 切分段落并分配全局递增的 para_N 编号，段落不跨页
+type segmenter struct {
+	paragraphs []Paragraph
+}
+
+// addPage 追加一页 pdftotext -layout 输出，按空行切分段落
+func (s *segmenter) addPage(page int, text string) {
+	s.addLines(page, text, true)
+}
+
+// addBlock 追加外部解析器给出的一个段落，内部空行不再切分
+func (s *segmenter) addBlock(page int, text string) {
+	s.addLines(page, text, false)
+}
+
+func (s *segmenter) addLines(page int, text string, splitOnBlank bool) {
+	text = strings.ReplaceAll(text, "\f", "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var (
+		lines []string
+		runes int
+	)
+	flush := func() {
+		s.emit(page, lines)
+		lines, runes = nil, 0
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" {
+			if splitOnBlank {
+				flush()
+			}
+			continue
+		}
+		n := utf8.RuneCountInString(line)
+		if len(lines) > 0 && runes+n > maxParagraphRunes {
+			flush()
+		}
+		lines = append(lines, line)
+		runes += n
+	}
+	flush()
+}
+
+// emit 去除版式缩进后写入段落。仅含数字与符号的段落（页码、分隔线）不编号。
+func (s *segmenter) emit(page int, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	indent := -1
+	for _, line := range lines {
+		n := len(line) - len(strings.TrimLeft(line, " "))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i := range lines {
+		lines[i] = lines[i][indent:]
+	}
+	text := strings.Join(lines, "\n")
+	if strings.IndexFunc(text, unicode.IsLetter) < 0 {
+		return
+	}
+	s.paragraphs = append(s.paragraphs, Paragraph{
+		ID:   paraPrefix + strconv.Itoa(len(s.paragraphs)+1),
+		Page: page,
+		Text: text,
+	})
+}
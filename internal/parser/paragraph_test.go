@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSegmenterAddPage(t *testing.T) {
+	var seg segmenter
+	seg.addPage(1, "    体检报告\r\n\n    姓名：张三\n      血压 152/95 mmHg   \n\n              - 1 -\n\f")
+	seg.addPage(2, "\n-----------\n\n结论\n  高血压 1 级\n")
+
+	want := []Paragraph{
+		{ID: "para_1", Page: 1, Text: "体检报告"},
+		// 保留段落内的相对缩进，去除共同缩进与行尾空白
+		{ID: "para_2", Page: 1, Text: "姓名：张三\n  血压 152/95 mmHg"},
+		// 页码与分隔线不编号，编号跨页连续
+		{ID: "para_3", Page: 2, Text: "结论\n  高血压 1 级"},
+	}
+	if len(seg.paragraphs) != len(want) {
+		t.Fatalf("paragraphs = %+v, want %+v", seg.paragraphs, want)
+	}
+	for i := range want {
+		if seg.paragraphs[i] != want[i] {
+			t.Errorf("paragraph %d = %+v, want %+v", i, seg.paragraphs[i], want[i])
+		}
+	}
+}
+
+func TestSegmenterSplitsLongParagraphs(t *testing.T) {
+	// 没有空行分隔的指标表格按行切开，单个段落不超过上限
+	line := strings.Repeat("指", 99)
+	var seg segmenter
+	seg.addPage(1, strings.Repeat(line+"\n", 20))
+
+	if len(seg.paragraphs) != 3 {
+		t.Fatalf("paragraphs = %d, want 3", len(seg.paragraphs))
+	}
+	total := 0
+	for _, p := range seg.paragraphs {
+		n := utf8.RuneCountInString(strings.ReplaceAll(p.Text, "\n", ""))
+		if n > maxParagraphRunes {
+			t.Errorf("%s has %d runes, want at most %d", p.ID, n, maxParagraphRunes)
+		}
+		total += strings.Count(p.Text, "\n") + 1
+	}
+	if total != 20 {
+		t.Errorf("lines = %d, want all 20 kept", total)
+	}
+}
+
+func TestSegmenterAddBlock(t *testing.T) {
+	var seg segmenter
+	seg.addBlock(3, "第五条 责任免除\n\n（一）既往症")
+	if len(seg.paragraphs) != 1 || seg.paragraphs[0].Text != "第五条 责任免除\n（一）既往症" || seg.paragraphs[0].Loc() != "p3/para_1" {
+		t.Fatalf("paragraphs = %+v, want one block on page 3", seg.paragraphs)
+	}
+}
+
+func TestParseLoc(t *testing.T) {
+	tests := []struct {
+		loc    string
+		page   int
+		paraID string
+		valid  bool
+	}{
+		{"p3/para_12", 3, "para_12", true},
+		{"para_7", 0, "para_7", true},
+		{"p0/para_1", 0, "", false},
+		{"3/para_1", 0, "", false},
+		{"p1/para_0", 0, "", false},
+		{"p1/paragraph_1", 0, "", false},
+		{"", 0, "", false},
+	}
+	for _, tt := range tests {
+		page, paraID, err := ParseLoc(tt.loc)
+		if (err == nil) != tt.valid || page != tt.page || paraID != tt.paraID {
+			t.Errorf("ParseLoc(%q) = %d, %q, %v", tt.loc, page, paraID, err)
+		}
+	}
+}
+
+func TestResultParagraph(t *testing.T) {
+	r := &Result{Paragraphs: []Paragraph{{ID: "para_1", Page: 1, Text: "a"}, {ID: "para_2", Page: 2, Text: "b"}}}
+	if p, ok := r.Paragraph("para_2"); !ok || p.Text != "b" {
+		t.Errorf("Paragraph(para_2) = %+v, %v", p, ok)
+	}
+	for _, id := range []string{"para_0", "para_3", "p2/para_2", "x"} {
+		if _, ok := r.Paragraph(id); ok {
+			t.Errorf("Paragraph(%q) found", id)
+		}
+	}
+	if r.Text() != "a\n\nb" {
+		t.Errorf("Text = %q", r.Text())
+	}
+}
@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/config"
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+// ResultVersion 解析结果 JSON 结构版本，结构不兼容变更时递增
+const ResultVersion = 1
+
+// Parser PDF 文本解析器
+type Parser interface {
+	// Name 解析器名称，写入解析结果
+	Name() string
+	// Parse 解析 PDF，失败时返回 *Error。docType 供按文档类型选择解析策略的后端使用
+	Parse(ctx context.Context, docType domain.DocumentType, pdf io.Reader) (*Result, error)
+}
+
+// Result 标准解析结果，与 document.parsed_text 一同存储于 document.parsed_json
+type Result struct {
+	Version    int         `json:"version"`
+	Parser     string      `json:"parser"`
+	Pages      int         `json:"pages"`
+	Paragraphs []Paragraph `json:"paragraphs"`
+}
+
+// Paragraph 段落，ID 为全文档递增的 para_N（从 1 开始），按页码与页内顺序编号
+type Paragraph struct {
+	ID   string `json:"id"`
+	Page int    `json:"page"`
+	Text string `json:"text"`
+}
+
+// Loc 证据定位，如 "p3/para_12"，用于 Evidence.Loc 与 EvidenceDetail.Loc
+func (p Paragraph) Loc() string {
+	return FormatLoc(p.Page, p.ID)
+}
+
+// FormatLoc 拼接证据定位
+func FormatLoc(page int, paraID string) string {
+	return "p" + strconv.Itoa(page) + "/" + paraID
+}
+
+// ParseLoc 解析证据定位，兼容仅含段落编号的 "para_12"（页码为 0）
+func ParseLoc(loc string) (page int, paraID string, err error) {
+	pagePart, paraID, hasPage := strings.Cut(loc, "/")
+	if !hasPage {
+		paraID = pagePart
+	} else {
+		page, err = strconv.Atoi(strings.TrimPrefix(pagePart, "p"))
+		if err != nil || !strings.HasPrefix(pagePart, "p") || page <= 0 {
+			return 0, "", fmt.Errorf("invalid loc: %q", loc)
+		}
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(paraID, paraPrefix))
+	if err != nil || !strings.HasPrefix(paraID, paraPrefix) || n <= 0 {
+		return 0, "", fmt.Errorf("invalid loc: %q", loc)
+	}
+	return page, paraID, nil
+}
+
+// Text 段落全文，段落间以空行分隔，写入 document.parsed_text
+func (r *Result) Text() string {
+	texts := make([]string, len(r.Paragraphs))
+	for i, p := range r.Paragraphs {
+		texts[i] = p.Text
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// Paragraph 按 ID 查找段落
+func (r *Result) Paragraph(id string) (Paragraph, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(id, paraPrefix))
+	if err != nil || n <= 0 || n > len(r.Paragraphs) || r.Paragraphs[n-1].ID != id {
+		return Paragraph{}, false
+	}
+	return r.Paragraphs[n-1], true
+}
+
+// Decode 解码 document.parsed_json，拒绝不支持的版本
+func Decode(data []byte) (*Result, error) {
+	var r Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to decode parse result: %w", err)
+	}
+	if r.Version != ResultVersion {
+		return nil, fmt.Errorf("unsupported parse result version: %d", r.Version)
+	}
+	return &r, nil
+}
+
+// Kind 解析失败分类
+type Kind string
+
+const (
+	// KindUnreadable 文件损坏、加密或不是合法 PDF
+	KindUnreadable Kind = "unreadable"
+	// KindEmptyText 没有可提取的文本，疑似扫描件
+	KindEmptyText Kind = "empty_text"
+	// KindCommand 解析命令异常（未安装、超时、非预期退出），可重试
+	KindCommand Kind = "command_error"
+)
+
+// ErrParse 解析失败，可通过 errors.Is(err, ErrParse) 判断
+var ErrParse = errors.New("pdf parse failed")
+
+// Error 解析失败
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("pdf parse failed (%s): %v", e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return target == ErrParse
+}
+
+// FailureCode 写入任务的失败码
+func (e *Error) FailureCode() string {
+	switch e.Kind {
+	case KindUnreadable:
+		return response.CodeDocumentUnreadable
+	case KindEmptyText:
+		return response.CodeDocumentNoText
+	default:
+		return response.CodeInternal
+	}
+}
+
+// Retryable 仅命令异常值得重试，文件本身的问题重试不会成功
+func (e *Error) Retryable() bool {
+	return e.Kind == KindCommand
+}
+
+// New 按配置创建解析器
+func New(cfg config.ParserConfig) (Parser, error) {
+	switch cfg.PDFParser {
+	case NamePDFToText:
+		return NewPDFToText(time.Duration(cfg.Timeout) * time.Second), nil
+	case NamePythonService:
+		return NewPythonService(PythonServiceOptions{
+			BaseURL:     cfg.PythonServiceURL,
+			CallTimeout: time.Duration(cfg.ServiceTimeout) * time.Second,
+			Timeout:     time.Duration(cfg.Timeout) * time.Second,
+			MaxRetries:  cfg.ServiceMaxRetries,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported pdf parser: %s", cfg.PDFParser)
+	}
+}
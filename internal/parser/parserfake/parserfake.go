@@ -0,0 +1,157 @@
+// Package parserfake 提供进程内的 python-service 桩服务，用于在没有 Python 解析服务的环境下
+// 验证 python-service 解析后端。文件内容在首行 "%PDF-" 之后按纯文本处理：
+// 换页符 "\f" 分页，空行分段；含 "%ENCRYPTED" 时按不可读文档拒绝。
+package parserfake
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/parser"
+)
+
+// maxUploadBytes 单次请求上限
+const maxUploadBytes = 64 << 20
+
+// Server python-service 桩服务，实现 http.Handler，可配合 httptest.NewServer 使用
+type Server struct {
+	// Delay 每个请求的处理延迟，便于验证超时
+	Delay time.Duration
+
+	mu       sync.Mutex
+	failures []int
+	requests map[string]int
+}
+
+// New 创建桩服务
+func New() *Server {
+	return &Server{requests: make(map[string]int)}
+}
+
+// FailNext 之后的 n 个请求返回 status，便于验证重试与熔断
+func (s *Server) FailNext(n, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, status)
+	}
+}
+
+// Requests 返回各接口收到的请求数
+func (s *Server) Requests() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int, len(s.requests))
+	for k, v := range s.requests {
+		out[k] = v
+	}
+	return out
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case parser.EndpointDocument, parser.EndpointReport, parser.EndpointPolicy:
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := s.record(r.URL.Path)
+	if s.Delay > 0 {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(s.Delay):
+		}
+	}
+	if status != 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	if err := r.ParseMultipartForm(maxUploadBytes); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	header, text, _ := bytes.Cut(data, []byte("\n"))
+	if !bytes.HasPrefix(header, []byte("%PDF-")) || bytes.Contains(data, []byte("%ENCRYPTED")) {
+		writeError(w, parser.KindUnreadable, "document is encrypted or corrupted")
+		return
+	}
+	resp := buildResponse(string(text))
+	if len(resp.Pages) == 0 {
+		writeError(w, parser.KindEmptyText, "no extractable text")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// record 记录请求并取出待注入的失败状态码
+func (s *Server) record(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[path]++
+	if len(s.failures) == 0 {
+		return 0
+	}
+	status := s.failures[0]
+	s.failures = s.failures[1:]
+	return status
+}
+
+func buildResponse(text string) parser.ServiceResponse {
+	pages := strings.Split(text, "\f")
+	pageCount := len(pages)
+	resp := parser.ServiceResponse{
+		Version:   parser.ServiceSchemaVersion,
+		PageCount: &pageCount,
+		Pages:     []parser.ServicePage{},
+	}
+	for i, page := range pages {
+		var paragraphs []parser.ServiceParagraph
+		for _, block := range strings.Split(page, "\n\n") {
+			block := strings.TrimSpace(block)
+			if block != "" {
+				paragraphs = append(paragraphs, parser.ServiceParagraph{Text: &block})
+			}
+		}
+		if len(paragraphs) > 0 {
+			resp.Pages = append(resp.Pages, parser.ServicePage{Page: i + 1, Paragraphs: paragraphs})
+		}
+	}
+	return resp
+}
+
+func writeError(w http.ResponseWriter, kind parser.Kind, message string) {
+	var resp parser.ServiceErrorResponse
+	resp.Error.Kind = kind
+	resp.Error.Message = message
+	writeJSON(w, http.StatusUnprocessableEntity, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
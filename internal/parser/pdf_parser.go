@@ -0,0 +1,158 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+// NamePDFToText pdftotext 解析器名称，与 PDF_PARSER 配置一致
+const NamePDFToText = "pdftotext"
+
+// poppler-utils 退出码：1 无法打开 PDF，3 权限（加密）限制
+const (
+	popplerExitOpenFailed  = 1
+	popplerExitPermissions = 3
+)
+
+// maxStderrLen 错误信息中保留的 stderr 长度
+const maxStderrLen = 512
+
+// PDFToText 基于 poppler-utils 的解析器：pdfinfo 读取页数，pdftotext -layout 逐页提取文本。
+// timeout 限制整份文档的解析时长，超时视为命令异常。
+type PDFToText struct {
+	timeout time.Duration
+	bin     string
+	infoBin string
+}
+
+// NewPDFToText 创建 pdftotext 解析器，timeout 为 0 时不限时
+func NewPDFToText(timeout time.Duration) *PDFToText {
+	return &PDFToText{
+		timeout: timeout,
+		bin:     "pdftotext",
+		infoBin: "pdfinfo",
+	}
+}
+
+func (p *PDFToText) Name() string {
+	return NamePDFToText
+}
+
+func (p *PDFToText) Parse(ctx context.Context, _ domain.DocumentType, pdf io.Reader) (*Result, error) {
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	// poppler 需要可随机访问的文件
+	path, err := writeTemp(pdf)
+	if err != nil {
+		return nil, &Error{Kind: KindCommand, Err: err}
+	}
+	defer os.Remove(path)
+
+	pages, err := p.pageCount(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Version: ResultVersion, Parser: p.Name(), Pages: pages}
+	var seg segmenter
+	for page := 1; page <= pages; page++ {
+		n := strconv.Itoa(page)
+		out, err := p.run(ctx, p.bin, "-layout", "-enc", "UTF-8", "-f", n, "-l", n, path, "-")
+		if err != nil {
+			return nil, err
+		}
+		seg.addPage(page, string(out))
+	}
+	result.Paragraphs = seg.paragraphs
+
+	if len(result.Paragraphs) == 0 {
+		return nil, &Error{Kind: KindEmptyText, Err: fmt.Errorf("no extractable text in %d page(s)", pages)}
+	}
+	return result, nil
+}
+
+// pageCount 通过 pdfinfo 读取页数
+func (p *PDFToText) pageCount(ctx context.Context, path string) (int, error) {
+	out, err := p.run(ctx, p.infoBin, path)
+	if err != nil {
+		return 0, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || strings.TrimSpace(name) != "Pages" {
+			continue
+		}
+		pages, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || pages < 0 {
+			return 0, &Error{Kind: KindUnreadable, Err: fmt.Errorf("invalid page count: %q", value)}
+		}
+		return pages, nil
+	}
+	return 0, &Error{Kind: KindCommand, Err: errors.New("pdfinfo output has no page count")}
+}
+
+// run 执行命令并按退出码分类错误
+func (p *PDFToText) run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err == nil {
+		return stdout.Bytes(), nil
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		if errors.Is(ctxErr, context.DeadlineExceeded) {
+			ctxErr = fmt.Errorf("%s timed out: %w", name, ctxErr)
+		}
+		return nil, &Error{Kind: KindCommand, Err: ctxErr}
+	}
+
+	detail := strings.TrimSpace(stderr.String())
+	if len(detail) > maxStderrLen {
+		detail = detail[:maxStderrLen]
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		switch exitErr.ExitCode() {
+		case popplerExitOpenFailed, popplerExitPermissions:
+			return nil, &Error{Kind: KindUnreadable, Err: fmt.Errorf("%s: %s", name, detail)}
+		}
+		return nil, &Error{Kind: KindCommand, Err: fmt.Errorf("%s exited with %d: %s", name, exitErr.ExitCode(), detail)}
+	}
+	return nil, &Error{Kind: KindCommand, Err: fmt.Errorf("failed to run %s: %w", name, err)}
+}
+
+func writeTemp(r io.Reader) (string, error) {
+	f, err := os.CreateTemp("", "policyfit-*.pdf")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	return f.Name(), nil
+}
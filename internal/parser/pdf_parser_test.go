@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+// fakePoppler 以 shell 脚本代替 pdfinfo 与 pdftotext，pages 为各页的 pdftotext 输出
+func fakePoppler(t *testing.T, info string, pages ...string) *PDFToText {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	script := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	// pdftotext -layout -enc UTF-8 -f N -l N file -：第 5 个参数为页码
+	var cases strings.Builder
+	for i, text := range pages {
+		cases.WriteString(strconv.Itoa(i+1) + ") printf '%s' '" + text + "' ;;\n")
+	}
+	p := NewPDFToText(5 * time.Second)
+	p.infoBin = script("pdfinfo", info)
+	p.bin = script("pdftotext", "case \"$5\" in\n"+cases.String()+"esac")
+	return p
+}
+
+// parseKind 断言 err 为指定分类的 *Error
+func parseKind(t *testing.T, err error, kind Kind) {
+	t.Helper()
+	var parseErr *Error
+	if !errors.As(err, &parseErr) || parseErr.Kind != kind {
+		t.Fatalf("err = %v, want parser error of kind %s", err, kind)
+	}
+}
+
+func parsePDF(p *PDFToText) (*Result, error) {
+	return p.Parse(context.Background(), domain.DocTypeReport, strings.NewReader("%PDF-1.7\n"))
+}
+
+func TestPDFToTextParse(t *testing.T) {
+	p := fakePoppler(t, "echo 'Title: report'; echo 'Pages:          2'",
+		"  体检报告\n\n  血压 152/95 mmHg\n\n      - 1 -\n\f",
+		"结论\n  高血压\n\f",
+	)
+	result, err := parsePDF(p)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if result.Version != ResultVersion || result.Parser != NamePDFToText || result.Pages != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+	var locs []string
+	for _, para := range result.Paragraphs {
+		locs = append(locs, para.Loc())
+	}
+	if got := strings.Join(locs, ","); got != "p1/para_1,p1/para_2,p2/para_3" {
+		t.Errorf("locs = %s", got)
+	}
+}
+
+func TestPDFToTextErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		info  string
+		pages []string
+		kind  Kind
+	}{
+		{"encrypted", "echo 'Command Line Error: Incorrect password' >&2; exit 3", nil, KindUnreadable},
+		{"not a pdf", "echo 'Syntax Error: May not be a PDF file' >&2; exit 1", nil, KindUnreadable},
+		{"scanned pages", "echo 'Pages: 2'", []string{"\f", "  \n\f"}, KindEmptyText},
+		{"no page count", "echo 'Title: report'", nil, KindCommand},
+		{"unexpected exit", "exit 99", nil, KindCommand},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePDF(fakePoppler(t, tt.info, tt.pages...))
+			parseKind(t, err, tt.kind)
+		})
+	}
+}
+
+func TestPDFToTextTimeout(t *testing.T) {
+	p := fakePoppler(t, "exec sleep 5")
+	p.timeout = 50 * time.Millisecond
+	_, err := parsePDF(p)
+	parseKind(t, err, KindCommand)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want a deadline error", err)
+	}
+}
+
+func TestPDFToTextMissingBinary(t *testing.T) {
+	p := NewPDFToText(time.Second)
+	p.infoBin = filepath.Join(t.TempDir(), "pdfinfo")
+	_, err := parsePDF(p)
+	parseKind(t, err, KindCommand)
+}
@@ -0,0 +1,314 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+// NamePythonService python-service 解析器名称，与 PDF_PARSER 配置一致
+const NamePythonService = "python-service"
+
+// ServiceSchemaVersion python-service 响应结构版本
+const ServiceSchemaVersion = 1
+
+const (
+	// EndpointDocument 通用文档解析接口，未单独适配的文档类型使用
+	EndpointDocument = "/parse/document"
+	// EndpointReport 体检报告解析接口
+	EndpointReport = "/parse/report"
+	// EndpointPolicy 保险条款解析接口
+	EndpointPolicy = "/parse/policy"
+
+	// maxServiceResponseBytes 响应体上限
+	maxServiceResponseBytes = 32 << 20
+)
+
+// ServiceResponse python-service 解析成功响应（200）。
+// 各接口可附带结构化字段，客户端只读取段落结构，未知字段忽略。
+type ServiceResponse struct {
+	Version   int           `json:"version"`
+	PageCount *int          `json:"page_count"`
+	Pages     []ServicePage `json:"pages"`
+}
+
+// ServicePage 单页解析结果，page 从 1 开始
+type ServicePage struct {
+	Page       int                `json:"page"`
+	Paragraphs []ServiceParagraph `json:"paragraphs"`
+}
+
+// ServiceParagraph 段落
+type ServiceParagraph struct {
+	Text *string `json:"text"`
+}
+
+// ServiceErrorResponse python-service 文档本身无法解析时的响应（422）
+type ServiceErrorResponse struct {
+	Error struct {
+		Kind    Kind   `json:"kind"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// PythonServiceOptions python-service 解析器参数
+type PythonServiceOptions struct {
+	BaseURL string
+	// CallTimeout 单次请求超时
+	CallTimeout time.Duration
+	// Timeout 整份文档解析超时（含重试），0 表示不限
+	Timeout time.Duration
+	// MaxRetries 5xx、网络错误与单次超时的最大重试次数
+	MaxRetries int
+	// RetryDelay 首次重试等待时长，之后每次翻倍，默认 500ms
+	RetryDelay time.Duration
+	// BreakerThreshold 触发熔断的连续失败次数，默认 5
+	BreakerThreshold int
+	// BreakerCooldown 熔断持续时长，默认 30s
+	BreakerCooldown time.Duration
+	// HTTPClient 默认 http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// PythonService 调用外部 python-service 解析文档，结果归一为与 pdftotext 相同的段落/页码结构。
+// 体检报告与保险条款使用专用接口，其余文档类型使用通用接口。
+type PythonService struct {
+	opts    PythonServiceOptions
+	breaker *breaker
+}
+
+// NewPythonService 创建 python-service 解析器
+func NewPythonService(opts PythonServiceOptions) *PythonService {
+	opts.BaseURL = strings.TrimRight(opts.BaseURL, "/")
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = 500 * time.Millisecond
+	}
+	if opts.BreakerThreshold <= 0 {
+		opts.BreakerThreshold = 5
+	}
+	if opts.BreakerCooldown <= 0 {
+		opts.BreakerCooldown = 30 * time.Second
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	return &PythonService{
+		opts:    opts,
+		breaker: newBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
+	}
+}
+
+func (p *PythonService) Name() string {
+	return NamePythonService
+}
+
+// Endpoint 文档类型对应的解析接口
+func Endpoint(docType domain.DocumentType) string {
+	switch docType {
+	case domain.DocTypeReport:
+		return EndpointReport
+	case domain.DocTypePolicy:
+		return EndpointPolicy
+	default:
+		return EndpointDocument
+	}
+}
+
+func (p *PythonService) Parse(ctx context.Context, docType domain.DocumentType, pdf io.Reader) (*Result, error) {
+	if p.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.opts.Timeout)
+		defer cancel()
+	}
+
+	body, contentType, err := multipartBody(docType, pdf)
+	if err != nil {
+		return nil, &Error{Kind: KindCommand, Err: err}
+	}
+
+	endpoint := Endpoint(docType)
+	for attempt := 0; ; attempt++ {
+		if !p.breaker.allow() {
+			return nil, &Error{Kind: KindCommand, Err: fmt.Errorf("%s: %w", endpoint, ErrCircuitOpen)}
+		}
+		resp, retry, err := p.call(ctx, endpoint, contentType, body)
+		switch {
+		case ctx.Err() != nil:
+			p.breaker.release()
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, &Error{Kind: KindCommand, Err: fmt.Errorf("%s timed out: %w", endpoint, ctx.Err())}
+			}
+			return nil, &Error{Kind: KindCommand, Err: ctx.Err()}
+		case retry:
+			p.breaker.failure()
+		default:
+			// 下游正常响应（包括拒绝解析），熔断器视为成功
+			p.breaker.success()
+			if err != nil {
+				return nil, err
+			}
+			return normalize(resp)
+		}
+
+		if attempt >= p.opts.MaxRetries {
+			return nil, &Error{Kind: KindCommand, Err: fmt.Errorf("%s failed after %d attempt(s): %w", endpoint, attempt+1, err)}
+		}
+		sleepCtx(ctx, p.opts.RetryDelay<<attempt)
+	}
+}
+
+// call 发送一次请求。retry 为 true 表示 5xx、网络错误或单次超时，可重试且计入熔断
+func (p *PythonService) call(ctx context.Context, endpoint, contentType string, body []byte) (resp *ServiceResponse, retry bool, err error) {
+	if p.opts.CallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.opts.CallTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.opts.BaseURL+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, &Error{Kind: KindCommand, Err: err}
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+
+	httpResp, err := p.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(httpResp.Body, maxServiceResponseBytes+1))
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	switch {
+	case httpResp.StatusCode >= http.StatusInternalServerError:
+		return nil, true, fmt.Errorf("status %d: %s", httpResp.StatusCode, truncate(data))
+	case httpResp.StatusCode == http.StatusUnprocessableEntity:
+		return nil, false, decodeServiceError(data)
+	case httpResp.StatusCode != http.StatusOK:
+		return nil, false, &Error{Kind: KindCommand, Err: fmt.Errorf("%s returned status %d: %s", endpoint, httpResp.StatusCode, truncate(data))}
+	case len(data) > maxServiceResponseBytes:
+		return nil, false, &Error{Kind: KindCommand, Err: fmt.Errorf("%s response exceeds %d bytes", endpoint, maxServiceResponseBytes)}
+	}
+
+	var out ServiceResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, false, &Error{Kind: KindCommand, Err: fmt.Errorf("%s returned invalid json: %w", endpoint, err)}
+	}
+	if err := validateResponse(&out); err != nil {
+		return nil, false, &Error{Kind: KindCommand, Err: fmt.Errorf("%s returned invalid response: %w", endpoint, err)}
+	}
+	return &out, false, nil
+}
+
+// validateResponse 校验响应结构：页码在 [1, page_count] 内且严格递增，段落文本必填
+func validateResponse(resp *ServiceResponse) error {
+	if resp.Version != ServiceSchemaVersion {
+		return fmt.Errorf("unsupported version %d", resp.Version)
+	}
+	if resp.PageCount == nil || *resp.PageCount < 0 {
+		return errors.New("page_count is required")
+	}
+	if resp.Pages == nil {
+		return errors.New("pages is required")
+	}
+	last := 0
+	for i, page := range resp.Pages {
+		if page.Page <= last || page.Page > *resp.PageCount {
+			return fmt.Errorf("pages[%d].page %d out of order or range", i, page.Page)
+		}
+		last = page.Page
+		for j, para := range page.Paragraphs {
+			if para.Text == nil {
+				return fmt.Errorf("pages[%d].paragraphs[%d].text is required", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// normalize 归一为标准解析结果，段落编号规则与 pdftotext 一致
+func normalize(resp *ServiceResponse) (*Result, error) {
+	var seg segmenter
+	for _, page := range resp.Pages {
+		for _, para := range page.Paragraphs {
+			seg.addBlock(page.Page, *para.Text)
+		}
+	}
+	if len(seg.paragraphs) == 0 {
+		return nil, &Error{Kind: KindEmptyText, Err: fmt.Errorf("no extractable text in %d page(s)", *resp.PageCount)}
+	}
+	return &Result{
+		Version:    ResultVersion,
+		Parser:     NamePythonService,
+		Pages:      *resp.PageCount,
+		Paragraphs: seg.paragraphs,
+	}, nil
+}
+
+// decodeServiceError 422 响应按 kind 分类，未知 kind 视为不可读
+func decodeServiceError(data []byte) error {
+	var out ServiceErrorResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return &Error{Kind: KindUnreadable, Err: fmt.Errorf("unprocessable document: %s", truncate(data))}
+	}
+	kind := out.Error.Kind
+	if kind != KindEmptyText {
+		kind = KindUnreadable
+	}
+	return &Error{Kind: kind, Err: errors.New(out.Error.Message)}
+}
+
+// multipartBody 构造请求体（file、doc_type 两个字段），重试时复用
+func multipartBody(docType domain.DocumentType, pdf io.Reader) ([]byte, string, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	if err := mw.WriteField("doc_type", string(docType)); err != nil {
+		return nil, "", err
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="file"; filename="document.pdf"`)
+	header.Set("Content-Type", "application/pdf")
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.Copy(part, pdf); err != nil {
+		return nil, "", fmt.Errorf("failed to read document: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), mw.FormDataContentType(), nil
+}
+
+func truncate(data []byte) string {
+	s := strings.TrimSpace(string(data))
+	if len(s) > maxStderrLen {
+		s = s[:maxStderrLen]
+	}
+	return s
+}
+
+// sleepCtx 等待 d 或 ctx 取消
+func sleepCtx(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
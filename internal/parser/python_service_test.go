@@ -0,0 +1,215 @@
+package parser_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/parser"
+	"github.com/zhenglizhi/policy-fit/internal/parser/parserfake"
+)
+
+const testPDF = "%PDF-1.7\n第一条 保险责任\n\n被保险人因疾病住院治疗\f第二条 责任免除\n"
+
+// newService 启动 parserfake 并返回连接到它的解析器
+func newService(t *testing.T, opts parser.PythonServiceOptions) (*parser.PythonService, *parserfake.Server) {
+	t.Helper()
+	fake := parserfake.New()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	opts.BaseURL = srv.URL
+	if opts.RetryDelay == 0 {
+		opts.RetryDelay = time.Millisecond
+	}
+	return parser.NewPythonService(opts), fake
+}
+
+func parse(p parser.Parser, content string) (*parser.Result, error) {
+	return p.Parse(context.Background(), domain.DocTypePolicy, strings.NewReader(content))
+}
+
+// parseError 断言 err 为指定分类的 *parser.Error
+func parseError(t *testing.T, err error, kind parser.Kind) *parser.Error {
+	t.Helper()
+	var parseErr *parser.Error
+	if !errors.As(err, &parseErr) || parseErr.Kind != kind {
+		t.Fatalf("err = %v, want parser error of kind %s", err, kind)
+	}
+	return parseErr
+}
+
+func TestPythonServiceParse(t *testing.T) {
+	p, fake := newService(t, parser.PythonServiceOptions{})
+	res, err := parse(p, testPDF)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if res.Parser != parser.NamePythonService || res.Pages != 2 || len(res.Paragraphs) != 3 {
+		t.Fatalf("result = %s, %d page(s), %d paragraph(s), want python-service, 2, 3", res.Parser, res.Pages, len(res.Paragraphs))
+	}
+	if got := res.Paragraphs[2].Loc(); got != "p2/para_3" {
+		t.Errorf("third paragraph loc = %s, want p2/para_3", got)
+	}
+	if got := fake.Requests()[parser.EndpointPolicy]; got != 1 {
+		t.Errorf("%s requests = %d, want 1", parser.EndpointPolicy, got)
+	}
+}
+
+func TestPythonServiceRejectedDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		kind    parser.Kind
+	}{
+		{"encrypted", "%PDF-1.7\n%ENCRYPTED\n", parser.KindUnreadable},
+		{"not a pdf", "hello", parser.KindUnreadable},
+		{"no text", "%PDF-1.7\n\f\n", parser.KindEmptyText},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, fake := newService(t, parser.PythonServiceOptions{MaxRetries: 3})
+			_, err := parse(p, tt.content)
+			if parseErr := parseError(t, err, tt.kind); parseErr.Retryable() {
+				t.Error("rejected document classified as retryable")
+			}
+			if got := fake.Requests()[parser.EndpointPolicy]; got != 1 {
+				t.Errorf("requests = %d, want 1 (no retry)", got)
+			}
+		})
+	}
+}
+
+func TestPythonServiceRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		status   int
+		wantErr  bool
+		wantReqs int
+	}{
+		{"recovers from 503", 2, http.StatusServiceUnavailable, false, 3},
+		{"recovers from 500", 1, http.StatusInternalServerError, false, 2},
+		{"retries exhausted", 3, http.StatusBadGateway, true, 3},
+		{"4xx not retried", 1, http.StatusBadRequest, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, fake := newService(t, parser.PythonServiceOptions{MaxRetries: 2})
+			fake.FailNext(tt.failures, tt.status)
+
+			_, err := parse(p, testPDF)
+			if tt.wantErr {
+				if parseErr := parseError(t, err, parser.KindCommand); !parseErr.Retryable() {
+					t.Error("command error not retryable by the worker")
+				}
+			} else if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := fake.Requests()[parser.EndpointPolicy]; got != tt.wantReqs {
+				t.Errorf("requests = %d, want %d", got, tt.wantReqs)
+			}
+		})
+	}
+}
+
+func TestPythonServiceCallTimeoutRetried(t *testing.T) {
+	p, fake := newService(t, parser.PythonServiceOptions{CallTimeout: 20 * time.Millisecond, MaxRetries: 1})
+	fake.Delay = 200 * time.Millisecond
+
+	_, err := parse(p, testPDF)
+	parseError(t, err, parser.KindCommand)
+	if got := fake.Requests()[parser.EndpointPolicy]; got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+}
+
+func TestPythonServiceCircuitBreaker(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+	p, fake := newService(t, parser.PythonServiceOptions{BreakerThreshold: 2, BreakerCooldown: cooldown})
+	requests := func() int { return fake.Requests()[parser.EndpointPolicy] }
+
+	// 连续失败达到阈值后打开，请求不再发往下游
+	fake.FailNext(2, http.StatusInternalServerError)
+	for i := 0; i < 2; i++ {
+		if _, err := parse(p, testPDF); errors.Is(err, parser.ErrCircuitOpen) {
+			t.Fatalf("attempt %d: breaker opened before reaching the threshold", i+1)
+		}
+	}
+	_, err := parse(p, testPDF)
+	parseError(t, err, parser.KindCommand)
+	if !errors.Is(err, parser.ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if got := requests(); got != 2 {
+		t.Fatalf("requests = %d, want 2 (open breaker must not call the service)", got)
+	}
+
+	// 半开试探失败：重新打开
+	time.Sleep(cooldown + 20*time.Millisecond)
+	fake.FailNext(1, http.StatusInternalServerError)
+	if _, err := parse(p, testPDF); err == nil || errors.Is(err, parser.ErrCircuitOpen) {
+		t.Fatalf("probe: err = %v, want the downstream failure", err)
+	}
+	if _, err := parse(p, testPDF); !errors.Is(err, parser.ErrCircuitOpen) {
+		t.Fatalf("after failed probe: err = %v, want ErrCircuitOpen", err)
+	}
+	if got := requests(); got != 3 {
+		t.Fatalf("requests = %d, want 3", got)
+	}
+
+	// 半开试探成功：关闭
+	time.Sleep(cooldown + 20*time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if _, err := parse(p, testPDF); err != nil {
+			t.Fatalf("after successful probe, attempt %d: %v", i+1, err)
+		}
+	}
+	if got := requests(); got != 5 {
+		t.Fatalf("requests = %d, want 5", got)
+	}
+}
+
+func TestPythonServiceRejectsInvalidResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"invalid json", `{"version": 1,`, "invalid json"},
+		{"unsupported version", `{"version": 2, "page_count": 1, "pages": []}`, "unsupported version 2"},
+		{"missing page_count", `{"version": 1, "pages": []}`, "page_count is required"},
+		{"negative page_count", `{"version": 1, "page_count": -1, "pages": []}`, "page_count is required"},
+		{"missing pages", `{"version": 1, "page_count": 1}`, "pages is required"},
+		{"page out of range", `{"version": 1, "page_count": 1, "pages": [{"page": 2, "paragraphs": []}]}`, "pages[0].page 2 out of order or range"},
+		{"page zero", `{"version": 1, "page_count": 1, "pages": [{"page": 0, "paragraphs": []}]}`, "pages[0].page 0 out of order or range"},
+		{"pages out of order", `{"version": 1, "page_count": 3, "pages": [{"page": 2, "paragraphs": []}, {"page": 1, "paragraphs": []}]}`, "pages[1].page 1 out of order or range"},
+		{"duplicate page", `{"version": 1, "page_count": 3, "pages": [{"page": 1, "paragraphs": []}, {"page": 1, "paragraphs": []}]}`, "pages[1].page 1 out of order or range"},
+		{"missing text", `{"version": 1, "page_count": 1, "pages": [{"page": 1, "paragraphs": [{"text": "a"}, {}]}]}`, "pages[0].paragraphs[1].text is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			p := parser.NewPythonService(parser.PythonServiceOptions{BaseURL: srv.URL, MaxRetries: 2, RetryDelay: time.Millisecond})
+
+			_, err := parse(p, testPDF)
+			parseError(t, err, parser.KindCommand)
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			if calls != 1 {
+				t.Errorf("calls = %d, want 1 (invalid responses are not retried in place)", calls)
+			}
+		})
+	}
+}
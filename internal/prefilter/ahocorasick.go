@@ -0,0 +1,122 @@
+package prefilter
+
+// automaton Aho–Corasick 多模式匹配自动机，按字节构建。
+// UTF-8 编码自同步，按字节匹配不会在多字节字符中间命中；ASCII 字母不区分大小写。
+// 纯 ASCII 模式（如 UA、BP）须整词命中，前后不能紧邻 ASCII 字母，避免在 USUAL 等单词中误命中。
+type automaton struct {
+	next []map[byte]int
+	fail []int
+	// out 在该状态结束的模式编号（含经失败链可达的后缀模式）
+	out [][]int
+	// size 各模式的字节长度，word 标记需要整词命中的纯 ASCII 模式
+	size []int
+	word []bool
+}
+
+// newAutomaton 构建自动机，模式编号为其在 patterns 中的下标，空模式忽略
+func newAutomaton(patterns []string) *automaton {
+	a := &automaton{
+		next: []map[byte]int{{}},
+		fail: []int{0},
+		out:  [][]int{nil},
+		size: make([]int, len(patterns)),
+		word: make([]bool, len(patterns)),
+	}
+	for i, p := range patterns {
+		if p == "" {
+			continue
+		}
+		a.size[i], a.word[i] = len(p), isASCII(p)
+		state := 0
+		for j := 0; j < len(p); j++ {
+			c := fold(p[j])
+			s, ok := a.next[state][c]
+			if !ok {
+				s = len(a.next)
+				a.next = append(a.next, map[byte]int{})
+				a.fail = append(a.fail, 0)
+				a.out = append(a.out, nil)
+				a.next[state][c] = s
+			}
+			state = s
+		}
+		a.out[state] = append(a.out[state], i)
+	}
+
+	// 按广度优先计算失败指针，并合并后缀状态的输出
+	queue := make([]int, 0, len(a.next))
+	for _, s := range a.next[0] {
+		queue = append(queue, s)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for c, s := range a.next[state] {
+			queue = append(queue, s)
+			f := a.fail[state]
+			for {
+				if t, ok := a.next[f][c]; ok && t != s {
+					a.fail[s] = t
+					break
+				}
+				if f == 0 {
+					break
+				}
+				f = a.fail[f]
+			}
+			a.out[s] = append(a.out[s], a.out[a.fail[s]]...)
+		}
+	}
+	return a
+}
+
+// scan 扫描文本，每命中一次模式调用一次 fn
+func (a *automaton) scan(text string, fn func(pattern int)) {
+	state := 0
+	for i := 0; i < len(text); i++ {
+		c := fold(text[i])
+		for {
+			if s, ok := a.next[state][c]; ok {
+				state = s
+				break
+			}
+			if state == 0 {
+				break
+			}
+			state = a.fail[state]
+		}
+		for _, p := range a.out[state] {
+			if a.word[p] && !wordBoundary(text, i+1-a.size[p], i+1) {
+				continue
+			}
+			fn(p)
+		}
+	}
+}
+
+// wordBoundary text[start:end] 前后是否都不是 ASCII 字母
+func wordBoundary(text string, start, end int) bool {
+	return (start == 0 || !isLetter(text[start-1])) && (end == len(text) || !isLetter(text[end]))
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetter(c byte) bool {
+	c = fold(c)
+	return 'a' <= c && c <= 'z'
+}
+
+// fold ASCII 大写字母转小写，其余字节不变
+func fold(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
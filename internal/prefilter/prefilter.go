@@ -0,0 +1,94 @@
+// Package prefilter 在调用 LLM 之前按主题关键词预筛体检报告段落：
+// 多模式匹配标记每个段落的候选主题，只有命中段落及其上下文送入健康事实抽取。
+package prefilter
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/zhenglizhi/policy-fit/internal/parser"
+)
+
+// Hit 命中关键词的段落
+type Hit struct {
+	// Index 段落在报告中的下标
+	Index int
+	// Topics 候选主题及命中的关键词，关键词按首次出现顺序排列
+	Topics map[string][]string
+}
+
+// TopicNames 命中的主题名，按字母序
+func (h Hit) TopicNames() []string {
+	names := make([]string, 0, len(h.Topics))
+	for name := range h.Topics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Filter 段落预筛器，创建后只读，可并发使用
+type Filter struct {
+	ac       *automaton
+	keywords []string
+	// topics 第 i 个关键词对应的主题
+	topics [][]string
+	window int
+}
+
+// New 创建预筛器。keywords 为关键词到主题名的映射（含同义词），
+// window 为命中段落前后各保留的上下文段落数。
+func New(keywords map[string][]string, window int) *Filter {
+	patterns := make([]string, 0, len(keywords))
+	for kw := range keywords {
+		patterns = append(patterns, kw)
+	}
+	sort.Strings(patterns)
+	topics := make([][]string, len(patterns))
+	for i, kw := range patterns {
+		topics[i] = keywords[kw]
+	}
+	return &Filter{ac: newAutomaton(patterns), keywords: patterns, topics: topics, window: window}
+}
+
+// Tag 标记命中关键词的段落，按段落顺序返回
+func (f *Filter) Tag(paras []parser.Paragraph) []Hit {
+	var hits []Hit
+	for i, p := range paras {
+		var hit *Hit
+		f.ac.scan(p.Text, func(pattern int) {
+			if hit == nil {
+				hit = &Hit{Index: i, Topics: make(map[string][]string)}
+			}
+			kw := f.keywords[pattern]
+			for _, topic := range f.topics[pattern] {
+				if !slices.Contains(hit.Topics[topic], kw) {
+					hit.Topics[topic] = append(hit.Topics[topic], kw)
+				}
+			}
+		})
+		if hit != nil {
+			hits = append(hits, *hit)
+		}
+	}
+	return hits
+}
+
+// Select 返回命中段落及其前后 window 段上下文，保持原顺序，不重复
+func (f *Filter) Select(paras []parser.Paragraph, hits []Hit) []parser.Paragraph {
+	keep := make([]bool, len(paras))
+	for _, h := range hits {
+		for i := h.Index - f.window; i <= h.Index+f.window; i++ {
+			if i >= 0 && i < len(paras) {
+				keep[i] = true
+			}
+		}
+	}
+	var selected []parser.Paragraph
+	for i, p := range paras {
+		if keep[i] {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
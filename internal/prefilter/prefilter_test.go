@@ -0,0 +1,123 @@
+package prefilter
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zhenglizhi/policy-fit/internal/parser"
+)
+
+// matches 返回 text 中命中的模式及次数
+func matches(patterns []string, text string) map[string]int {
+	got := make(map[string]int)
+	newAutomaton(patterns).scan(text, func(p int) {
+		got[patterns[p]]++
+	})
+	return got
+}
+
+// naive 逐个模式计数（不区分 ASCII 大小写、允许重叠，纯 ASCII 模式前后不能紧邻 ASCII 字母），作为自动机结果的参照
+func naive(patterns []string, text string) map[string]int {
+	want := make(map[string]int)
+	lower := strings.ToLower(text)
+	for _, p := range patterns {
+		if p == "" {
+			continue
+		}
+		lp := strings.ToLower(p)
+		for i := 0; i+len(lp) <= len(lower); i++ {
+			if lower[i:i+len(lp)] == lp && (!isASCII(p) || wordBoundary(lower, i, i+len(lp))) {
+				want[p]++
+			}
+		}
+	}
+	return want
+}
+
+func TestAutomaton(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		text     string
+	}{
+		{"classic overlaps", []string{"he", "she", "his", "hers"}, "ushers"},
+		{"suffix outputs via fail links", []string{"a", "ab", "bab", "bc", "bca", "c", "caa"}, "abccab"},
+		{"repeated overlapping", []string{"aa", "aaa"}, "aaaaa"},
+		{"chinese keywords", []string{"血压", "高血压", "血压偏高", "收缩压"}, "既往高血压，血压偏高，收缩压 152"},
+		{"shared prefixes", []string{"糖尿病", "糖化血红蛋白", "糖化"}, "糖化血红蛋白 6.8%，否认糖尿病"},
+		{"case insensitive ascii", []string{"HbA1c", "LDL", "ldl-c"}, "hba1c 7.1%, LDL-C 4.5, ldl 4.5"},
+		{"empty pattern ignored", []string{"", "BP"}, "BP 150/95"},
+		{"ascii word boundaries", []string{"UA", "BP", "TC", "HDL-C"}, "USUAL NIBP BP:150/95 tc6.5 ETC UA偏高 尿酸(UA) HDL-CH HDL-C"},
+		{"no match", []string{"肝", "肾"}, "血压正常"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matches(tt.patterns, tt.text)
+			if want := naive(tt.patterns, tt.text); !reflect.DeepEqual(got, want) {
+				t.Fatalf("matches = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestAutomatonWholeWordASCII(t *testing.T) {
+	got := matches([]string{"UA", "TG", "尿酸"}, "USUAL STG 血尿酸UA 420, TG: 2.5")
+	if want := map[string]int{"UA": 1, "TG": 1, "尿酸": 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("matches = %v, want %v", got, want)
+	}
+}
+
+func TestAutomatonNoPartialRune(t *testing.T) {
+	// "压" 为 E5 8E 8B，"厂" 为 E5 8E 82：共享前两个字节，不应在字符中间命中
+	if got := matches([]string{"压"}, "厂房"); len(got) != 0 {
+		t.Fatalf("matches = %v, want none", got)
+	}
+}
+
+func paragraphs(texts ...string) []parser.Paragraph {
+	paras := make([]parser.Paragraph, len(texts))
+	for i, text := range texts {
+		paras[i] = parser.Paragraph{ID: "para_" + strconv.Itoa(i+1), Page: 1, Text: text}
+	}
+	return paras
+}
+
+func TestFilter(t *testing.T) {
+	f := New(map[string][]string{
+		"血压":    {"hypertension"},
+		"高血压":   {"hypertension"},
+		"血糖":    {"diabetes"},
+		"HbA1c": {"diabetes"},
+		"肌酐":    {"renal", "hypertension"},
+	}, 1)
+	paras := paragraphs(
+		"一般检查",
+		"血压 152/95mmHg，既往高血压",
+		"视力正常",
+		"听力正常",
+		"肝功能正常",
+		"hba1c 6.8%，肌酐 110",
+		"结论",
+	)
+
+	hits := f.Tag(paras)
+	if len(hits) != 2 || hits[0].Index != 1 || hits[1].Index != 5 {
+		t.Fatalf("hits = %+v, want paragraphs 1 and 5", hits)
+	}
+	if got := hits[0].Topics["hypertension"]; !reflect.DeepEqual(got, []string{"血压", "高血压"}) {
+		t.Errorf("hypertension keywords = %v, want in order of first occurrence", got)
+	}
+	if got := hits[1].TopicNames(); !reflect.DeepEqual(got, []string{"diabetes", "hypertension", "renal"}) {
+		t.Errorf("TopicNames = %v", got)
+	}
+
+	var ids []string
+	for _, p := range f.Select(paras, hits) {
+		ids = append(ids, p.ID)
+	}
+	if want := []string{"para_1", "para_2", "para_3", "para_5", "para_6", "para_7"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Select = %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+// AuditRepository 审计日志数据访问
+type AuditRepository interface {
+	Create(ctx context.Context, entry *domain.AuditLog) error
+	ListByTask(ctx context.Context, taskID int64) ([]domain.AuditLog, error)
+}
+
+type auditRepository struct {
+	db DBTX
+}
+
+func (r *auditRepository) Create(ctx context.Context, entry *domain.AuditLog) error {
+	detail := entry.Detail
+	if detail == nil {
+		detail = map[string]interface{}{}
+	}
+	data, err := marshalJSON(detail)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit detail: %w", err)
+	}
+
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO audit_log (task_id, actor_id, action, target_type, target_id, detail)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id, created_at`,
+		nullInt64(entry.TaskID), nullInt64(entry.ActorID), entry.Action, entry.TargetType, nullString(entry.TargetID), data,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit log: %w", err)
+	}
+	return nil
+}
+
+func (r *auditRepository) ListByTask(ctx context.Context, taskID int64) ([]domain.AuditLog, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, task_id, actor_id, action, target_type, target_id, detail, created_at
+		 FROM audit_log WHERE task_id = $1 ORDER BY id`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.AuditLog
+	for rows.Next() {
+		var (
+			entry     domain.AuditLog
+			taskIDCol sql.NullInt64
+			actorID   sql.NullInt64
+			targetID  sql.NullString
+			detail    []byte
+		)
+		if err := rows.Scan(&entry.ID, &taskIDCol, &actorID, &entry.Action, &entry.TargetType, &targetID, &detail, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		entry.TaskID = taskIDCol.Int64
+		entry.ActorID = actorID.Int64
+		entry.TargetID = targetID.String
+		if err := unmarshalJSON(detail, &entry.Detail); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal audit detail: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
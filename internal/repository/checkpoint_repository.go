@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+// CheckpointRepository 流水线阶段检查点数据访问
+type CheckpointRepository interface {
+	// Save 写入阶段检查点，同一任务同一阶段重复写入时覆盖
+	Save(ctx context.Context, checkpoint *domain.TaskCheckpoint) error
+	Get(ctx context.Context, taskID int64, stage domain.TaskStatus) (*domain.TaskCheckpoint, error)
+}
+
+type checkpointRepository struct {
+	db DBTX
+}
+
+func (r *checkpointRepository) Save(ctx context.Context, checkpoint *domain.TaskCheckpoint) error {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO task_checkpoint (task_id, stage, data)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (task_id, stage) DO UPDATE SET data = EXCLUDED.data
+		 RETURNING created_at, updated_at`,
+		checkpoint.TaskID, checkpoint.Stage, []byte(checkpoint.Data),
+	).Scan(&checkpoint.CreatedAt, &checkpoint.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save task checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (r *checkpointRepository) Get(ctx context.Context, taskID int64, stage domain.TaskStatus) (*domain.TaskCheckpoint, error) {
+	var checkpoint domain.TaskCheckpoint
+	err := r.db.QueryRowContext(ctx,
+		`SELECT task_id, stage, data, created_at, updated_at
+		 FROM task_checkpoint
+		 WHERE task_id = $1 AND stage = $2`,
+		taskID, stage,
+	).Scan(
+		&checkpoint.TaskID,
+		&checkpoint.Stage,
+		&checkpoint.Data,
+		&checkpoint.CreatedAt,
+		&checkpoint.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+// DocumentRepository 文档数据访问
+type DocumentRepository interface {
+	Create(ctx context.Context, doc *domain.Document) error
+	Get(ctx context.Context, id int64) (*domain.Document, error)
+	ListByTask(ctx context.Context, taskID int64) ([]domain.Document, error)
+	// UpdateParseStatus 更新解析状态与解析结果，解析失败时 parsedText、parsedJSON 为空
+	UpdateParseStatus(ctx context.Context, id int64, status domain.ParseStatus, parsedText string, parsedJSON []byte) error
+}
+
+type documentRepository struct {
+	db DBTX
+}
+
+const documentColumns = `id, task_id, doc_type, file_name, storage_key, parse_status, parsed_text, parsed_json, created_at`
+
+func (r *documentRepository) Create(ctx context.Context, doc *domain.Document) error {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO document (task_id, doc_type, file_name, storage_key, parse_status, parsed_text)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id, created_at`,
+		doc.TaskID, doc.DocType, doc.FileName, doc.StorageKey, doc.ParseStatus, nullString(doc.ParsedText),
+	).Scan(&doc.ID, &doc.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert document: %w", err)
+	}
+	return nil
+}
+
+func (r *documentRepository) Get(ctx context.Context, id int64) (*domain.Document, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+documentColumns+` FROM document WHERE id = $1`, id)
+	doc, err := scanDocument(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return doc, err
+}
+
+func (r *documentRepository) ListByTask(ctx context.Context, taskID int64) ([]domain.Document, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+documentColumns+` FROM document WHERE task_id = $1 ORDER BY id`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []domain.Document
+	for rows.Next() {
+		doc, scanErr := scanDocument(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		docs = append(docs, *doc)
+	}
+	return docs, rows.Err()
+}
+
+func (r *documentRepository) UpdateParseStatus(ctx context.Context, id int64, status domain.ParseStatus, parsedText string, parsedJSON []byte) error {
+	var jsonArg interface{}
+	if len(parsedJSON) > 0 {
+		jsonArg = parsedJSON
+	}
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE document SET parse_status = $2, parsed_text = $3, parsed_json = $4 WHERE id = $1`,
+		id, status, nullString(parsedText), jsonArg,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update parse status: %w", err)
+	}
+	return expectAffected(result)
+}
+
+func scanDocument(row rowScanner) (*domain.Document, error) {
+	var (
+		doc        domain.Document
+		parsedText sql.NullString
+		parsedJSON []byte
+	)
+	err := row.Scan(
+		&doc.ID,
+		&doc.TaskID,
+		&doc.DocType,
+		&doc.FileName,
+		&doc.StorageKey,
+		&doc.ParseStatus,
+		&parsedText,
+		&parsedJSON,
+		&doc.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan document: %w", err)
+	}
+	doc.ParsedText = parsedText.String
+	if len(parsedJSON) > 0 {
+		doc.ParsedJSON = parsedJSON
+	}
+	return &doc, nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+// FindingRepository 风险发现数据访问
+type FindingRepository interface {
+	// BatchCreate 批量写入风险发现，并回填 ID 与创建时间
+	BatchCreate(ctx context.Context, findings []domain.RiskFinding) error
+	ListByTask(ctx context.Context, taskID int64) ([]domain.RiskFinding, error)
+	DeleteByTask(ctx context.Context, taskID int64) error
+}
+
+type findingRepository struct {
+	db DBTX
+}
+
+const findingColumns = `id, task_id, level, topic, summary, health_evidence, policy_evidence, questions, actions, confidence, downgrades, created_at`
+
+func (r *findingRepository) BatchCreate(ctx context.Context, findings []domain.RiskFinding) error {
+	for i := range findings {
+		f := &findings[i]
+
+		healthEvidence, err := marshalJSON(nonNilEvidence(f.HealthEvidence))
+		if err != nil {
+			return fmt.Errorf("failed to marshal health_evidence: %w", err)
+		}
+		policyEvidence, err := marshalJSON(nonNilEvidence(f.PolicyEvidence))
+		if err != nil {
+			return fmt.Errorf("failed to marshal policy_evidence: %w", err)
+		}
+		questions, err := marshalJSON(nonNilStrings(f.Questions))
+		if err != nil {
+			return fmt.Errorf("failed to marshal questions: %w", err)
+		}
+		var actions interface{}
+		if f.Actions != nil {
+			if actions, err = marshalJSON(f.Actions); err != nil {
+				return fmt.Errorf("failed to marshal actions: %w", err)
+			}
+		}
+		var downgrades interface{}
+		if len(f.Downgrades) > 0 {
+			if downgrades, err = marshalJSON(f.Downgrades); err != nil {
+				return fmt.Errorf("failed to marshal downgrades: %w", err)
+			}
+		}
+
+		err = r.db.QueryRowContext(ctx,
+			`INSERT INTO risk_finding
+			   (task_id, level, topic, summary, health_evidence, policy_evidence, questions, actions, confidence, downgrades)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			 RETURNING id, created_at`,
+			f.TaskID, f.Level, f.Topic, f.Summary, healthEvidence, policyEvidence, questions, actions, f.Confidence, downgrades,
+		).Scan(&f.ID, &f.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert finding (topic=%s): %w", f.Topic, err)
+		}
+	}
+	return nil
+}
+
+func (r *findingRepository) ListByTask(ctx context.Context, taskID int64) ([]domain.RiskFinding, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+findingColumns+` FROM risk_finding WHERE task_id = $1 ORDER BY id`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list findings: %w", err)
+	}
+	defer rows.Close()
+
+	var findings []domain.RiskFinding
+	for rows.Next() {
+		f, scanErr := scanFinding(rows)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		findings = append(findings, *f)
+	}
+	return findings, rows.Err()
+}
+
+func (r *findingRepository) DeleteByTask(ctx context.Context, taskID int64) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM risk_finding WHERE task_id = $1`, taskID); err != nil {
+		return fmt.Errorf("failed to delete findings: %w", err)
+	}
+	return nil
+}
+
+func scanFinding(row rowScanner) (*domain.RiskFinding, error) {
+	var (
+		f                              domain.RiskFinding
+		healthEvidence, policyEvidence []byte
+		questions, actions, downgrades []byte
+		confidence                     sql.NullFloat64
+	)
+	err := row.Scan(
+		&f.ID,
+		&f.TaskID,
+		&f.Level,
+		&f.Topic,
+		&f.Summary,
+		&healthEvidence,
+		&policyEvidence,
+		&questions,
+		&actions,
+		&confidence,
+		&downgrades,
+		&f.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan finding: %w", err)
+	}
+
+	if err := unmarshalJSON(healthEvidence, &f.HealthEvidence); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal health_evidence: %w", err)
+	}
+	if err := unmarshalJSON(policyEvidence, &f.PolicyEvidence); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal policy_evidence: %w", err)
+	}
+	if err := unmarshalJSON(questions, &f.Questions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal questions: %w", err)
+	}
+	if err := unmarshalJSON(actions, &f.Actions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal actions: %w", err)
+	}
+	if err := unmarshalJSON(downgrades, &f.Downgrades); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal downgrades: %w", err)
+	}
+	f.Confidence = confidence.Float64
+	return &f, nil
+}
+
+// nonNilEvidence 保证 NOT NULL 的 JSONB 列写入 [] 而不是 NULL
+func nonNilEvidence(items []domain.Evidence) []domain.Evidence {
+	if items == nil {
+		return []domain.Evidence{}
+	}
+	return items
+}
+
+func nonNilStrings(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+// IdempotencyRepository 幂等记录数据访问
+type IdempotencyRepository interface {
+	Get(ctx context.Context, userID int64, scope, key string) (*domain.IdempotencyRecord, error)
+	// Create 写入幂等记录，同一 (user_id, scope, key) 已存在时返回 ErrDuplicate
+	Create(ctx context.Context, record *domain.IdempotencyRecord) error
+}
+
+type idempotencyRepository struct {
+	db DBTX
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, userID int64, scope, key string) (*domain.IdempotencyRecord, error) {
+	var (
+		record domain.IdempotencyRecord
+		taskID sql.NullInt64
+	)
+	err := r.db.QueryRowContext(ctx,
+		`SELECT user_id, scope, idem_key, request_hash, task_id, response, created_at
+		 FROM idempotency_record
+		 WHERE user_id = $1 AND scope = $2 AND idem_key = $3`,
+		userID, scope, key,
+	).Scan(
+		&record.UserID,
+		&record.Scope,
+		&record.Key,
+		&record.RequestHash,
+		&taskID,
+		&record.Response,
+		&record.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+	record.TaskID = taskID.Int64
+	return &record, nil
+}
+
+func (r *idempotencyRepository) Create(ctx context.Context, record *domain.IdempotencyRecord) error {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO idempotency_record (user_id, scope, idem_key, request_hash, task_id, response)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING created_at`,
+		record.UserID, record.Scope, record.Key, record.RequestHash, nullInt64(record.TaskID), []byte(record.Response),
+	).Scan(&record.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert idempotency record: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,578 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+// MemoryStore 内存实现的 Store，供 handler/service 测试使用。
+// 事务通过快照实现：fn 成功后整体替换状态，失败则丢弃快照。
+type MemoryStore struct {
+	mu    *sync.Mutex
+	txMu  *sync.Mutex
+	state *memoryState
+	inTx  bool
+}
+
+type memoryState struct {
+	nextID      int64
+	users       map[int64]domain.User
+	tasks       map[int64]domain.AnalysisTask
+	documents   map[int64]domain.Document
+	findings    map[int64]domain.RiskFinding
+	audits      []domain.AuditLog
+	idem        map[string]domain.IdempotencyRecord
+	checkpoints map[string]domain.TaskCheckpoint
+}
+
+// NewMemoryStore 创建内存 Store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		mu:   &sync.Mutex{},
+		txMu: &sync.Mutex{},
+		state: &memoryState{
+			users:       make(map[int64]domain.User),
+			tasks:       make(map[int64]domain.AnalysisTask),
+			documents:   make(map[int64]domain.Document),
+			findings:    make(map[int64]domain.RiskFinding),
+			idem:        make(map[string]domain.IdempotencyRecord),
+			checkpoints: make(map[string]domain.TaskCheckpoint),
+		},
+	}
+}
+
+func (s *MemoryStore) Users() UserRepository {
+	return &memoryUserRepository{s: s}
+}
+
+func (s *MemoryStore) Tasks() TaskRepository {
+	return &memoryTaskRepository{s: s}
+}
+
+func (s *MemoryStore) Documents() DocumentRepository {
+	return &memoryDocumentRepository{s: s}
+}
+
+func (s *MemoryStore) Findings() FindingRepository {
+	return &memoryFindingRepository{s: s}
+}
+
+func (s *MemoryStore) Audits() AuditRepository {
+	return &memoryAuditRepository{s: s}
+}
+
+func (s *MemoryStore) Idempotency() IdempotencyRepository {
+	return &memoryIdempotencyRepository{s: s}
+}
+
+func (s *MemoryStore) Checkpoints() CheckpointRepository {
+	return &memoryCheckpointRepository{s: s}
+}
+
+func (s *MemoryStore) WithTx(ctx context.Context, fn func(Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	snapshot := s.state.clone()
+	s.mu.Unlock()
+
+	tx := &MemoryStore{mu: &sync.Mutex{}, txMu: s.txMu, state: snapshot, inTx: true}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.state = snapshot
+	s.mu.Unlock()
+	return nil
+}
+
+func (st *memoryState) clone() *memoryState {
+	c := &memoryState{
+		nextID:      st.nextID,
+		users:       make(map[int64]domain.User, len(st.users)),
+		tasks:       make(map[int64]domain.AnalysisTask, len(st.tasks)),
+		documents:   make(map[int64]domain.Document, len(st.documents)),
+		findings:    make(map[int64]domain.RiskFinding, len(st.findings)),
+		audits:      append([]domain.AuditLog(nil), st.audits...),
+		idem:        make(map[string]domain.IdempotencyRecord, len(st.idem)),
+		checkpoints: make(map[string]domain.TaskCheckpoint, len(st.checkpoints)),
+	}
+	for id, u := range st.users {
+		c.users[id] = u
+	}
+	for id, t := range st.tasks {
+		c.tasks[id] = t
+	}
+	for id, d := range st.documents {
+		c.documents[id] = d
+	}
+	for id, f := range st.findings {
+		c.findings[id] = f
+	}
+	for k, rec := range st.idem {
+		c.idem[k] = rec
+	}
+	for k, cp := range st.checkpoints {
+		c.checkpoints[k] = cp
+	}
+	return c
+}
+
+func (st *memoryState) newID() int64 {
+	st.nextID++
+	return st.nextID
+}
+
+type memoryUserRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryUserRepository) UpsertByPhone(ctx context.Context, phone string) (*domain.User, bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, u := range r.s.state.users {
+		if u.Phone == phone {
+			return &u, false, nil
+		}
+	}
+	u := domain.User{ID: r.s.state.newID(), Phone: phone, CreatedAt: time.Now()}
+	r.s.state.users[u.ID] = u
+	return &u, true, nil
+}
+
+type memoryTaskRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryTaskRepository) Create(ctx context.Context, task *domain.AnalysisTask) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if task.RequestID != "" {
+		for _, existing := range r.s.state.tasks {
+			if existing.UserID == task.UserID && existing.RequestID == task.RequestID {
+				return ErrDuplicate
+			}
+		}
+	}
+
+	now := time.Now()
+	task.ID = r.s.state.newID()
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	r.s.state.tasks[task.ID] = copyTask(*task)
+	return nil
+}
+
+func (r *memoryTaskRepository) Get(ctx context.Context, id int64) (*domain.AnalysisTask, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	task, ok := r.s.state.tasks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	task = copyTask(task)
+	return &task, nil
+}
+
+func (r *memoryTaskRepository) GetForUser(ctx context.Context, id, userID int64) (*domain.AnalysisTask, error) {
+	task, err := r.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if task.UserID != userID {
+		return nil, ErrNotFound
+	}
+	return task, nil
+}
+
+func (r *memoryTaskRepository) GetByRequestID(ctx context.Context, userID int64, requestID string) (*domain.AnalysisTask, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, task := range r.s.state.tasks {
+		if task.UserID == userID && task.RequestID == requestID {
+			task = copyTask(task)
+			return &task, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryTaskRepository) UpdateStatus(ctx context.Context, id int64, from, to domain.TaskStatus) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	task, ok := r.s.state.tasks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if task.Status != from {
+		return ErrStatusConflict
+	}
+	task.Status = to
+	task.UpdatedAt = time.Now()
+	r.s.state.tasks[id] = task
+	return nil
+}
+
+func (r *memoryTaskRepository) UpdateFailure(ctx context.Context, id int64, from domain.TaskStatus, failureCode string, retryCount int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	task, ok := r.s.state.tasks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if task.Status != from {
+		return ErrStatusConflict
+	}
+	task.Status = domain.TaskStatusFailed
+	task.FailureCode = failureCode
+	task.RetryCount = retryCount
+	task.UpdatedAt = time.Now()
+	r.s.state.tasks[id] = task
+	return nil
+}
+
+func (r *memoryTaskRepository) ResetForRerun(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	task, ok := r.s.state.tasks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if task.Status != domain.TaskStatusFailed {
+		return ErrStatusConflict
+	}
+	task.Status = domain.TaskStatusPending
+	task.FailureCode = ""
+	task.RetryCount = 0
+	task.UpdatedAt = time.Now()
+	r.s.state.tasks[id] = task
+	return nil
+}
+
+func (r *memoryTaskRepository) UpdateRetryCount(ctx context.Context, id int64, status domain.TaskStatus, retryCount int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	task, ok := r.s.state.tasks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if task.Status != status {
+		return ErrStatusConflict
+	}
+	task.RetryCount = retryCount
+	task.UpdatedAt = time.Now()
+	r.s.state.tasks[id] = task
+	return nil
+}
+
+func (r *memoryTaskRepository) UpdateReport(ctx context.Context, id int64, summary map[string]int, ruleVersion, promptVersion string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	task, ok := r.s.state.tasks[id]
+	if !ok {
+		return ErrNotFound
+	}
+	now := time.Now()
+	task.RiskSummary = copySummary(summary)
+	task.RuleVersion = ruleVersion
+	task.PromptVersion = promptVersion
+	task.ReportedAt = &now
+	task.UpdatedAt = now
+	r.s.state.tasks[id] = task
+	return nil
+}
+
+func (r *memoryTaskRepository) Delete(ctx context.Context, id, userID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if task, ok := r.s.state.tasks[id]; !ok || task.UserID != userID {
+		return ErrNotFound
+	}
+	delete(r.s.state.tasks, id)
+
+	// 与外键 ON DELETE CASCADE / SET NULL 行为保持一致
+	for docID, doc := range r.s.state.documents {
+		if doc.TaskID == id {
+			delete(r.s.state.documents, docID)
+		}
+	}
+	for findingID, f := range r.s.state.findings {
+		if f.TaskID == id {
+			delete(r.s.state.findings, findingID)
+		}
+	}
+	for i := range r.s.state.audits {
+		if r.s.state.audits[i].TaskID == id {
+			r.s.state.audits[i].TaskID = 0
+		}
+	}
+	for k, rec := range r.s.state.idem {
+		if rec.TaskID == id {
+			delete(r.s.state.idem, k)
+		}
+	}
+	for k, cp := range r.s.state.checkpoints {
+		if cp.TaskID == id {
+			delete(r.s.state.checkpoints, k)
+		}
+	}
+	return nil
+}
+
+type memoryDocumentRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryDocumentRepository) Create(ctx context.Context, doc *domain.Document) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.state.tasks[doc.TaskID]; !ok {
+		return ErrNotFound
+	}
+	doc.ID = r.s.state.newID()
+	doc.CreatedAt = time.Now()
+	r.s.state.documents[doc.ID] = *doc
+	return nil
+}
+
+func (r *memoryDocumentRepository) Get(ctx context.Context, id int64) (*domain.Document, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	doc, ok := r.s.state.documents[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &doc, nil
+}
+
+func (r *memoryDocumentRepository) ListByTask(ctx context.Context, taskID int64) ([]domain.Document, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var docs []domain.Document
+	for _, doc := range r.s.state.documents {
+		if doc.TaskID == taskID {
+			docs = append(docs, doc)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	return docs, nil
+}
+
+func (r *memoryDocumentRepository) UpdateParseStatus(ctx context.Context, id int64, status domain.ParseStatus, parsedText string, parsedJSON []byte) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	doc, ok := r.s.state.documents[id]
+	if !ok {
+		return ErrNotFound
+	}
+	doc.ParseStatus = status
+	doc.ParsedText = parsedText
+	doc.ParsedJSON = nil
+	if len(parsedJSON) > 0 {
+		doc.ParsedJSON = append([]byte(nil), parsedJSON...)
+	}
+	r.s.state.documents[id] = doc
+	return nil
+}
+
+type memoryFindingRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryFindingRepository) BatchCreate(ctx context.Context, findings []domain.RiskFinding) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for i := range findings {
+		if _, ok := r.s.state.tasks[findings[i].TaskID]; !ok {
+			return ErrNotFound
+		}
+	}
+	now := time.Now()
+	for i := range findings {
+		findings[i].ID = r.s.state.newID()
+		findings[i].CreatedAt = now
+		r.s.state.findings[findings[i].ID] = copyFinding(findings[i])
+	}
+	return nil
+}
+
+func (r *memoryFindingRepository) ListByTask(ctx context.Context, taskID int64) ([]domain.RiskFinding, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var findings []domain.RiskFinding
+	for _, f := range r.s.state.findings {
+		if f.TaskID == taskID {
+			findings = append(findings, copyFinding(f))
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].ID < findings[j].ID })
+	return findings, nil
+}
+
+func (r *memoryFindingRepository) DeleteByTask(ctx context.Context, taskID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for id, f := range r.s.state.findings {
+		if f.TaskID == taskID {
+			delete(r.s.state.findings, id)
+		}
+	}
+	return nil
+}
+
+type memoryAuditRepository struct {
+	s *MemoryStore
+}
+
+func (r *memoryAuditRepository) Create(ctx context.Context, entry *domain.AuditLog) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	entry.ID = r.s.state.newID()
+	entry.CreatedAt = time.Now()
+	r.s.state.audits = append(r.s.state.audits, *entry)
+	return nil
+}
+
+func (r *memoryAuditRepository) ListByTask(ctx context.Context, taskID int64) ([]domain.AuditLog, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var entries []domain.AuditLog
+	for _, entry := range r.s.state.audits {
+		if entry.TaskID == taskID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+type memoryIdempotencyRepository struct {
+	s *MemoryStore
+}
+
+func idempotencyMapKey(userID int64, scope, key string) string {
+	return fmt.Sprintf("%d/%s/%s", userID, scope, key)
+}
+
+func (r *memoryIdempotencyRepository) Get(ctx context.Context, userID int64, scope, key string) (*domain.IdempotencyRecord, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	record, ok := r.s.state.idem[idempotencyMapKey(userID, scope, key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &record, nil
+}
+
+func (r *memoryIdempotencyRepository) Create(ctx context.Context, record *domain.IdempotencyRecord) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	mapKey := idempotencyMapKey(record.UserID, record.Scope, record.Key)
+	if _, ok := r.s.state.idem[mapKey]; ok {
+		return ErrDuplicate
+	}
+	record.CreatedAt = time.Now()
+	r.s.state.idem[mapKey] = *record
+	return nil
+}
+
+type memoryCheckpointRepository struct {
+	s *MemoryStore
+}
+
+func checkpointMapKey(taskID int64, stage domain.TaskStatus) string {
+	return fmt.Sprintf("%d/%s", taskID, stage)
+}
+
+func (r *memoryCheckpointRepository) Save(ctx context.Context, checkpoint *domain.TaskCheckpoint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.state.tasks[checkpoint.TaskID]; !ok {
+		return fmt.Errorf("failed to save task checkpoint: task %d not found", checkpoint.TaskID)
+	}
+	mapKey := checkpointMapKey(checkpoint.TaskID, checkpoint.Stage)
+	now := time.Now()
+	checkpoint.CreatedAt = now
+	if existing, ok := r.s.state.checkpoints[mapKey]; ok {
+		checkpoint.CreatedAt = existing.CreatedAt
+	}
+	checkpoint.UpdatedAt = now
+
+	saved := *checkpoint
+	saved.Data = append([]byte(nil), checkpoint.Data...)
+	r.s.state.checkpoints[mapKey] = saved
+	return nil
+}
+
+func (r *memoryCheckpointRepository) Get(ctx context.Context, taskID int64, stage domain.TaskStatus) (*domain.TaskCheckpoint, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	checkpoint, ok := r.s.state.checkpoints[checkpointMapKey(taskID, stage)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	checkpoint.Data = append([]byte(nil), checkpoint.Data...)
+	return &checkpoint, nil
+}
+
+func copyTask(t domain.AnalysisTask) domain.AnalysisTask {
+	t.RiskSummary = copySummary(t.RiskSummary)
+	if t.ReportedAt != nil {
+		reportedAt := *t.ReportedAt
+		t.ReportedAt = &reportedAt
+	}
+	return t
+}
+
+func copySummary(summary map[string]int) map[string]int {
+	if summary == nil {
+		return nil
+	}
+	c := make(map[string]int, len(summary))
+	for k, v := range summary {
+		c[k] = v
+	}
+	return c
+}
+
+func copyFinding(f domain.RiskFinding) domain.RiskFinding {
+	f.HealthEvidence = append([]domain.Evidence(nil), f.HealthEvidence...)
+	f.PolicyEvidence = append([]domain.Evidence(nil), f.PolicyEvidence...)
+	f.Questions = append([]string(nil), f.Questions...)
+	f.Actions = append([]string(nil), f.Actions...)
+	f.Downgrades = append([]domain.Downgrade(nil), f.Downgrades...)
+	return f
+}
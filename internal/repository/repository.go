@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
+	"github.com/zhenglizhi/policy-fit/internal/config"
+)
+
+var (
+	// ErrNotFound 记录不存在
+	ErrNotFound = errors.New("record not found")
+	// ErrStatusConflict 状态已被其他请求修改
+	ErrStatusConflict = errors.New("status conflict")
+	// ErrDuplicate 违反唯一约束
+	ErrDuplicate = errors.New("duplicate record")
+)
+
+// pgUniqueViolation PostgreSQL 唯一约束冲突错误码
+const pgUniqueViolation = "23505"
+
+// DBTX 同时由 *sql.DB 与 *sql.Tx 实现，使 repository 可在事务内外复用
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Store 聚合所有 repository，并提供跨 repository 的事务
+type Store interface {
+	Users() UserRepository
+	Tasks() TaskRepository
+	Documents() DocumentRepository
+	Findings() FindingRepository
+	Audits() AuditRepository
+	Idempotency() IdempotencyRepository
+	Checkpoints() CheckpointRepository
+
+	// WithTx 在同一事务中执行 fn，fn 返回错误时整体回滚
+	WithTx(ctx context.Context, fn func(Store) error) error
+}
+
+// OpenPostgres 按配置建立 PostgreSQL 连接
+func OpenPostgres(cfg config.DatabaseConfig) (*sql.DB, error) {
+	dsn := fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host,
+		cfg.Port,
+		cfg.User,
+		cfg.Password,
+		cfg.DBName,
+		cfg.SSLMode,
+	)
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return db, nil
+}
+
+// OpenRedis 按配置建立 Redis 连接
+func OpenRedis(ctx context.Context, cfg config.RedisConfig) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return client, nil
+}
+
+type postgresStore struct {
+	db   *sql.DB
+	conn DBTX
+}
+
+// NewPostgresStore 创建基于 PostgreSQL 的 Store
+func NewPostgresStore(db *sql.DB) Store {
+	return &postgresStore{db: db, conn: db}
+}
+
+func (s *postgresStore) Users() UserRepository {
+	return &userRepository{db: s.conn}
+}
+
+func (s *postgresStore) Tasks() TaskRepository {
+	return &taskRepository{db: s.conn}
+}
+
+func (s *postgresStore) Documents() DocumentRepository {
+	return &documentRepository{db: s.conn}
+}
+
+func (s *postgresStore) Findings() FindingRepository {
+	return &findingRepository{db: s.conn}
+}
+
+func (s *postgresStore) Audits() AuditRepository {
+	return &auditRepository{db: s.conn}
+}
+
+func (s *postgresStore) Idempotency() IdempotencyRepository {
+	return &idempotencyRepository{db: s.conn}
+}
+
+func (s *postgresStore) Checkpoints() CheckpointRepository {
+	return &checkpointRepository{db: s.conn}
+}
+
+func (s *postgresStore) WithTx(ctx context.Context, fn func(Store) error) error {
+	// 已处于事务中时直接复用，避免嵌套事务
+	if _, ok := s.conn.(*sql.Tx); ok {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := fn(&postgresStore{db: s.db, conn: tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isUniqueViolation 判断是否为唯一约束冲突
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pgUniqueViolation
+}
+
+// marshalJSON 将值编码为 JSONB 参数，nil 写入 NULL
+func marshalJSON(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// unmarshalJSON 解码 JSONB 列，NULL 保持零值
+func unmarshalJSON(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// nullInt64 将 0 视为 NULL，用于可空外键
+func nullInt64(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
+
+func nullString(v string) sql.NullString {
+	return sql.NullString{String: v, Valid: v != ""}
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+// TaskRepository 分析任务数据访问
+type TaskRepository interface {
+	Create(ctx context.Context, task *domain.AnalysisTask) error
+	Get(ctx context.Context, id int64) (*domain.AnalysisTask, error)
+	// GetForUser 查询属于 userID 的任务，任务不存在或属于其他用户时均返回 ErrNotFound
+	GetForUser(ctx context.Context, id, userID int64) (*domain.AnalysisTask, error)
+	// GetByRequestID 按幂等键查询 userID 的任务，幂等键按用户隔离
+	GetByRequestID(ctx context.Context, userID int64, requestID string) (*domain.AnalysisTask, error)
+	// UpdateStatus 仅当当前状态为 from 时更新为 to，否则返回 ErrStatusConflict
+	UpdateStatus(ctx context.Context, id int64, from, to domain.TaskStatus) error
+	// UpdateFailure 将任务从 from 置为 failed，并记录失败码与重试次数
+	UpdateFailure(ctx context.Context, id int64, from domain.TaskStatus, failureCode string, retryCount int) error
+	// ResetForRerun 将失败任务置回 pending 并清除失败码与重试次数，任务不是 failed 时返回 ErrStatusConflict
+	ResetForRerun(ctx context.Context, id int64) error
+	// UpdateRetryCount 在任务仍处于 status 时更新重试次数，状态不变
+	UpdateRetryCount(ctx context.Context, id int64, status domain.TaskStatus, retryCount int) error
+	// UpdateReport 写入风险摘要与报告版本，报告生成时间取当前时间
+	UpdateReport(ctx context.Context, id int64, summary map[string]int, ruleVersion, promptVersion string) error
+	// Delete 删除属于 userID 的任务，任务不存在或属于其他用户时返回 ErrNotFound
+	Delete(ctx context.Context, id, userID int64) error
+}
+
+type taskRepository struct {
+	db DBTX
+}
+
+const taskColumns = `id, user_id, request_id, status, risk_summary, failure_code, retry_count, rule_version, prompt_version, reported_at, created_at, updated_at`
+
+func (r *taskRepository) Create(ctx context.Context, task *domain.AnalysisTask) error {
+	summary, err := marshalJSON(task.RiskSummary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal risk_summary: %w", err)
+	}
+
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO analysis_task (user_id, request_id, status, risk_summary)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at, updated_at`,
+		task.UserID, nullString(task.RequestID), task.Status, summary,
+	).Scan(&task.ID, &task.CreatedAt, &task.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert task: %w", err)
+	}
+	return nil
+}
+
+func (r *taskRepository) Get(ctx context.Context, id int64) (*domain.AnalysisTask, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+taskColumns+` FROM analysis_task WHERE id = $1`, id)
+	return scanTask(row)
+}
+
+func (r *taskRepository) GetForUser(ctx context.Context, id, userID int64) (*domain.AnalysisTask, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+taskColumns+` FROM analysis_task WHERE id = $1 AND user_id = $2`, id, userID)
+	return scanTask(row)
+}
+
+func (r *taskRepository) GetByRequestID(ctx context.Context, userID int64, requestID string) (*domain.AnalysisTask, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+taskColumns+` FROM analysis_task WHERE user_id = $1 AND request_id = $2`, userID, requestID)
+	return scanTask(row)
+}
+
+func (r *taskRepository) UpdateStatus(ctx context.Context, id int64, from, to domain.TaskStatus) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE analysis_task SET status = $3 WHERE id = $1 AND status = $2`,
+		id, from, to,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task status: %w", err)
+	}
+	return r.expectStatusUpdated(ctx, id, result)
+}
+
+func (r *taskRepository) UpdateFailure(ctx context.Context, id int64, from domain.TaskStatus, failureCode string, retryCount int) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE analysis_task SET status = $3, failure_code = $4, retry_count = $5
+		 WHERE id = $1 AND status = $2`,
+		id, from, domain.TaskStatusFailed, nullString(failureCode), retryCount,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task failure: %w", err)
+	}
+	return r.expectStatusUpdated(ctx, id, result)
+}
+
+func (r *taskRepository) ResetForRerun(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE analysis_task SET status = $3, failure_code = NULL, retry_count = 0
+		 WHERE id = $1 AND status = $2`,
+		id, domain.TaskStatusFailed, domain.TaskStatusPending,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reset task for rerun: %w", err)
+	}
+	return r.expectStatusUpdated(ctx, id, result)
+}
+
+func (r *taskRepository) UpdateRetryCount(ctx context.Context, id int64, status domain.TaskStatus, retryCount int) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE analysis_task SET retry_count = $3 WHERE id = $1 AND status = $2`,
+		id, status, retryCount,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task retry count: %w", err)
+	}
+	return r.expectStatusUpdated(ctx, id, result)
+}
+
+// expectStatusUpdated 区分任务不存在与状态已被并发修改两种情况
+func (r *taskRepository) expectStatusUpdated(ctx context.Context, id int64, result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		if _, getErr := r.Get(ctx, id); getErr != nil {
+			return getErr
+		}
+		return ErrStatusConflict
+	}
+	return nil
+}
+
+func (r *taskRepository) UpdateReport(ctx context.Context, id int64, summary map[string]int, ruleVersion, promptVersion string) error {
+	data, err := marshalJSON(summary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal risk_summary: %w", err)
+	}
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE analysis_task
+		 SET risk_summary = $2, rule_version = $3, prompt_version = $4, reported_at = NOW()
+		 WHERE id = $1`,
+		id, data, nullString(ruleVersion), nullString(promptVersion),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update task report: %w", err)
+	}
+	return expectAffected(result)
+}
+
+func (r *taskRepository) Delete(ctx context.Context, id, userID int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM analysis_task WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	return expectAffected(result)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTask(row rowScanner) (*domain.AnalysisTask, error) {
+	var (
+		task          domain.AnalysisTask
+		requestID     sql.NullString
+		summary       []byte
+		failureCode   sql.NullString
+		ruleVersion   sql.NullString
+		promptVersion sql.NullString
+		reportedAt    sql.NullTime
+	)
+	err := row.Scan(
+		&task.ID,
+		&task.UserID,
+		&requestID,
+		&task.Status,
+		&summary,
+		&failureCode,
+		&task.RetryCount,
+		&ruleVersion,
+		&promptVersion,
+		&reportedAt,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan task: %w", err)
+	}
+	task.RequestID = requestID.String
+	task.FailureCode = failureCode.String
+	task.RuleVersion = ruleVersion.String
+	task.PromptVersion = promptVersion.String
+	if reportedAt.Valid {
+		task.ReportedAt = &reportedAt.Time
+	}
+	if err := unmarshalJSON(summary, &task.RiskSummary); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal risk_summary: %w", err)
+	}
+	return &task, nil
+}
+
+func expectAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
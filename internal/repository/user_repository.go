@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+// UserRepository 用户账号数据访问
+type UserRepository interface {
+	// UpsertByPhone 按手机号查找用户，不存在时创建；created 表示本次新建
+	UpsertByPhone(ctx context.Context, phone string) (user *domain.User, created bool, err error)
+}
+
+type userRepository struct {
+	db DBTX
+}
+
+func (r *userRepository) UpsertByPhone(ctx context.Context, phone string) (*domain.User, bool, error) {
+	var user domain.User
+	var created bool
+	// 冲突时做一次空更新以便 RETURNING 返回已有行；xmax = 0 表示本次插入
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO user_account (phone) VALUES ($1)
+		 ON CONFLICT (phone) DO UPDATE SET phone = EXCLUDED.phone
+		 RETURNING id, phone, created_at, (xmax = 0)`,
+		phone,
+	).Scan(&user.ID, &user.Phone, &user.CreatedAt, &created)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to upsert user: %w", err)
+	}
+	return &user, created, nil
+}
@@ -0,0 +1,107 @@
+package ruleengine
+
+import (
+	"strings"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/extract"
+)
+
+// 条件表达式可用的健康事实属性
+const (
+	AttrDiagnosed          = "diagnosed"
+	AttrDiagnosisUnclear   = "diagnosis_unclear"
+	AttrLongTermMedication = "long_term_medication"
+	AttrDateMissing        = "date_missing"
+	AttrAbnormalIndex      = "abnormal_index"
+	AttrBorderlineValues   = "borderline_values"
+	AttrConfidence         = "confidence"
+
+	// valuesPrefix 检测值属性前缀，values.sbp 对应 HealthFact.Measurements["sbp"]
+	valuesPrefix = "values"
+	// thresholdsPrefix 主题阈值常量前缀，thresholds.dbp_abnormal 对应主题 thresholds 配置
+	thresholdsPrefix = "thresholds"
+)
+
+// factAttrs 属性类型，用于编译期检查
+var factAttrs = map[string]Kind{
+	AttrDiagnosed:          KindBool,
+	AttrDiagnosisUnclear:   KindBool,
+	AttrLongTermMedication: KindBool,
+	AttrDateMissing:        KindBool,
+	AttrAbnormalIndex:      KindBool,
+	AttrBorderlineValues:   KindBool,
+	AttrConfidence:         KindNumber,
+}
+
+// topicScope 主题条件的编译作用域：事实属性、values.* 检测值与本主题的 thresholds.* 常量
+func topicScope(thresholds map[string]float64) Scope {
+	consts := make(map[string]float64, len(thresholds))
+	for name, v := range thresholds {
+		consts[thresholdsPrefix+"."+name] = v
+	}
+	return Scope{
+		Attrs:    factAttrs,
+		Prefixes: map[string]Kind{valuesPrefix: KindNumber},
+		Consts:   consts,
+	}
+}
+
+// factEnv 健康事实的属性取值：
+//   - diagnosed、long_term_medication：抽取结果为 unknown 时为未知值
+//   - diagnosis_unclear：未明确诊断（diagnosed 为 false 或 unknown）
+//   - date_missing：检查日期缺失或为 unknown
+//   - abnormal_index：任一检测值超出参考范围（偏高或偏低）
+//   - borderline_values：任一检测值处于临界区间
+//   - 以上两项在没有可判定的检测值时为未知值
+//   - confidence：抽取置信度
+//   - values.*：归一化后的标准单位检测值，未归一化时取 Values 中的数值，缺失时为未知值
+func factEnv(f *domain.HealthFact) Env {
+	return func(name string) Value {
+		switch name {
+		case AttrDiagnosed:
+			return OptionalBool(f.Diagnosed)
+		case AttrDiagnosisUnclear:
+			return Bool(f.Diagnosed == nil || !*f.Diagnosed)
+		case AttrLongTermMedication:
+			return OptionalBool(f.LongTermMedication)
+		case AttrDateMissing:
+			return Bool(f.Evidence.Date == "" || f.Evidence.Date == extract.Unknown)
+		case AttrAbnormalIndex:
+			return anyMeasurement(f, domain.Measurement.OutOfRange)
+		case AttrBorderlineValues:
+			return anyMeasurement(f, func(m domain.Measurement) bool {
+				return m.Flag == domain.MeasurementBorderline
+			})
+		case AttrConfidence:
+			return Number(f.Confidence)
+		}
+		if key, ok := strings.CutPrefix(name, valuesPrefix+"."); ok {
+			if m, ok := f.Measurements[key]; ok {
+				return Number(m.Value)
+			}
+			if n, ok := f.Values[key].(float64); ok {
+				return Number(n)
+			}
+		}
+		return Unknown
+	}
+}
+
+// anyMeasurement 任一已判定的检测值满足 pred 时为真，没有已判定的检测值时为未知值
+func anyMeasurement(f *domain.HealthFact, pred func(domain.Measurement) bool) Value {
+	assessed := false
+	for _, m := range f.Measurements {
+		if m.Flag == "" {
+			continue
+		}
+		if pred(m) {
+			return Bool(true)
+		}
+		assessed = true
+	}
+	if !assessed {
+		return Unknown
+	}
+	return Bool(false)
+}
@@ -0,0 +1,109 @@
+// Package ruleengine 加载 configs/topics.yaml，将健康事实按主题归类，
+// 与映射的条款类型匹配，并按配置的红/黄条件评定风险等级。
+package ruleengine
+
+import (
+	"slices"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+// Engine 规则引擎，加载后只读，可并发使用
+type Engine struct {
+	version    string
+	topics     []*Topic
+	guardrails *Guardrails
+}
+
+// Version 规则集版本：配置中的 version 加规则文件内容摘要，如 "2026.10.1+3f2a9c1b"
+func (e *Engine) Version() string {
+	return e.version
+}
+
+// Topic 按名称查找主题
+func (e *Engine) Topic(name string) (*Topic, bool) {
+	for _, t := range e.topics {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// Topics 已加载的主题，按配置顺序
+func (e *Engine) Topics() []*Topic {
+	return e.topics
+}
+
+// Keywords 段落预筛关键词（hit_keywords 与 synonyms）到主题名的映射，
+// 同一关键词出现在多个主题时对应全部主题
+func (e *Engine) Keywords() map[string][]string {
+	keywords := make(map[string][]string)
+	for _, topic := range e.topics {
+		for _, kw := range append(append([]string(nil), topic.HitKeywords...), topic.Synonyms...) {
+			if kw == "" || slices.Contains(keywords[kw], topic.Name) {
+				continue
+			}
+			keywords[kw] = append(keywords[kw], topic.Name)
+		}
+	}
+	return keywords
+}
+
+// Guardrails 评级后的护栏，由报告生成在组装风险发现后调用
+func (e *Engine) Guardrails() *Guardrails {
+	return e.guardrails
+}
+
+// Fired 命中的条件
+type Fired struct {
+	Level     domain.RiskLevel `json:"level"`
+	Condition string           `json:"condition"`
+	// Loc 触发条件的健康事实证据定位
+	Loc string `json:"loc"`
+}
+
+// TopicResult 单个主题的评估结果
+type TopicResult struct {
+	Topic       string              `json:"topic"`
+	Level       domain.RiskLevel    `json:"level"`
+	Fired       []Fired             `json:"fired,omitempty"`
+	HealthFacts []domain.HealthFact `json:"health_facts"`
+	PolicyFacts []domain.PolicyFact `json:"policy_facts,omitempty"`
+}
+
+// Evaluate 逐主题评估：命中任一红色条件为红，否则命中任一黄色条件为黄，否则为绿。
+// 没有相关健康事实的主题不输出结果。
+func (e *Engine) Evaluate(health []domain.HealthFact, policy []domain.PolicyFact) []TopicResult {
+	known := make(map[string]bool, len(e.topics))
+	for _, topic := range e.topics {
+		known[topic.Name] = true
+	}
+	var results []TopicResult
+	for _, topic := range e.topics {
+		facts := topic.matchHealth(health, known)
+		if len(facts) == 0 {
+			continue
+		}
+		result := TopicResult{
+			Topic:       topic.Name,
+			Level:       domain.RiskLevelGreen,
+			HealthFacts: facts,
+			PolicyFacts: topic.matchPolicy(policy),
+		}
+		for i := range facts {
+			env := factEnv(&facts[i])
+			for _, cond := range append(topic.Red, topic.Yellow...) {
+				if !cond.Expr.Eval(env) {
+					continue
+				}
+				result.Fired = append(result.Fired, Fired{Level: cond.Level, Condition: cond.Expr.String(), Loc: facts[i].Evidence.Loc})
+				if cond.Level == domain.RiskLevelRed || result.Level == domain.RiskLevelGreen {
+					result.Level = cond.Level
+				}
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
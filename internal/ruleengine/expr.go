@@ -0,0 +1,434 @@
+package ruleengine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Kind 属性值类型
+type Kind int
+
+const (
+	// KindUnknown 未知值：报告未写明或无法判断，参与的比较结果为未知
+	KindUnknown Kind = iota
+	KindBool
+	KindNumber
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindBool:
+		return "bool"
+	case KindNumber:
+		return "number"
+	default:
+		return "unknown"
+	}
+}
+
+// Value 表达式求值中的值。布尔运算采用三值逻辑：
+// false and unknown = false，true or unknown = true，其余含未知值的运算结果为未知
+type Value struct {
+	Kind Kind
+	Bool bool
+	Num  float64
+}
+
+// Unknown 未知值
+var Unknown = Value{}
+
+// Bool 布尔值
+func Bool(b bool) Value {
+	return Value{Kind: KindBool, Bool: b}
+}
+
+// Number 数值
+func Number(n float64) Value {
+	return Value{Kind: KindNumber, Num: n}
+}
+
+// OptionalBool *bool 为 nil 时为未知值
+func OptionalBool(b *bool) Value {
+	if b == nil {
+		return Unknown
+	}
+	return Bool(*b)
+}
+
+// isTrue 仅在值为确定的 true 时返回 true
+func (v Value) isTrue() bool {
+	return v.Kind == KindBool && v.Bool
+}
+
+// Env 属性取值，属性不存在时返回 Unknown
+type Env func(name string) Value
+
+// Expr 编译后的条件表达式
+type Expr struct {
+	src  string
+	root node
+}
+
+// String 表达式原文
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval 求值，结果为未知时视为不命中
+func (e *Expr) Eval(env Env) bool {
+	return e.root.eval(env).isTrue()
+}
+
+// Scope 表达式可引用的名字
+type Scope struct {
+	// Attrs 属性及其类型
+	Attrs map[string]Kind
+	// Prefixes 动态属性前缀（如 "values"），"前缀.任意名" 均为该类型的属性
+	Prefixes map[string]Kind
+	// Consts 编译期常量（如 "thresholds.dbp_abnormal"），编译时内联为数值
+	Consts map[string]float64
+	// Used 非 nil 时记录编译中引用到的常量名
+	Used map[string]bool
+}
+
+// lookup 返回名字对应的节点与类型
+func (s Scope) lookup(name string) (node, Kind, bool) {
+	if v, ok := s.Consts[name]; ok {
+		if s.Used != nil {
+			s.Used[name] = true
+		}
+		return literalNode{Number(v)}, KindNumber, true
+	}
+	if kind, ok := s.Attrs[name]; ok {
+		return identNode(name), kind, true
+	}
+	if prefix, rest, ok := strings.Cut(name, "."); ok && rest != "" {
+		if kind, ok := s.Prefixes[prefix]; ok {
+			return identNode(name), kind, true
+		}
+	}
+	return nil, 0, false
+}
+
+// SyntaxError 表达式编译错误，Col 为从 1 开始的字符位置
+type SyntaxError struct {
+	Col int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("col %d: %s", e.Col, e.Msg)
+}
+
+// Compile 编译条件表达式并做类型检查，scope 为可引用的属性与常量。
+//
+// 语法：
+//
+//	expr    = or
+//	or      = and { "or" and }
+//	and     = unary { "and" unary }
+//	unary   = "not" unary | primary
+//	primary = "(" expr ")" | operand [ ("==" | "!=" | "<" | "<=" | ">" | ">=") operand ]
+//	operand = 属性名 | 常量名 | 数字 | "true" | "false"
+//
+// 表达式整体与 and/or/not 的操作数必须为布尔值，大小比较只用于数值。
+func Compile(src string, scope Scope) (*Expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks, scope: scope}
+	root, kind, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &SyntaxError{Col: t.col, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+	if kind != KindBool {
+		return nil, &SyntaxError{Col: 1, Msg: fmt.Sprintf("condition must be bool, got %s", kind)}
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokKind
+	text string
+	col  int
+}
+
+// lex 词法分析，属性名允许小写字母、数字、下划线与点（如 values.sbp）
+func lex(src string) ([]token, error) {
+	var toks []token
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		col := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			toks = append(toks, token{tokLParen, "(", col})
+			i++
+		case r == ')':
+			toks = append(toks, token{tokRParen, ")", col})
+			i++
+		case strings.ContainsRune("=!<>", r):
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+			}
+			if op == "=" || op == "!" {
+				return nil, &SyntaxError{Col: col, Msg: fmt.Sprintf("unknown operator %q", op)}
+			}
+			toks = append(toks, token{tokOp, op, col})
+			i += len(op)
+		case unicode.IsDigit(r) || r == '-' || r == '.':
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{tokNumber, string(runes[i:j]), col})
+			i = j
+		case r == '_' || (r >= 'a' && r <= 'z'):
+			j := i + 1
+			for j < len(runes) && (runes[j] == '_' || runes[j] == '.' || (runes[j] >= 'a' && runes[j] <= 'z') || unicode.IsDigit(runes[j])) {
+				j++
+			}
+			toks = append(toks, token{tokIdent, string(runes[i:j]), col})
+			i = j
+		default:
+			return nil, &SyntaxError{Col: col, Msg: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+	return append(toks, token{tokEOF, "end of expression", len(runes) + 1}), nil
+}
+
+type exprParser struct {
+	toks  []token
+	pos   int
+	scope Scope
+}
+
+func (p *exprParser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *exprParser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) keyword(word string) bool {
+	t := p.peek()
+	if t.kind == tokIdent && t.text == word {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseOr() (node, Kind, error) {
+	return p.parseLogical("or", p.parseAnd)
+}
+
+func (p *exprParser) parseAnd() (node, Kind, error) {
+	return p.parseLogical("and", p.parseUnary)
+}
+
+func (p *exprParser) parseLogical(op string, operand func() (node, Kind, error)) (node, Kind, error) {
+	col := p.peek().col
+	left, kind, err := operand()
+	if err != nil {
+		return nil, 0, err
+	}
+	for {
+		if !p.keyword(op) {
+			return left, kind, nil
+		}
+		if kind != KindBool {
+			return nil, 0, &SyntaxError{Col: col, Msg: fmt.Sprintf("%q operand must be bool, got %s", op, kind)}
+		}
+		rcol := p.peek().col
+		right, rkind, err := operand()
+		if err != nil {
+			return nil, 0, err
+		}
+		if rkind != KindBool {
+			return nil, 0, &SyntaxError{Col: rcol, Msg: fmt.Sprintf("%q operand must be bool, got %s", op, rkind)}
+		}
+		left = &logicalNode{and: op == "and", left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseUnary() (node, Kind, error) {
+	if p.keyword("not") {
+		col := p.peek().col
+		x, kind, err := p.parseUnary()
+		if err != nil {
+			return nil, 0, err
+		}
+		if kind != KindBool {
+			return nil, 0, &SyntaxError{Col: col, Msg: fmt.Sprintf("\"not\" operand must be bool, got %s", kind)}
+		}
+		return &notNode{x: x}, KindBool, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (node, Kind, error) {
+	if p.peek().kind == tokLParen {
+		open := p.next()
+		x, kind, err := p.parseOr()
+		if err != nil {
+			return nil, 0, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, 0, &SyntaxError{Col: t.col, Msg: fmt.Sprintf("expected \")\" to close \"(\" at col %d, got %q", open.col, t.text)}
+		}
+		return x, kind, nil
+	}
+
+	left, lkind, err := p.parseOperand()
+	if err != nil {
+		return nil, 0, err
+	}
+	if p.peek().kind != tokOp {
+		return left, lkind, nil
+	}
+	op := p.next()
+	right, rkind, err := p.parseOperand()
+	if err != nil {
+		return nil, 0, err
+	}
+	if lkind != rkind {
+		return nil, 0, &SyntaxError{Col: op.col, Msg: fmt.Sprintf("cannot compare %s %s %s", lkind, op.text, rkind)}
+	}
+	if lkind == KindBool && op.text != "==" && op.text != "!=" {
+		return nil, 0, &SyntaxError{Col: op.col, Msg: fmt.Sprintf("operator %q requires numbers", op.text)}
+	}
+	return &compareNode{op: op.text, left: left, right: right}, KindBool, nil
+}
+
+func (p *exprParser) parseOperand() (node, Kind, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, 0, &SyntaxError{Col: t.col, Msg: fmt.Sprintf("invalid number %q", t.text)}
+		}
+		return literalNode{Number(n)}, KindNumber, nil
+	case tokIdent:
+		switch t.text {
+		case "true", "false":
+			return literalNode{Bool(t.text == "true")}, KindBool, nil
+		case "and", "or", "not":
+			return nil, 0, &SyntaxError{Col: t.col, Msg: fmt.Sprintf("unexpected %q", t.text)}
+		}
+		n, kind, ok := p.scope.lookup(t.text)
+		if !ok {
+			return nil, 0, &SyntaxError{Col: t.col, Msg: fmt.Sprintf("unknown attribute or constant %q", t.text)}
+		}
+		return n, kind, nil
+	default:
+		return nil, 0, &SyntaxError{Col: t.col, Msg: fmt.Sprintf("expected attribute or literal, got %q", t.text)}
+	}
+}
+
+type node interface {
+	eval(env Env) Value
+}
+
+type literalNode struct {
+	v Value
+}
+
+func (n literalNode) eval(Env) Value {
+	return n.v
+}
+
+type identNode string
+
+func (n identNode) eval(env Env) Value {
+	return env(string(n))
+}
+
+type notNode struct {
+	x node
+}
+
+func (n *notNode) eval(env Env) Value {
+	v := n.x.eval(env)
+	if v.Kind != KindBool {
+		return Unknown
+	}
+	return Bool(!v.Bool)
+}
+
+type logicalNode struct {
+	and         bool
+	left, right node
+}
+
+func (n *logicalNode) eval(env Env) Value {
+	l := n.left.eval(env)
+	// 短路：false and x = false，true or x = true
+	if l.Kind == KindBool && l.Bool != n.and {
+		return l
+	}
+	r := n.right.eval(env)
+	if r.Kind == KindBool && r.Bool != n.and {
+		return r
+	}
+	if l.Kind != KindBool || r.Kind != KindBool {
+		return Unknown
+	}
+	return Bool(n.and)
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) eval(env Env) Value {
+	l, r := n.left.eval(env), n.right.eval(env)
+	if l.Kind == KindUnknown || r.Kind == KindUnknown || l.Kind != r.Kind {
+		return Unknown
+	}
+	if l.Kind == KindBool {
+		eq := l.Bool == r.Bool
+		return Bool(eq == (n.op == "=="))
+	}
+	switch n.op {
+	case "==":
+		return Bool(l.Num == r.Num)
+	case "!=":
+		return Bool(l.Num != r.Num)
+	case "<":
+		return Bool(l.Num < r.Num)
+	case "<=":
+		return Bool(l.Num <= r.Num)
+	case ">":
+		return Bool(l.Num > r.Num)
+	default:
+		return Bool(l.Num >= r.Num)
+	}
+}
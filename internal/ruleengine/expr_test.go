@@ -0,0 +1,129 @@
+package ruleengine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testScope = Scope{
+	Attrs:    map[string]Kind{"diagnosed": KindBool, "date_missing": KindBool, "confidence": KindNumber},
+	Prefixes: map[string]Kind{"values": KindNumber},
+	Consts:   map[string]float64{"thresholds.sbp": 140},
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		src     string
+		wantCol int
+		wantMsg string
+	}{
+		{"diagnosed = true", 11, `unknown operator "="`},
+		{"diagnosed == true & date_missing", 19, `unexpected character '&'`},
+		{"diagnosed == tru", 14, `unknown attribute or constant "tru"`},
+		{"values.sbp >= thresholds.dbp", 15, `unknown attribute or constant "thresholds.dbp"`},
+		{"diagnosed == 1", 11, "cannot compare bool == number"},
+		{"diagnosed < true", 11, `operator "<" requires numbers`},
+		{"values.sbp", 1, "condition must be bool, got number"},
+		{"values.sbp and diagnosed", 1, `"and" operand must be bool, got number`},
+		{"diagnosed or confidence", 14, `"or" operand must be bool, got number`},
+		{"not confidence", 5, `"not" operand must be bool, got number`},
+		{"(diagnosed == true", 19, `expected ")" to close "(" at col 1, got "end of expression"`},
+		{"diagnosed == true)", 18, `unexpected ")"`},
+		{"diagnosed ==", 13, `expected attribute or literal, got "end of expression"`},
+		{"diagnosed and and", 15, `unexpected "and"`},
+		{"values.sbp >= 1.2.3", 15, `invalid number "1.2.3"`},
+		// 列号按字符计算，不受多字节字符影响
+		{"diagnosed == true and 血压", 23, `unexpected character '血'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := Compile(tt.src, testScope)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("err = %v, want *SyntaxError", err)
+			}
+			if syntaxErr.Col != tt.wantCol || !strings.Contains(syntaxErr.Msg, tt.wantMsg) {
+				t.Fatalf("err = %v, want col %d: %s", err, tt.wantCol, tt.wantMsg)
+			}
+		})
+	}
+}
+
+// env 按名字返回固定值，未列出的属性为未知值
+func env(values map[string]Value) Env {
+	return func(name string) Value {
+		if v, ok := values[name]; ok {
+			return v
+		}
+		return Unknown
+	}
+}
+
+func TestEvalThreeValued(t *testing.T) {
+	vars := env(map[string]Value{
+		"t": Bool(true),
+		"f": Bool(false),
+		"n": Number(150),
+	})
+	scope := Scope{
+		Attrs:    map[string]Kind{"t": KindBool, "f": KindBool, "u": KindBool, "n": KindNumber, "m": KindNumber},
+		Prefixes: map[string]Kind{"values": KindNumber},
+	}
+	tests := []struct {
+		src  string
+		want Value
+	}{
+		{"t and u", Unknown},
+		{"f and u", Bool(false)},
+		{"u and f", Bool(false)},
+		{"t or u", Bool(true)},
+		{"u or t", Bool(true)},
+		{"f or u", Unknown},
+		{"not u", Unknown},
+		{"not f", Bool(true)},
+		{"u == true", Unknown},
+		{"u != true", Unknown},
+		{"m >= 140", Unknown},
+		{"values.sbp >= 140", Unknown},
+		{"n >= 140", Bool(true)},
+		{"n < 140", Bool(false)},
+		{"n >= 140 and u", Unknown},
+		{"n >= 140 or u", Bool(true)},
+		{"not (f and u)", Bool(true)},
+		{"t == f", Bool(false)},
+		{"t != f", Bool(true)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			expr, err := Compile(tt.src, scope)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			if got := expr.root.eval(vars); got != tt.want {
+				t.Fatalf("eval = %s %v, want %s %v", got.Kind, got.Bool, tt.want.Kind, tt.want.Bool)
+			}
+			// 结果为未知时不命中
+			if got := expr.Eval(vars); got != tt.want.isTrue() {
+				t.Fatalf("Eval = %v, want %v", got, tt.want.isTrue())
+			}
+		})
+	}
+}
+
+func TestCompileInlinesConstants(t *testing.T) {
+	expr, err := Compile("values.sbp >= thresholds.sbp", testScope)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	if !expr.Eval(env(map[string]Value{"values.sbp": Number(140)})) {
+		t.Error("140 >= thresholds.sbp (140) not matched")
+	}
+	if expr.Eval(env(map[string]Value{"values.sbp": Number(139)})) {
+		t.Error("139 >= thresholds.sbp (140) matched")
+	}
+	// 常量在编译期内联，求值时不从 env 读取
+	if expr.Eval(env(map[string]Value{"values.sbp": Number(139), "thresholds.sbp": Number(100)})) {
+		t.Error("constant read from env at evaluation time")
+	}
+}
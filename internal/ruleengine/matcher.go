@@ -0,0 +1,46 @@
+package ruleengine
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+// matchHealth 类别与主题一致的健康事实。类别不是任何已知主题（如 other 或为空）时，
+// 才按标签、证据原文是否包含主题关键词归类，避免证据段落顺带提及的指标把事实归入其他主题
+func (t *Topic) matchHealth(facts []domain.HealthFact, known map[string]bool) []domain.HealthFact {
+	var matched []domain.HealthFact
+	for _, f := range facts {
+		if f.Category == t.Name || (!known[f.Category] && (t.mentions(f.Label) || t.mentions(f.Evidence.Text))) {
+			matched = append(matched, f)
+		}
+	}
+	return matched
+}
+
+// matchPolicy 主题映射类型的条款事实，提及主题关键词的条款排在前面
+func (t *Topic) matchPolicy(facts []domain.PolicyFact) []domain.PolicyFact {
+	var matched []domain.PolicyFact
+	for _, f := range facts {
+		for _, typ := range t.PolicyTypes {
+			if f.Type == typ {
+				matched = append(matched, f)
+				break
+			}
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return t.mentions(matched[i].Content) && !t.mentions(matched[j].Content)
+	})
+	return matched
+}
+
+func (t *Topic) mentions(text string) bool {
+	for _, kw := range t.HitKeywords {
+		if strings.Contains(text, kw) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,276 @@
+package ruleengine
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/extract"
+	"gopkg.in/yaml.v3"
+)
+
+// identifier 主题名与阈值名，主题名与 HealthFact.Category 对应
+var identifier = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// 每个主题的追问问题数量（PRD §7.3）
+const (
+	minQuestions = 2
+	maxQuestions = 5
+)
+
+// topicFields topics.yaml 中主题支持的字段
+var topicFields = map[string]bool{
+	"label":             true,
+	"hit_keywords":      true,
+	"synonyms":          true,
+	"policy_types":      true,
+	"red_conditions":    true,
+	"yellow_conditions": true,
+	"min_confidence":    true,
+	"thresholds":        true,
+	"red_signals":       true,
+	"yellow_signals":    true,
+	"questions":         true,
+}
+
+// Topic 风险主题规则
+type Topic struct {
+	Name string
+	// Label 主题中文名，用于风险说明，未配置时使用 Name
+	Label string
+	// MinConfidence 红色结论所需的最低置信度，0 表示使用全局配置
+	MinConfidence float64
+	HitKeywords   []string
+	// Synonyms 关键词的同义写法与检测项名称（如 HbA1c、糖化血红蛋白），与 HitKeywords 一起用于段落预筛
+	Synonyms    []string
+	PolicyTypes []string
+	// Thresholds 数值阈值，条件中以 thresholds.{name} 引用
+	Thresholds map[string]float64
+	// RedSignals、YellowSignals 红/黄等级的判定依据说明，用于风险解释
+	RedSignals    []string
+	YellowSignals []string
+	// Questions 主题追问问题，2-5 条
+	Questions []string
+	Red       []*Condition
+	Yellow    []*Condition
+}
+
+// Condition 已编译的红/黄条件
+type Condition struct {
+	Level domain.RiskLevel
+	// Line 条件在配置文件中的行号
+	Line int
+	Expr *Expr
+}
+
+// topicSpec topics.yaml 中的单个主题，条件保留为节点以记录行号
+type topicSpec struct {
+	Label            string             `yaml:"label"`
+	HitKeywords      []string           `yaml:"hit_keywords"`
+	Synonyms         []string           `yaml:"synonyms"`
+	PolicyTypes      []string           `yaml:"policy_types"`
+	RedConditions    []yaml.Node        `yaml:"red_conditions"`
+	YellowConditions []yaml.Node        `yaml:"yellow_conditions"`
+	MinConfidence    float64            `yaml:"min_confidence"`
+	Thresholds       map[string]float64 `yaml:"thresholds"`
+	RedSignals       []string           `yaml:"red_signals"`
+	YellowSignals    []string           `yaml:"yellow_signals"`
+	Questions        []string           `yaml:"questions"`
+}
+
+// guardrailSpec topics.yaml 中的全局护栏配置
+type guardrailSpec struct {
+	MinConfidence    float64  `yaml:"min_confidence"`
+	ForbiddenPhrases []string `yaml:"forbidden_phrases"`
+}
+
+// Load 加载并校验规则文件，任一条件编译失败即返回带行号的错误
+func Load(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	return Parse(path, data)
+}
+
+// Parse 解析规则内容，name 用于错误信息中的文件名
+func Parse(name string, data []byte) (*Engine, error) {
+	var doc struct {
+		Version    string        `yaml:"version"`
+		Guardrails guardrailSpec `yaml:"guardrails"`
+		Topics     yaml.Node     `yaml:"topics"`
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if doc.Version == "" {
+		return nil, fmt.Errorf("%s: version is required", name)
+	}
+	if doc.Topics.Kind != yaml.MappingNode || len(doc.Topics.Content) == 0 {
+		return nil, fmt.Errorf("%s: topics must be a non-empty mapping", name)
+	}
+
+	var (
+		errs   []error
+		topics []*Topic
+	)
+	fail := func(line int, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s:%d: %s", name, line, fmt.Sprintf(format, args...)))
+	}
+	// 映射节点的 Content 按键、值交替排列，保留配置中的主题顺序
+	for i := 0; i+1 < len(doc.Topics.Content); i += 2 {
+		key, value := doc.Topics.Content[i], doc.Topics.Content[i+1]
+		if !identifier.MatchString(key.Value) {
+			fail(key.Line, "invalid topic name %q", key.Value)
+			continue
+		}
+		topic, topicErrs := compileTopic(key.Value, value)
+		for _, e := range topicErrs {
+			fail(e.line, "topic %s: %s", key.Value, e.msg)
+		}
+		if len(topicErrs) == 0 {
+			topics = append(topics, topic)
+		}
+	}
+	guardrails := &Guardrails{
+		minConfidence:    doc.Guardrails.MinConfidence,
+		topicConfidence:  make(map[string]float64),
+		forbiddenPhrases: doc.Guardrails.ForbiddenPhrases,
+	}
+	if guardrails.minConfidence == 0 {
+		guardrails.minConfidence = defaultMinConfidence
+	}
+	if guardrails.minConfidence < 0 || guardrails.minConfidence > 1 {
+		errs = append(errs, fmt.Errorf("%s: guardrails.min_confidence must be within (0, 1]", name))
+	}
+	if guardrails.forbiddenPhrases == nil {
+		guardrails.forbiddenPhrases = defaultForbiddenPhrases
+	}
+	for _, topic := range topics {
+		if topic.MinConfidence != 0 {
+			guardrails.topicConfidence[topic.Name] = topic.MinConfidence
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	// 版本号附带内容摘要，修改规则但忘记更新 version 时仍可区分
+	digest := sha256.Sum256(data)
+	version := fmt.Sprintf("%s+%x", doc.Version, digest[:4])
+	return &Engine{version: version, topics: topics, guardrails: guardrails}, nil
+}
+
+type lineError struct {
+	line int
+	msg  string
+}
+
+func compileTopic(name string, node *yaml.Node) (*Topic, []lineError) {
+	if node.Kind != yaml.MappingNode {
+		return nil, []lineError{{node.Line, "must be a mapping"}}
+	}
+	var errs []lineError
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if key := node.Content[i]; !topicFields[key.Value] {
+			errs = append(errs, lineError{key.Line, fmt.Sprintf("unknown field %q", key.Value)})
+		}
+	}
+	var spec topicSpec
+	if err := node.Decode(&spec); err != nil {
+		return nil, append(errs, lineError{node.Line, err.Error()})
+	}
+
+	if len(spec.HitKeywords) == 0 {
+		errs = append(errs, lineError{fieldLine(node, "hit_keywords"), "hit_keywords must not be empty"})
+	}
+	if len(spec.PolicyTypes) == 0 {
+		errs = append(errs, lineError{fieldLine(node, "policy_types"), "policy_types must not be empty"})
+	}
+	for _, t := range spec.PolicyTypes {
+		if !extract.IsPolicyType(t) {
+			errs = append(errs, lineError{fieldLine(node, "policy_types"), fmt.Sprintf("unknown policy type %q", t)})
+		}
+	}
+	if spec.MinConfidence < 0 || spec.MinConfidence > 1 {
+		errs = append(errs, lineError{fieldLine(node, "min_confidence"), "min_confidence must be within (0, 1]"})
+	}
+	names := make([]string, 0, len(spec.Thresholds))
+	for name := range spec.Thresholds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !identifier.MatchString(name) {
+			errs = append(errs, lineError{fieldLine(node, "thresholds"), fmt.Sprintf("invalid threshold name %q", name)})
+		}
+	}
+	if n := len(spec.Questions); n < minQuestions || n > maxQuestions {
+		errs = append(errs, lineError{fieldLine(node, "questions"), fmt.Sprintf("questions must have %d-%d entries, got %d", minQuestions, maxQuestions, n)})
+	}
+	if len(spec.RedConditions)+len(spec.YellowConditions) == 0 {
+		errs = append(errs, lineError{node.Line, "at least one red or yellow condition is required"})
+	}
+
+	topic := &Topic{
+		Name:          name,
+		Label:         spec.Label,
+		MinConfidence: spec.MinConfidence,
+		HitKeywords:   spec.HitKeywords,
+		Synonyms:      spec.Synonyms,
+		PolicyTypes:   spec.PolicyTypes,
+		Thresholds:    spec.Thresholds,
+		RedSignals:    spec.RedSignals,
+		YellowSignals: spec.YellowSignals,
+		Questions:     spec.Questions,
+	}
+	scope := topicScope(spec.Thresholds)
+	scope.Used = make(map[string]bool)
+	compiled := true
+	compile := func(level domain.RiskLevel, nodes []yaml.Node) []*Condition {
+		conds := make([]*Condition, 0, len(nodes))
+		for _, n := range nodes {
+			if n.Kind != yaml.ScalarNode {
+				errs = append(errs, lineError{n.Line, fmt.Sprintf("%s condition must be a string", level)})
+				compiled = false
+				continue
+			}
+			expr, err := Compile(n.Value, scope)
+			if err != nil {
+				compiled = false
+				errs = append(errs, lineError{n.Line, fmt.Sprintf("%s condition %q: %v", level, n.Value, err)})
+				continue
+			}
+			conds = append(conds, &Condition{Level: level, Line: n.Line, Expr: expr})
+		}
+		return conds
+	}
+	topic.Red = compile(domain.RiskLevelRed, spec.RedConditions)
+	topic.Yellow = compile(domain.RiskLevelYellow, spec.YellowConditions)
+	// 未被任何条件引用的阈值多为改名或删条件后的残留，条件编译失败时引用情况不完整，不做检查
+	if compiled {
+		for _, name := range names {
+			if !scope.Used[thresholdsPrefix+"."+name] {
+				errs = append(errs, lineError{fieldLine(node, "thresholds"), fmt.Sprintf("threshold %q is not referenced by any condition", name)})
+			}
+		}
+	}
+	return topic, errs
+}
+
+// fieldLine 映射节点中字段所在行，字段不存在时为映射节点所在行
+func fieldLine(node *yaml.Node, field string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == field {
+			return node.Content[i].Line
+		}
+	}
+	return node.Line
+}
@@ -0,0 +1,167 @@
+package ruleengine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+func loadTopics(t *testing.T) *Engine {
+	t.Helper()
+	engine, err := Load("../../configs/topics.yaml")
+	if err != nil {
+		t.Fatalf("Load topics.yaml: %v", err)
+	}
+	return engine
+}
+
+func TestLoadTopicsConfig(t *testing.T) {
+	engine := loadTopics(t)
+	if !strings.HasPrefix(engine.Version(), "2026.") || !strings.Contains(engine.Version(), "+") {
+		t.Errorf("Version = %s, want config version with digest", engine.Version())
+	}
+	if len(engine.Topics()) == 0 {
+		t.Fatal("no topics loaded")
+	}
+	if got := engine.Guardrails().MinConfidence("unknown_topic"); got != 0.6 {
+		t.Errorf("MinConfidence = %v, want 0.6", got)
+	}
+}
+
+func TestParseReportsLines(t *testing.T) {
+	const rules = `version: "1"
+topics:
+  hypertension:
+    hit_keywords: ["高血压"]
+    policy_types: ["exclusion"]
+    questions: ["a", "b"]
+    red_conditions:
+      - "diagnosed == true"
+      - "values.sbp >= thresholds.missing"
+    yellow_conditions:
+      - "diagnosed = false"
+  Bad:
+    hit_keywords: ["x"]
+`
+	_, err := Parse("topics.yaml", []byte(rules))
+	if err == nil {
+		t.Fatal("Parse accepted invalid rules")
+	}
+	for _, want := range []string{
+		`topics.yaml:9: topic hypertension: red condition "values.sbp >= thresholds.missing": col 15: unknown attribute or constant "thresholds.missing"`,
+		`topics.yaml:11: topic hypertension: yellow condition "diagnosed = false": col 11: unknown operator "="`,
+		`topics.yaml:12: invalid topic name "Bad"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestParseRejectsUnreferencedThresholds(t *testing.T) {
+	const rules = `version: "1"
+topics:
+  hypertension:
+    hit_keywords: ["高血压"]
+    policy_types: ["exclusion"]
+    questions: ["a", "b"]
+    thresholds:
+      sbp_borderline: 130
+      sbp_abnormal: 140
+    yellow_conditions:
+      - "values.sbp >= thresholds.sbp_borderline"
+`
+	_, err := Parse("topics.yaml", []byte(rules))
+	if err == nil {
+		t.Fatal("Parse accepted an unreferenced threshold")
+	}
+	want := `topics.yaml:7: topic hypertension: threshold "sbp_abnormal" is not referenced by any condition`
+	if err.Error() != want {
+		t.Fatalf("err = %v, want %q", err, want)
+	}
+
+	// 引用全部阈值后可以加载
+	fixed := strings.Replace(rules, `thresholds.sbp_borderline"`, `thresholds.sbp_borderline or values.sbp >= thresholds.sbp_abnormal"`, 1)
+	if _, err := Parse("topics.yaml", []byte(fixed)); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// measured 带已判定检测值的健康事实
+func measured(category, date string, key string, value float64, flag domain.MeasurementFlag) domain.HealthFact {
+	return domain.HealthFact{
+		Category:     category,
+		Evidence:     domain.EvidenceDetail{Date: date, Loc: "p1/para_1"},
+		Measurements: map[string]domain.Measurement{key: {Value: value, Flag: flag}},
+		Confidence:   0.9,
+	}
+}
+
+func TestEvaluateGatesMissingDate(t *testing.T) {
+	engine := loadTopics(t)
+	tests := []struct {
+		name string
+		fact domain.HealthFact
+		want domain.RiskLevel
+	}{
+		{"diagnosed", domain.HealthFact{Category: "hypertension", Diagnosed: boolPtr(true), Evidence: domain.EvidenceDetail{Date: "2026-05-01"}}, domain.RiskLevelRed},
+		{"date missing alone", domain.HealthFact{Category: "hypertension", Diagnosed: boolPtr(false)}, domain.RiskLevelGreen},
+		{"date missing, normal values", measured("hypertension", "", "sbp", 118, domain.MeasurementNormal), domain.RiskLevelGreen},
+		{"date missing, abnormal values", measured("hypertension", "", "sbp", 152, domain.MeasurementHigh), domain.RiskLevelYellow},
+		{"borderline sbp", measured("hypertension", "2026-05-01", "sbp", 132, domain.MeasurementNormal), domain.RiskLevelYellow},
+		{"unknown values", domain.HealthFact{Category: "hypertension", Evidence: domain.EvidenceDetail{Date: "2026-05-01"}}, domain.RiskLevelGreen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := engine.Evaluate([]domain.HealthFact{tt.fact}, nil)
+			if len(results) != 1 || results[0].Topic != "hypertension" {
+				t.Fatalf("results = %+v, want one hypertension result", results)
+			}
+			if results[0].Level != tt.want {
+				t.Fatalf("level = %s (fired %+v), want %s", results[0].Level, results[0].Fired, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateMatchesByCategory(t *testing.T) {
+	engine := loadTopics(t)
+	tests := []struct {
+		name string
+		fact domain.HealthFact
+		want []string
+	}{
+		{
+			"known category ignores other topics' keywords in evidence",
+			domain.HealthFact{
+				Category:  "hypertension",
+				Label:     "高血压",
+				Diagnosed: boolPtr(true),
+				Evidence:  domain.EvidenceDetail{Text: "诊断高血压，空腹血糖 5.1 mmol/L", Date: "2026-05-01"},
+			},
+			[]string{"hypertension"},
+		},
+		{
+			"unknown category falls back to keywords",
+			domain.HealthFact{Category: "other", Label: "空腹血糖", Evidence: domain.EvidenceDetail{Text: "空腹血糖 5.1 mmol/L", Date: "2026-05-01"}},
+			[]string{"diabetes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, r := range engine.Evaluate([]domain.HealthFact{tt.fact}, nil) {
+				got = append(got, r.Topic)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("topics = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
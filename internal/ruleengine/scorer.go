@@ -0,0 +1,119 @@
+package ruleengine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+)
+
+// 护栏规则标识，记录在 Downgrade.Rule 中
+const (
+	RuleLowConfidence   = "low_confidence"
+	RuleMissingEvidence = "missing_evidence"
+)
+
+const (
+	// defaultMinConfidence 未配置时红色结论所需的最低置信度（PRD §10.1）
+	defaultMinConfidence = 0.6
+)
+
+// defaultForbiddenPhrases 未配置时禁止出现在结论与建议中的确定性理赔表述
+var defaultForbiddenPhrases = []string{"必赔", "拒赔", "一定赔", "一定不赔", "保证理赔"}
+
+// neutralSummaries 结论包含禁用表述时替换的中性表述
+var neutralSummaries = map[domain.RiskLevel]string{
+	domain.RiskLevelRed:    "体检异常与条款约定存在较强关联，建议在投保或理赔前重点核实。",
+	domain.RiskLevelYellow: "存在需要进一步确认的信息，建议补充材料或向保险公司咨询。",
+	domain.RiskLevelGreen:  "暂未发现高风险冲突。",
+}
+
+// Guardrails 风险评级后的护栏：低置信度与证据不足时红降黄，
+// 并拦截结论与建议中的确定性理赔表述
+type Guardrails struct {
+	minConfidence    float64
+	topicConfidence  map[string]float64
+	forbiddenPhrases []string
+}
+
+// MinConfidence 主题红色结论所需的最低置信度
+func (g *Guardrails) MinConfidence(topic string) float64 {
+	if v, ok := g.topicConfidence[topic]; ok {
+		return v
+	}
+	return g.minConfidence
+}
+
+// Apply 对风险发现应用护栏，依次执行 Grade 与 Sanitize。
+// 需要按最终等级生成说明文字时，可分别调用两者。
+func (g *Guardrails) Apply(ctx context.Context, f *domain.RiskFinding) {
+	g.Grade(f)
+	g.Sanitize(ctx, f)
+}
+
+// Grade 校验红色结论，降级记录追加到 f.Downgrades。
+// 红色结论须同时满足：置信度不低于主题阈值、至少一条体检证据与一条条款证据。
+func (g *Guardrails) Grade(f *domain.RiskFinding) {
+	if f.Level != domain.RiskLevelRed {
+		return
+	}
+	if min := g.MinConfidence(f.Topic); f.Confidence < min {
+		g.downgrade(f, RuleLowConfidence, fmt.Sprintf("抽取置信度 %.2f 低于 %.2f，结论需要人工确认", f.Confidence, min))
+	}
+	if len(f.HealthEvidence) == 0 || len(f.PolicyEvidence) == 0 {
+		g.downgrade(f, RuleMissingEvidence, missingEvidenceReason(f))
+	}
+	if len(f.Downgrades) > 0 {
+		f.Level = domain.RiskLevelYellow
+	}
+}
+
+// Sanitize 拦截确定性理赔表述：结论包含禁用表述时替换为中性表述，包含禁用表述的建议被移除
+func (g *Guardrails) Sanitize(ctx context.Context, f *domain.RiskFinding) {
+	log := logger.FromContext(ctx)
+	if phrase, ok := g.forbidden(f.Summary); ok {
+		log.Warn("Replacing finding summary with forbidden phrase", "topic", f.Topic, "phrase", phrase)
+		f.Summary = neutralSummaries[f.Level]
+	}
+	actions := f.Actions[:0]
+	for _, action := range f.Actions {
+		if phrase, ok := g.forbidden(action); ok {
+			log.Warn("Dropping finding action with forbidden phrase", "topic", f.Topic, "phrase", phrase)
+			continue
+		}
+		actions = append(actions, action)
+	}
+	f.Actions = actions
+}
+
+// downgrade 记录一次红降黄，多条规则同时触发时均记录
+func (g *Guardrails) downgrade(f *domain.RiskFinding, rule, reason string) {
+	f.Downgrades = append(f.Downgrades, domain.Downgrade{
+		Rule:   rule,
+		From:   domain.RiskLevelRed,
+		To:     domain.RiskLevelYellow,
+		Reason: reason,
+	})
+}
+
+func missingEvidenceReason(f *domain.RiskFinding) string {
+	switch {
+	case len(f.HealthEvidence) == 0 && len(f.PolicyEvidence) == 0:
+		return "缺少体检与条款原文证据"
+	case len(f.HealthEvidence) == 0:
+		return "缺少体检原文证据"
+	default:
+		return "未在条款中找到相关约定原文"
+	}
+}
+
+func (g *Guardrails) forbidden(text string) (string, bool) {
+	for _, phrase := range g.forbiddenPhrases {
+		if strings.Contains(text, phrase) {
+			return phrase, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,114 @@
+package ruleengine
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("error", "json")
+	os.Exit(m.Run())
+}
+
+func testGuardrails() *Guardrails {
+	return &Guardrails{
+		minConfidence:    0.6,
+		topicConfidence:  map[string]float64{"thyroid": 0.8},
+		forbiddenPhrases: defaultForbiddenPhrases,
+	}
+}
+
+func TestGuardrailsGrade(t *testing.T) {
+	health := []domain.Evidence{{Loc: "p1/para_2", Text: "血压 152/95mmHg"}}
+	policy := []domain.Evidence{{Loc: "p4/para_9", Text: "既往症不在保障范围内"}}
+	tests := []struct {
+		name      string
+		finding   domain.RiskFinding
+		wantLevel domain.RiskLevel
+		wantRules []string
+	}{
+		{
+			name:      "red kept",
+			finding:   domain.RiskFinding{Topic: "hypertension", Level: domain.RiskLevelRed, Confidence: 0.6, HealthEvidence: health, PolicyEvidence: policy},
+			wantLevel: domain.RiskLevelRed,
+		},
+		{
+			name:      "low confidence",
+			finding:   domain.RiskFinding{Topic: "hypertension", Level: domain.RiskLevelRed, Confidence: 0.59, HealthEvidence: health, PolicyEvidence: policy},
+			wantLevel: domain.RiskLevelYellow,
+			wantRules: []string{RuleLowConfidence},
+		},
+		{
+			name:      "topic threshold overrides global",
+			finding:   domain.RiskFinding{Topic: "thyroid", Level: domain.RiskLevelRed, Confidence: 0.7, HealthEvidence: health, PolicyEvidence: policy},
+			wantLevel: domain.RiskLevelYellow,
+			wantRules: []string{RuleLowConfidence},
+		},
+		{
+			name:      "missing policy evidence",
+			finding:   domain.RiskFinding{Topic: "hypertension", Level: domain.RiskLevelRed, Confidence: 0.9, HealthEvidence: health},
+			wantLevel: domain.RiskLevelYellow,
+			wantRules: []string{RuleMissingEvidence},
+		},
+		{
+			name:      "both rules recorded",
+			finding:   domain.RiskFinding{Topic: "hypertension", Level: domain.RiskLevelRed, Confidence: 0.3},
+			wantLevel: domain.RiskLevelYellow,
+			wantRules: []string{RuleLowConfidence, RuleMissingEvidence},
+		},
+		{
+			name:      "yellow untouched",
+			finding:   domain.RiskFinding{Topic: "hypertension", Level: domain.RiskLevelYellow, Confidence: 0.1},
+			wantLevel: domain.RiskLevelYellow,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.finding
+			testGuardrails().Grade(&f)
+			if f.Level != tt.wantLevel {
+				t.Errorf("level = %s, want %s", f.Level, tt.wantLevel)
+			}
+			var rules []string
+			for _, d := range f.Downgrades {
+				if d.From != domain.RiskLevelRed || d.To != domain.RiskLevelYellow || d.Reason == "" {
+					t.Errorf("downgrade = %+v, want red to yellow with a reason", d)
+				}
+				rules = append(rules, d.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.wantRules) {
+				t.Errorf("rules = %v, want %v", rules, tt.wantRules)
+			}
+		})
+	}
+}
+
+func TestGuardrailsSanitize(t *testing.T) {
+	f := domain.RiskFinding{
+		Topic:   "hypertension",
+		Level:   domain.RiskLevelYellow,
+		Summary: "该情况理赔时一定不赔",
+		Actions: []string{"补充近期复查报告", "保险公司必赔，无需告知", "向保险公司确认告知范围"},
+	}
+	testGuardrails().Sanitize(context.Background(), &f)
+	if f.Summary != neutralSummaries[domain.RiskLevelYellow] {
+		t.Errorf("summary = %q, want the neutral yellow summary", f.Summary)
+	}
+	if want := []string{"补充近期复查报告", "向保险公司确认告知范围"}; !reflect.DeepEqual(f.Actions, want) {
+		t.Errorf("actions = %v, want %v", f.Actions, want)
+	}
+}
+
+func TestGuardrailsApplyUsesFinalLevel(t *testing.T) {
+	// 降级后替换结论时使用降级后的等级
+	f := domain.RiskFinding{Topic: "hypertension", Level: domain.RiskLevelRed, Confidence: 0.9, Summary: "确定拒赔"}
+	testGuardrails().Apply(context.Background(), &f)
+	if f.Level != domain.RiskLevelYellow || f.Summary != neutralSummaries[domain.RiskLevelYellow] {
+		t.Fatalf("finding = %s %q, want yellow with the neutral yellow summary", f.Level, f.Summary)
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/auth"
+	"github.com/zhenglizhi/policy-fit/internal/config"
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/repository"
+	"github.com/zhenglizhi/policy-fit/internal/sms"
+)
+
+// AuditActionUserLogin 登录审计动作
+const AuditActionUserLogin = "user.login"
+
+// otpDigits 验证码位数
+const otpDigits = 6
+
+// phonePattern 中国大陆手机号
+var phonePattern = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
+// OTPStore 验证码与限流存储，由 auth.OTPStore 实现
+type OTPStore interface {
+	Save(ctx context.Context, phone, digest string, ttl time.Duration) error
+	// Verify 返回 auth.OTPMatched、auth.OTPMismatch 或 auth.OTPExpired
+	Verify(ctx context.Context, phone, digest string, maxAttempts int) (int, error)
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error)
+	Exceeded(ctx context.Context, key string, limit int) (bool, time.Duration, error)
+}
+
+// TokenPair 登录或刷新后签发的 token
+type TokenPair struct {
+	UserID       int64  `json:"user_id"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	// ExpiresIn 访问 token 有效期（秒）
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// AuthService 手机号验证码登录与 token 签发
+type AuthService struct {
+	store  repository.Store
+	otps   OTPStore
+	sender sms.Sender
+	jwt    *auth.JWT
+	cfg    config.OTPConfig
+	// pepper 验证码摘要密钥，Redis 泄露时无法直接枚举出验证码
+	pepper     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewAuthService 创建登录服务，验证码摘要以 OTP_PEPPER 为密钥
+func NewAuthService(store repository.Store, otps OTPStore, sender sms.Sender, jwt *auth.JWT, cfg *config.Config) *AuthService {
+	return &AuthService{
+		store:      store,
+		otps:       otps,
+		sender:     sender,
+		jwt:        jwt,
+		cfg:        cfg.OTP,
+		pepper:     []byte(cfg.OTP.Pepper),
+		accessTTL:  time.Duration(cfg.Security.JWTAccessTTL) * time.Second,
+		refreshTTL: time.Duration(cfg.Security.JWTRefreshTTL) * time.Second,
+	}
+}
+
+// NormalizePhone 去除空格、连字符与 +86 前缀并校验手机号
+func NormalizePhone(raw string) (string, error) {
+	phone := strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(raw))
+	for _, prefix := range []string{"+86", "0086", "86"} {
+		if len(phone) == 11+len(prefix) && strings.HasPrefix(phone, prefix) {
+			phone = phone[len(prefix):]
+			break
+		}
+	}
+	if !phonePattern.MatchString(phone) {
+		return "", ErrInvalidPhone
+	}
+	return phone, nil
+}
+
+// SendOTP 生成并发送验证码。同一手机号受发送间隔与每小时次数限制，同一 IP 受每小时次数限制。
+func (s *AuthService) SendOTP(ctx context.Context, rawPhone, ip string) error {
+	phone, err := NormalizePhone(rawPhone)
+	if err != nil {
+		return err
+	}
+	if err := s.throttle(ctx, "otp-interval:"+phone, 1, time.Duration(s.cfg.SendInterval)*time.Second); err != nil {
+		return err
+	}
+	if err := s.throttle(ctx, "otp-phone:"+phone, s.cfg.PhoneHourlyLimit, time.Hour); err != nil {
+		return err
+	}
+	if err := s.throttle(ctx, "otp-ip:"+ip, s.cfg.IPHourlyLimit, time.Hour); err != nil {
+		return err
+	}
+
+	code, err := generateOTP()
+	if err != nil {
+		return err
+	}
+	if err := s.otps.Save(ctx, phone, s.digest(phone, code), time.Duration(s.cfg.TTL)*time.Second); err != nil {
+		return fmt.Errorf("failed to save otp: %w", err)
+	}
+	if err := s.sender.Send(ctx, phone, code); err != nil {
+		return fmt.Errorf("failed to send otp: %w", err)
+	}
+	return nil
+}
+
+// Login 校验验证码，成功后创建或查找用户并签发 token。
+// 验证码错误返回 ErrInvalidOTP；错误次数用尽后验证码作废，需重新获取。
+// 同一手机号每小时校验失败达到 PhoneFailureLimit 次后拒绝登录，防止反复获取验证码逐个猜测。
+func (s *AuthService) Login(ctx context.Context, rawPhone, code, ip string) (*TokenPair, error) {
+	phone, err := NormalizePhone(rawPhone)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.throttle(ctx, "login-ip:"+ip, s.cfg.IPHourlyLimit*s.cfg.MaxAttempts, time.Hour); err != nil {
+		return nil, err
+	}
+	failKey := "login-fail:" + phone
+	blocked, retryAfter, err := s.otps.Exceeded(ctx, failKey, s.cfg.PhoneFailureLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if blocked {
+		return nil, &ThrottledError{Wait: retryAfter}
+	}
+	code = strings.TrimSpace(code)
+	if len(code) != otpDigits {
+		return nil, ErrInvalidOTP
+	}
+	result, err := s.otps.Verify(ctx, phone, s.digest(phone, code), s.cfg.MaxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify otp: %w", err)
+	}
+	if result != auth.OTPMatched {
+		if _, _, err := s.otps.Allow(ctx, failKey, s.cfg.PhoneFailureLimit, time.Hour); err != nil {
+			return nil, fmt.Errorf("failed to record login failure: %w", err)
+		}
+		return nil, ErrInvalidOTP
+	}
+
+	var user *domain.User
+	err = s.store.WithTx(ctx, func(tx repository.Store) error {
+		var created bool
+		var err error
+		if user, created, err = tx.Users().UpsertByPhone(ctx, phone); err != nil {
+			return err
+		}
+		return tx.Audits().Create(ctx, &domain.AuditLog{
+			ActorID:    user.ID,
+			Action:     AuditActionUserLogin,
+			TargetType: "user_account",
+			TargetID:   strconv.FormatInt(user.ID, 10),
+			Detail:     map[string]interface{}{"created": created, "ip": ip},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(user.ID, time.Now())
+}
+
+// Refresh 以刷新 token 换取新的 token 对
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	now := time.Now()
+	claims, err := s.jwt.Verify(refreshToken, now)
+	if err != nil || claims.Use != auth.UseRefresh {
+		return nil, ErrInvalidRefreshToken
+	}
+	return s.issue(claims.UserID, now)
+}
+
+func (s *AuthService) issue(userID int64, now time.Time) (*TokenPair, error) {
+	access, err := s.jwt.Sign(auth.Claims{UserID: userID, Use: auth.UseAccess, IssuedAt: now, ExpiresAt: now.Add(s.accessTTL)})
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := s.jwt.Sign(auth.Claims{UserID: userID, Use: auth.UseRefresh, IssuedAt: now, ExpiresAt: now.Add(s.refreshTTL)})
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		UserID:       userID,
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTTL / time.Second),
+	}, nil
+}
+
+// throttle 超出限额时返回 *ThrottledError
+func (s *AuthService) throttle(ctx context.Context, key string, limit int, window time.Duration) error {
+	ok, retryAfter, err := s.otps.Allow(ctx, key, limit, window)
+	if err != nil {
+		return fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if !ok {
+		return &ThrottledError{Wait: retryAfter}
+	}
+	return nil
+}
+
+// digest 验证码摘要，绑定手机号，同一验证码在不同手机号下摘要不同
+func (s *AuthService) digest(phone, code string) string {
+	mac := hmac.New(sha256.New, s.pepper)
+	mac.Write([]byte(phone + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate otp: %w", err)
+	}
+	return fmt.Sprintf("%0*d", otpDigits, n.Int64()), nil
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/zhenglizhi/policy-fit/internal/auth"
+	"github.com/zhenglizhi/policy-fit/internal/config"
+	"github.com/zhenglizhi/policy-fit/internal/repository"
+)
+
+const testPhone = "13812345678"
+
+// captureSender 记录各手机号最近一次收到的验证码
+type captureSender struct {
+	codes map[string]string
+}
+
+func (s *captureSender) Send(ctx context.Context, phone, code string) error {
+	s.codes[phone] = code
+	return nil
+}
+
+type testAuth struct {
+	*AuthService
+	mr     *miniredis.Miniredis
+	sender *captureSender
+}
+
+func newAuthService(t *testing.T, otp config.OTPConfig) *testAuth {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	jwt, err := auth.NewJWT("k1", map[string][]byte{"k1": []byte("test-signing-key-0123456789abcdef")}, 30*time.Second)
+	if err != nil {
+		t.Fatalf("NewJWT: %v", err)
+	}
+	cfg := &config.Config{
+		OTP:      otp,
+		Security: config.SecurityConfig{JWTAccessTTL: 900, JWTRefreshTTL: 86400},
+	}
+	sender := &captureSender{codes: make(map[string]string)}
+	s := NewAuthService(repository.NewMemoryStore(), auth.NewOTPStore(rdb), sender, jwt, cfg)
+	return &testAuth{AuthService: s, mr: mr, sender: sender}
+}
+
+func testOTPConfig() config.OTPConfig {
+	return config.OTPConfig{
+		TTL:               300,
+		MaxAttempts:       3,
+		SendInterval:      60,
+		PhoneHourlyLimit:  5,
+		IPHourlyLimit:     10,
+		PhoneFailureLimit: 10,
+		Pepper:            "test-pepper",
+	}
+}
+
+// send 发送验证码并返回发送内容，随后越过发送间隔
+func (a *testAuth) send(t *testing.T, rawPhone string) string {
+	t.Helper()
+	if err := a.SendOTP(context.Background(), rawPhone, "10.0.0.1"); err != nil {
+		t.Fatalf("SendOTP: %v", err)
+	}
+	a.mr.FastForward(time.Duration(a.cfg.SendInterval) * time.Second)
+	phone, _ := NormalizePhone(rawPhone)
+	return a.sender.codes[phone]
+}
+
+// wrongCode 与 code 不同的六位验证码
+func wrongCode(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+func throttled(t *testing.T, err error) {
+	t.Helper()
+	var throttledErr *ThrottledError
+	if !errors.As(err, &throttledErr) || !errors.Is(err, ErrThrottled) || throttledErr.RetryAfter() <= 0 {
+		t.Fatalf("err = %v, want ThrottledError with a retry delay", err)
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	for raw, want := range map[string]string{
+		"13812345678":       "13812345678",
+		" 138-1234-5678 ":   "13812345678",
+		"+86 138 1234 5678": "13812345678",
+		"008613812345678":   "13812345678",
+		"8613812345678":     "13812345678",
+	} {
+		if got, err := NormalizePhone(raw); err != nil || got != want {
+			t.Errorf("NormalizePhone(%q) = %q, %v, want %q", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"", "12812345678", "1381234567", "138123456789", "+1 13812345678"} {
+		if _, err := NormalizePhone(raw); !errors.Is(err, ErrInvalidPhone) {
+			t.Errorf("NormalizePhone(%q): err = %v, want ErrInvalidPhone", raw, err)
+		}
+	}
+}
+
+func TestLogin(t *testing.T) {
+	ctx := context.Background()
+	a := newAuthService(t, testOTPConfig())
+
+	code := a.send(t, "+86 "+testPhone)
+	if len(code) != otpDigits {
+		t.Fatalf("code = %q, want %d digits", code, otpDigits)
+	}
+	// Redis 中只保存绑定手机号的摘要
+	if got := a.mr.HGet("policyfit:otp:"+testPhone, "digest"); got != a.digest(testPhone, code) {
+		t.Errorf("stored digest = %q, want the keyed digest of the code", got)
+	}
+	if a.digest(testPhone, code) == a.digest("13900000000", code) {
+		t.Error("digest does not depend on the phone number")
+	}
+
+	pair, err := a.Login(ctx, testPhone, " "+code+" ", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if pair.TokenType != "Bearer" || pair.ExpiresIn != 900 || pair.AccessToken == "" || pair.RefreshToken == "" {
+		t.Errorf("pair = %+v", pair)
+	}
+	claims, err := a.jwt.Verify(pair.AccessToken, time.Now())
+	if err != nil || claims.UserID != pair.UserID || claims.Use != auth.UseAccess {
+		t.Errorf("access claims = %+v, %v", claims, err)
+	}
+
+	// 验证码只能使用一次
+	if _, err := a.Login(ctx, testPhone, code, "10.0.0.1"); !errors.Is(err, ErrInvalidOTP) {
+		t.Fatalf("reused code: err = %v, want ErrInvalidOTP", err)
+	}
+	// 同一手机号再次登录对应同一用户
+	again, err := a.Login(ctx, testPhone, a.send(t, testPhone), "10.0.0.1")
+	if err != nil || again.UserID != pair.UserID {
+		t.Fatalf("second Login = %+v, %v, want user %d", again, err, pair.UserID)
+	}
+}
+
+func TestLoginAttemptsExhausted(t *testing.T) {
+	ctx := context.Background()
+	a := newAuthService(t, testOTPConfig())
+	code := a.send(t, testPhone)
+
+	// 位数不对的验证码不消耗校验次数
+	for _, c := range []string{"", "12345", "1234567"} {
+		if _, err := a.Login(ctx, testPhone, c, "10.0.0.1"); !errors.Is(err, ErrInvalidOTP) {
+			t.Fatalf("Login(%q): err = %v, want ErrInvalidOTP", c, err)
+		}
+	}
+	for i := 0; i < a.cfg.MaxAttempts; i++ {
+		if _, err := a.Login(ctx, testPhone, wrongCode(code), "10.0.0.1"); !errors.Is(err, ErrInvalidOTP) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidOTP", i+1, err)
+		}
+	}
+	// 错误次数用尽后正确的验证码也已作废
+	if _, err := a.Login(ctx, testPhone, code, "10.0.0.1"); !errors.Is(err, ErrInvalidOTP) {
+		t.Fatalf("err = %v, want ErrInvalidOTP after attempts are exhausted", err)
+	}
+	if _, err := a.Login(ctx, testPhone, a.send(t, testPhone), "10.0.0.1"); err != nil {
+		t.Fatalf("Login with a new code: %v", err)
+	}
+}
+
+func TestLoginPhoneFailureLimit(t *testing.T) {
+	ctx := context.Background()
+	cfg := testOTPConfig()
+	cfg.PhoneFailureLimit = 4
+	a := newAuthService(t, cfg)
+
+	// 失败次数跨验证码累计，换新验证码无法继续猜测
+	for i := 0; i < cfg.PhoneFailureLimit; i++ {
+		code := a.send(t, testPhone)
+		if _, err := a.Login(ctx, testPhone, wrongCode(code), "10.0.0.1"); !errors.Is(err, ErrInvalidOTP) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidOTP", i+1, err)
+		}
+	}
+	code := a.send(t, testPhone)
+	_, err := a.Login(ctx, testPhone, code, "10.0.0.1")
+	throttled(t, err)
+
+	a.mr.FastForward(time.Hour)
+	if _, err := a.Login(ctx, testPhone, a.send(t, testPhone), "10.0.0.1"); err != nil {
+		t.Fatalf("Login after the window: %v", err)
+	}
+}
+
+func TestSendOTPThrottle(t *testing.T) {
+	ctx := context.Background()
+	cfg := testOTPConfig()
+	cfg.IPHourlyLimit = 2
+	a := newAuthService(t, cfg)
+
+	if err := a.SendOTP(ctx, testPhone, "10.0.0.1"); err != nil {
+		t.Fatalf("SendOTP: %v", err)
+	}
+	// 发送间隔内重复发送被拒绝
+	throttled(t, a.SendOTP(ctx, testPhone, "10.0.0.2"))
+	a.mr.FastForward(time.Minute)
+	if err := a.SendOTP(ctx, testPhone, "10.0.0.1"); err != nil {
+		t.Fatalf("SendOTP after the interval: %v", err)
+	}
+	// 同一 IP 每小时次数用尽后换手机号同样被拒绝
+	throttled(t, a.SendOTP(ctx, "13900000000", "10.0.0.1"))
+	if err := a.SendOTP(ctx, "13700000000", "10.0.0.3"); err != nil {
+		t.Fatalf("SendOTP from another ip: %v", err)
+	}
+
+	if err := a.SendOTP(ctx, "123", "10.0.0.1"); !errors.Is(err, ErrInvalidPhone) {
+		t.Fatalf("err = %v, want ErrInvalidPhone", err)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+	a := newAuthService(t, testOTPConfig())
+	pair, err := a.Login(ctx, testPhone, a.send(t, testPhone), "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	refreshed, err := a.Refresh(ctx, pair.RefreshToken)
+	if err != nil || refreshed.UserID != pair.UserID {
+		t.Fatalf("Refresh = %+v, %v", refreshed, err)
+	}
+	// 访问 token 不能用于刷新
+	for _, token := range []string{pair.AccessToken, "", "not.a.token"} {
+		if _, err := a.Refresh(ctx, token); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Errorf("Refresh(%q): err = %v, want ErrInvalidRefreshToken", token, err)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/parser"
+	"github.com/zhenglizhi/policy-fit/internal/repository"
+	"github.com/zhenglizhi/policy-fit/internal/storage"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+)
+
+// AuditActionDocumentUploaded 文档上传审计动作
+const AuditActionDocumentUploaded = "document.uploaded"
+
+// pdfMagic PDF 文件头
+var pdfMagic = []byte("%PDF-")
+
+// maxFileNameLen 与 document.file_name 列长度一致
+const maxFileNameLen = 256
+
+// DocumentService 文档服务
+type DocumentService struct {
+	store   repository.Store
+	storage storage.Storage
+	maxSize int64
+}
+
+// NewDocumentService 创建文档服务，maxSize 为单文件大小上限（字节）
+func NewDocumentService(store repository.Store, st storage.Storage, maxSize int64) *DocumentService {
+	return &DocumentService{
+		store:   store,
+		storage: st,
+		maxSize: maxSize,
+	}
+}
+
+// ParseDocType 校验文档类型
+func ParseDocType(raw string) (domain.DocumentType, error) {
+	switch docType := domain.DocumentType(raw); docType {
+	case domain.DocTypeReport, domain.DocTypePolicy, domain.DocTypeDisclosure:
+		return docType, nil
+	default:
+		return "", ErrUnsupportedDocType
+	}
+}
+
+// Upload 校验并流式写入文档，成功后持久化 ParseStatus=pending 的文档记录。
+// 任务须属于 actorID；文件类型通过 PDF 魔数判断，不信任扩展名。
+func (s *DocumentService) Upload(ctx context.Context, taskID, actorID int64, docType domain.DocumentType, fileName string, r io.Reader) (*domain.Document, error) {
+	if _, err := ParseDocType(string(docType)); err != nil {
+		return nil, err
+	}
+
+	task, err := s.store.Tasks().GetForUser(ctx, taskID, actorID)
+	if err != nil {
+		return nil, translateNotFound(err)
+	}
+	if task.Status != domain.TaskStatusPending {
+		return nil, ErrTaskNotEditable
+	}
+
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(pdfMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if !bytes.Equal(head, pdfMagic) {
+		return nil, ErrNotPDF
+	}
+
+	key := storage.DocumentKey(taskID, docType)
+	if _, err := s.storage.Put(ctx, key, &sizeLimitReader{r: br, remaining: s.maxSize}); err != nil {
+		s.removeObject(ctx, key)
+		return nil, err
+	}
+
+	doc := &domain.Document{
+		TaskID:      taskID,
+		DocType:     docType,
+		FileName:    sanitizeFileName(fileName),
+		StorageKey:  key,
+		ParseStatus: domain.ParseStatusPending,
+	}
+	err = s.store.WithTx(ctx, func(tx repository.Store) error {
+		if err := tx.Documents().Create(ctx, doc); err != nil {
+			return err
+		}
+		return tx.Audits().Create(ctx, &domain.AuditLog{
+			TaskID:     taskID,
+			ActorID:    actorID,
+			Action:     AuditActionDocumentUploaded,
+			TargetType: "document",
+			TargetID:   strconv.FormatInt(doc.ID, 10),
+			Detail:     map[string]interface{}{"doc_type": docType},
+		})
+	})
+	if err != nil {
+		s.removeObject(ctx, key)
+		return nil, translateNotFound(err)
+	}
+	return doc, nil
+}
+
+// ParsedDocument 文档解析摘要，作为 parsing 阶段的检查点产出
+type ParsedDocument struct {
+	DocumentID int64               `json:"document_id"`
+	DocType    domain.DocumentType `json:"doc_type"`
+	Pages      int                 `json:"pages"`
+	Paragraphs int                 `json:"paragraphs"`
+}
+
+// ParseDocuments 解析任务下的全部文档，解析结果写入 document.parsed_text 与 document.parsed_json。
+// 解析失败时文档置为 failed 并返回 *parser.Error（携带失败码），重试时重新解析。
+func (s *DocumentService) ParseDocuments(ctx context.Context, taskID int64, p parser.Parser) ([]ParsedDocument, error) {
+	docs, err := s.store.Documents().ListByTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := make([]ParsedDocument, 0, len(docs))
+	for _, doc := range docs {
+		result, err := s.parseDocument(ctx, doc, p)
+		if err != nil {
+			var parseErr *parser.Error
+			if errors.As(err, &parseErr) {
+				if updateErr := s.store.Documents().UpdateParseStatus(ctx, doc.ID, domain.ParseStatusFailed, "", nil); updateErr != nil {
+					logger.FromContext(ctx).Warn("Failed to mark document parse failed", "document_id", doc.ID, "error", updateErr)
+				}
+			}
+			return nil, fmt.Errorf("document %d (%s): %w", doc.ID, doc.DocType, err)
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.store.Documents().UpdateParseStatus(ctx, doc.ID, domain.ParseStatusSuccess, result.Text(), data); err != nil {
+			return nil, err
+		}
+		logger.FromContext(ctx).Info("Document parsed",
+			"document_id", doc.ID,
+			"parser", result.Parser,
+			"pages", result.Pages,
+			"paragraphs", len(result.Paragraphs),
+		)
+		parsed = append(parsed, ParsedDocument{
+			DocumentID: doc.ID,
+			DocType:    doc.DocType,
+			Pages:      result.Pages,
+			Paragraphs: len(result.Paragraphs),
+		})
+	}
+	return parsed, nil
+}
+
+// ParseResult 已解析文档及其段落
+type ParseResult struct {
+	Document domain.Document
+	Result   *parser.Result
+}
+
+// LoadParseResults 读取任务下全部文档的解析结果，供 parsing 之后的阶段使用，不重新解析
+func (s *DocumentService) LoadParseResults(ctx context.Context, taskID int64) ([]ParseResult, error) {
+	docs, err := s.store.Documents().ListByTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ParseResult, 0, len(docs))
+	for _, doc := range docs {
+		if doc.ParseStatus != domain.ParseStatusSuccess || len(doc.ParsedJSON) == 0 {
+			return nil, fmt.Errorf("document %d (%s) has not been parsed", doc.ID, doc.DocType)
+		}
+		result, err := parser.Decode(doc.ParsedJSON)
+		if err != nil {
+			return nil, fmt.Errorf("document %d (%s): %w", doc.ID, doc.DocType, err)
+		}
+		results = append(results, ParseResult{Document: doc, Result: result})
+	}
+	return results, nil
+}
+
+func (s *DocumentService) parseDocument(ctx context.Context, doc domain.Document, p parser.Parser) (*parser.Result, error) {
+	rc, err := s.storage.Get(ctx, doc.StorageKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	defer rc.Close()
+	return p.Parse(ctx, doc.DocType, rc)
+}
+
+// PurgeTaskObjects 删除任务下的全部存储对象，任务记录删除后调用
+func (s *DocumentService) PurgeTaskObjects(ctx context.Context, taskID int64) error {
+	n, err := storage.DeletePrefix(ctx, s.storage, storage.TaskPrefix(taskID))
+	if err != nil {
+		return err
+	}
+	logger.FromContext(ctx).Info("Task objects purged", "task_id", taskID, "count", n)
+	return nil
+}
+
+// removeObject 清理写入失败或未能落库的对象
+func (s *DocumentService) removeObject(ctx context.Context, key string) {
+	if err := s.storage.Delete(context.WithoutCancel(ctx), key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		logger.FromContext(ctx).Warn("Failed to remove orphan object", "storage_key", key, "error", err)
+	}
+}
+
+func sanitizeFileName(name string) string {
+	name = filepath.Base(filepath.Clean("/" + name))
+	if name == "/" || name == "." {
+		name = "document.pdf"
+	}
+	for len(name) > maxFileNameLen {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// sizeLimitReader 超过上限时返回 ErrFileTooLarge，而不是静默截断
+type sizeLimitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrFileTooLarge
+	}
+	return n, err
+}
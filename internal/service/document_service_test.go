@@ -0,0 +1,134 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/repository"
+	"github.com/zhenglizhi/policy-fit/internal/storage"
+)
+
+const testMaxUpload = 1024
+
+func newDocumentService(t *testing.T) (*DocumentService, *TaskService, *repository.MemoryStore, storage.Storage) {
+	t.Helper()
+	st, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	tasks, store, _ := newTaskService(t)
+	return NewDocumentService(store, st, testMaxUpload), tasks, store, st
+}
+
+// pdf 生成以 PDF 魔数开头、总长 size 字节的内容
+func pdf(size int) []byte {
+	data := bytes.Repeat([]byte("x"), size)
+	copy(data, "%PDF-1.7\n")
+	return data
+}
+
+func TestUpload(t *testing.T) {
+	tests := []struct {
+		name    string
+		content io.Reader
+		wantErr error
+	}{
+		{"pdf", bytes.NewReader(pdf(100)), nil},
+		{"exactly the limit", bytes.NewReader(pdf(testMaxUpload)), nil},
+		{"one byte over the limit", bytes.NewReader(pdf(testMaxUpload + 1)), ErrFileTooLarge},
+		{"far over the limit", bytes.NewReader(pdf(10 * testMaxUpload)), ErrFileTooLarge},
+		{"one byte at a time", iotest.OneByteReader(bytes.NewReader(pdf(testMaxUpload + 1))), ErrFileTooLarge},
+		{"magic only", strings.NewReader("%PDF-"), nil},
+		{"not a pdf", strings.NewReader("<html>%PDF-1.7</html>"), ErrNotPDF},
+		{"truncated magic", strings.NewReader("%PD"), ErrNotPDF},
+		{"empty", strings.NewReader(""), ErrNotPDF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, tasks, store, st := newDocumentService(t)
+			task, _, err := tasks.CreateTask(ctx, 1, Idempotency{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			doc, err := s.Upload(ctx, task.ID, 1, domain.DocTypeReport, "report.pdf", tt.content)
+			objects, listErr := st.List(ctx, storage.TaskPrefix(task.ID))
+			if listErr != nil {
+				t.Fatalf("List: %v", listErr)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Upload: err = %v, want %v", err, tt.wantErr)
+				}
+				// 拒绝的上传不留下对象与文档记录
+				if len(objects) != 0 {
+					t.Errorf("objects = %+v, want none", objects)
+				}
+				if docs, _ := store.Documents().ListByTask(ctx, task.ID); len(docs) != 0 {
+					t.Errorf("documents = %d, want none", len(docs))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Upload: %v", err)
+			}
+			if len(objects) != 1 || objects[0].Key != doc.StorageKey || doc.ParseStatus != domain.ParseStatusPending {
+				t.Fatalf("doc = %+v, objects = %+v", doc, objects)
+			}
+		})
+	}
+}
+
+func TestUploadRejects(t *testing.T) {
+	ctx := context.Background()
+	s, tasks, _, _ := newDocumentService(t)
+	task := createRunnableTask(t, tasks, tasks.store, 1)
+	upload := func(taskID, actorID int64, docType domain.DocumentType) error {
+		_, err := s.Upload(ctx, taskID, actorID, docType, "a.pdf", bytes.NewReader(pdf(10)))
+		return err
+	}
+
+	if err := upload(task.ID, 1, "invoice"); !errors.Is(err, ErrUnsupportedDocType) {
+		t.Errorf("unsupported type: err = %v, want ErrUnsupportedDocType", err)
+	}
+	if err := upload(task.ID, 2, domain.DocTypeReport); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("another user's task: err = %v, want ErrTaskNotFound", err)
+	}
+	if err := upload(task.ID+1000, 1, domain.DocTypeReport); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("missing task: err = %v, want ErrTaskNotFound", err)
+	}
+	if _, err := tasks.Transition(ctx, task.ID, domain.TaskStatusParsing); err != nil {
+		t.Fatal(err)
+	}
+	if err := upload(task.ID, 1, domain.DocTypeReport); !errors.Is(err, ErrTaskNotEditable) {
+		t.Errorf("task in progress: err = %v, want ErrTaskNotEditable", err)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	long := strings.Repeat("体", 100) + ".pdf"
+	tests := map[string]string{
+		"report.pdf":           "report.pdf",
+		"../../etc/passwd":     "passwd",
+		"/abs/path/policy.pdf": "policy.pdf",
+		"":                     "document.pdf",
+		"..":                   "document.pdf",
+		"/":                    "document.pdf",
+	}
+	for name, want := range tests {
+		if got := sanitizeFileName(name); got != want {
+			t.Errorf("sanitizeFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+	got := sanitizeFileName(long)
+	if len(got) > maxFileNameLen || !strings.HasPrefix(long, got) || !strings.HasSuffix(got, "体") {
+		t.Errorf("sanitizeFileName(long) = %d bytes, want truncated at a rune boundary within %d bytes", len(got), maxFileNameLen)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/pkg/response"
+)
+
+// 面向接口的领域错误携带业务错误码，HTTP 状态与用户提示见 pkg/response 错误码目录
+var (
+	// ErrTaskNotFound 任务不存在
+	ErrTaskNotFound = response.NewError(response.CodeTaskNotFound, "task not found")
+	// ErrIllegalTransition 任务状态流转不合法
+	ErrIllegalTransition = response.NewError(response.CodeTaskStateConflict, "illegal task status transition")
+	// ErrMissingDocuments 任务缺少必要文档
+	ErrMissingDocuments = response.NewError(response.CodeTaskMissingDocuments, "task is missing required documents")
+	// ErrIdempotencyConflict 幂等键已用于不同的请求
+	ErrIdempotencyConflict = response.NewError(response.CodeIdempotencyConflict, "idempotency key reused with a different request")
+	// ErrTaskNotEditable 任务已开始分析，不允许再上传文档
+	ErrTaskNotEditable = response.NewError(response.CodeTaskStateConflict, "documents can only be uploaded while the task is pending")
+	// ErrUnsupportedDocType 文档类型不支持
+	ErrUnsupportedDocType = response.NewError(response.CodeUnsupportedDocType, "unsupported doc_type (allowed: report, policy, disclosure)")
+	// ErrNotPDF 文件不是 PDF
+	ErrNotPDF = response.NewError(response.CodeUnsupportedFileFormat, "file is not a PDF")
+	// ErrFileTooLarge 文件超过大小上限
+	ErrFileTooLarge = response.NewError(response.CodeFileTooLarge, "file exceeds the maximum upload size")
+	// ErrInvalidPhone 手机号格式不正确
+	ErrInvalidPhone = response.NewError(response.CodeInvalidParam, "invalid phone number")
+	// ErrInvalidOTP 验证码错误、已过期或错误次数已用尽
+	ErrInvalidOTP = response.NewError(response.CodeUnauthorized, "invalid or expired verification code")
+	// ErrInvalidRefreshToken 刷新 token 无效或已过期
+	ErrInvalidRefreshToken = response.NewError(response.CodeUnauthorized, "invalid or expired refresh token")
+	// ErrThrottled 请求过于频繁
+	ErrThrottled = response.NewError(response.CodeRateLimited, "too many requests")
+	// ErrQuotaExceeded 用户分析次数已用完
+	ErrQuotaExceeded = response.NewError(response.CodeQuotaExceeded, "analysis quota exceeded")
+	// ErrLLMUnavailable 模型服务限流或不可用，llm 错误可通过 errors.Is 与之比较
+	ErrLLMUnavailable = response.NewError(response.CodeLLMUnavailable, "llm provider unavailable")
+)
+
+// ErrCheckpointNotFound 阶段尚未完成，没有检查点（内部错误，不对外暴露）
+var ErrCheckpointNotFound = errors.New("stage checkpoint not found")
+
+// TransitionError 非法状态流转，可通过 errors.Is(err, ErrIllegalTransition) 判断
+type TransitionError struct {
+	From domain.TaskStatus
+	To   domain.TaskStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("illegal task status transition: %s -> %s", e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrIllegalTransition
+}
+
+// MissingDocumentsError 缺少的文档类型，可通过 errors.Is(err, ErrMissingDocuments) 判断
+type MissingDocumentsError struct {
+	Missing []domain.DocumentType
+}
+
+func (e *MissingDocumentsError) Error() string {
+	types := make([]string, 0, len(e.Missing))
+	for _, t := range e.Missing {
+		types = append(types, string(t))
+	}
+	return fmt.Sprintf("task is missing required documents: %s", strings.Join(types, ", "))
+}
+
+func (e *MissingDocumentsError) Unwrap() error {
+	return ErrMissingDocuments
+}
+
+// ThrottledError 请求超出频率限制，可通过 errors.Is(err, ErrThrottled) 判断
+type ThrottledError struct {
+	// Wait 距离限流窗口结束的时长
+	Wait time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("too many requests, retry after %s", e.Wait.Round(time.Second))
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return ErrThrottled
+}
+
+// RetryAfter 渲染响应时写入 Retry-After
+func (e *ThrottledError) RetryAfter() time.Duration {
+	return e.Wait
+}
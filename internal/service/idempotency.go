@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/repository"
+)
+
+// 幂等作用域
+const (
+	idempotencyScopeCreate = "task.create"
+	idempotencyScopeRun    = "task.run"
+)
+
+// Idempotency 幂等请求参数，Key 为空表示不启用幂等
+type Idempotency struct {
+	Key string
+	// RequestHash 请求载荷摘要，同一 Key 对应不同载荷时拒绝
+	RequestHash string
+}
+
+// withIdempotency 在事务中执行 op，并将结果快照与幂等记录一并提交。
+// 命中已有记录时直接返回快照；并发请求以唯一约束兜底。
+func (s *TaskService) withIdempotency(
+	ctx context.Context,
+	userID int64,
+	scope string,
+	taskID int64,
+	idem Idempotency,
+	op func(tx repository.Store) (*domain.AnalysisTask, error),
+) (*domain.AnalysisTask, bool, error) {
+	if idem.Key != "" {
+		task, ok, err := s.replay(ctx, userID, scope, taskID, idem)
+		if err != nil || ok {
+			return task, ok, err
+		}
+	}
+
+	var task *domain.AnalysisTask
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		var err error
+		if task, err = op(tx); err != nil {
+			return err
+		}
+		if idem.Key == "" {
+			return nil
+		}
+
+		snapshot, err := json.Marshal(task)
+		if err != nil {
+			return fmt.Errorf("failed to marshal idempotency snapshot: %w", err)
+		}
+		return tx.Idempotency().Create(ctx, &domain.IdempotencyRecord{
+			UserID:      userID,
+			Scope:       scope,
+			Key:         idem.Key,
+			RequestHash: idem.RequestHash,
+			TaskID:      task.ID,
+			Response:    snapshot,
+		})
+	})
+	if idem.Key != "" && errors.Is(err, repository.ErrDuplicate) {
+		// 并发的相同请求已先行提交
+		task, ok, replayErr := s.replay(ctx, userID, scope, taskID, idem)
+		if replayErr != nil || ok {
+			return task, ok, replayErr
+		}
+		// 幂等记录已被清理，但该用户的任务仍占用此幂等键
+		return nil, false, ErrIdempotencyConflict
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return task, false, nil
+}
+
+func (s *TaskService) replay(ctx context.Context, userID int64, scope string, taskID int64, idem Idempotency) (*domain.AnalysisTask, bool, error) {
+	record, err := s.store.Idempotency().Get(ctx, userID, scope, idem.Key)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if record.RequestHash != idem.RequestHash || (taskID != 0 && record.TaskID != taskID) {
+		return nil, false, ErrIdempotencyConflict
+	}
+
+	var task domain.AnalysisTask
+	if err := json.Unmarshal(record.Response, &task); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal idempotency snapshot: %w", err)
+	}
+	return &task, true, nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+func TestCreateTaskIdempotency(t *testing.T) {
+	ctx := context.Background()
+	s, _, _ := newTaskService(t)
+	idem := Idempotency{Key: "create-1", RequestHash: "h1"}
+
+	first, replayed, err := s.CreateTask(ctx, 1, idem)
+	if err != nil || replayed {
+		t.Fatalf("CreateTask = %v, replayed %v", err, replayed)
+	}
+	if first.RequestID != idem.Key {
+		t.Errorf("RequestID = %q, want the idempotency key", first.RequestID)
+	}
+
+	again, replayed, err := s.CreateTask(ctx, 1, idem)
+	if err != nil || !replayed || again.ID != first.ID {
+		t.Fatalf("replay = task %d, replayed %v, %v, want task %d replayed", again.ID, replayed, err, first.ID)
+	}
+
+	if _, _, err := s.CreateTask(ctx, 1, Idempotency{Key: "create-1", RequestHash: "h2"}); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Fatalf("different payload: err = %v, want ErrIdempotencyConflict", err)
+	}
+
+	// 幂等键按用户隔离
+	other, replayed, err := s.CreateTask(ctx, 2, idem)
+	if err != nil || replayed || other.ID == first.ID {
+		t.Fatalf("other user = task %d, replayed %v, %v, want a new task", other.ID, replayed, err)
+	}
+
+	// 不带幂等键的请求每次创建新任务
+	a, _, _ := s.CreateTask(ctx, 1, Idempotency{})
+	b, _, _ := s.CreateTask(ctx, 1, Idempotency{})
+	if a.ID == b.ID {
+		t.Fatal("requests without a key share a task")
+	}
+}
+
+func TestRunTaskIdempotency(t *testing.T) {
+	ctx := context.Background()
+	s, store, queue := newTaskService(t)
+	task := createRunnableTask(t, s, store, 1)
+	idem := Idempotency{Key: "run-1", RequestHash: "h1"}
+
+	if _, replayed, err := s.RunTask(ctx, task.ID, 1, idem); err != nil || replayed {
+		t.Fatalf("RunTask = %v, replayed %v", err, replayed)
+	}
+	// 任务仍为 pending 时重放会再次投递，弥补上次投递失败
+	if _, replayed, err := s.RunTask(ctx, task.ID, 1, idem); err != nil || !replayed {
+		t.Fatalf("replay = %v, replayed %v", err, replayed)
+	}
+	if queue.count() != 2 {
+		t.Fatalf("jobs = %d, want 2 while the task is pending", queue.count())
+	}
+
+	// 任务已开始处理后重放不再投递
+	if _, err := s.Transition(ctx, task.ID, domain.TaskStatusParsing); err != nil {
+		t.Fatal(err)
+	}
+	if _, replayed, err := s.RunTask(ctx, task.ID, 1, idem); err != nil || !replayed {
+		t.Fatalf("replay after start = %v, replayed %v", err, replayed)
+	}
+	if queue.count() != 2 {
+		t.Fatalf("jobs = %d, want no new job once the task has started", queue.count())
+	}
+
+	if _, _, err := s.RunTask(ctx, task.ID, 1, Idempotency{Key: "run-1", RequestHash: "h2"}); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Fatalf("different payload: err = %v, want ErrIdempotencyConflict", err)
+	}
+	// 同一幂等键用于另一个任务
+	other := createRunnableTask(t, s, store, 1)
+	if _, _, err := s.RunTask(ctx, other.ID, 1, idem); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Fatalf("another task: err = %v, want ErrIdempotencyConflict", err)
+	}
+}
+
+func TestRunTaskFailureNotRecorded(t *testing.T) {
+	ctx := context.Background()
+	s, store, queue := newTaskService(t)
+	task, _, err := s.CreateTask(ctx, 1, Idempotency{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	idem := Idempotency{Key: "run-1", RequestHash: "h1"}
+
+	// 校验失败的请求不写幂等记录，补齐文档后可用同一幂等键重试
+	if _, _, err := s.RunTask(ctx, task.ID, 1, idem); !errors.Is(err, ErrMissingDocuments) {
+		t.Fatalf("err = %v, want ErrMissingDocuments", err)
+	}
+	for _, docType := range requiredDocTypes {
+		if err := store.Documents().Create(ctx, &domain.Document{TaskID: task.ID, DocType: docType}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, replayed, err := s.RunTask(ctx, task.ID, 1, idem); err != nil || replayed {
+		t.Fatalf("retry = %v, replayed %v, want a fresh run", err, replayed)
+	}
+	if queue.count() != 1 {
+		t.Fatalf("jobs = %d, want 1", queue.count())
+	}
+}
@@ -0,0 +1,15 @@
+package service
+
+import "context"
+
+// AnalysisJob 分析任务消息
+type AnalysisJob struct {
+	TaskID     int64
+	RequestID  string
+	RetryCount int
+}
+
+// JobQueue 分析任务队列，由 jobs 包基于 Redis Streams 实现
+type JobQueue interface {
+	Enqueue(ctx context.Context, job AnalysisJob) error
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/extract"
+	"github.com/zhenglizhi/policy-fit/internal/repository"
+	"github.com/zhenglizhi/policy-fit/internal/ruleengine"
+)
+
+// AuditActionReportGenerated 报告生成审计动作
+const AuditActionReportGenerated = "report.generated"
+
+const (
+	// maxEvidence 每条风险发现保留的体检证据与条款证据条数
+	maxEvidence = 3
+	// maxFindingQuestions 每条风险发现的追问问题上限（PRD §7.3）
+	maxFindingQuestions = 5
+	// maxFindingActions 每条风险发现的建议行动上限
+	maxFindingActions = 3
+
+	// keywordFallbackPrompt 关键词降级抽取时记录的提示词版本
+	keywordFallbackPrompt = "keyword_fallback"
+)
+
+// levelOrder 风险发现按红、黄、绿排序（PRD §22.3）
+var levelOrder = map[domain.RiskLevel]int{
+	domain.RiskLevelRed:    0,
+	domain.RiskLevelYellow: 1,
+	domain.RiskLevelGreen:  2,
+}
+
+// levelActions 各等级的建议行动：补材料、咨询客服、补充告知（PRD §7.3）
+var levelActions = map[domain.RiskLevel][]string{
+	domain.RiskLevelRed:    {"核对投保时是否已如实告知，保留告知记录或截图", "联系保险公司核保或客服，确认条款适用范围"},
+	domain.RiskLevelYellow: {"按体检建议复查相关指标并保留报告", "向保险公司客服咨询条款适用范围"},
+	domain.RiskLevelGreen:  {"妥善保存体检报告与保单原件"},
+}
+
+// actionUploadPolicy 主题没有关联条款原文时的建议行动
+const actionUploadPolicy = "补充上传完整的保险条款或投保告知书"
+
+// Report matching 阶段产出：风险发现、风险摘要与生成所用版本。
+// 作为阶段检查点写入，同时实现 StageResult，与状态推进在同一事务中落库。
+type Report struct {
+	Findings []domain.RiskFinding `json:"findings"`
+	// RiskSummary 各等级风险发现数量，键为 red、yellow、green
+	RiskSummary   map[string]int `json:"risk_summary"`
+	RuleVersion   string         `json:"rule_version"`
+	PromptVersion string         `json:"prompt_version"`
+}
+
+// Persist 替换任务的风险发现，写入风险摘要与报告版本，并记录审计日志
+func (r *Report) Persist(ctx context.Context, tx repository.Store, taskID int64) error {
+	if err := tx.Findings().DeleteByTask(ctx, taskID); err != nil {
+		return err
+	}
+	if len(r.Findings) > 0 {
+		if err := tx.Findings().BatchCreate(ctx, r.Findings); err != nil {
+			return translateNotFound(err)
+		}
+	}
+	if err := tx.Tasks().UpdateReport(ctx, taskID, r.RiskSummary, r.RuleVersion, r.PromptVersion); err != nil {
+		return translateNotFound(err)
+	}
+	return tx.Audits().Create(ctx, &domain.AuditLog{
+		TaskID:     taskID,
+		Action:     AuditActionReportGenerated,
+		TargetType: auditTargetTask,
+		TargetID:   strconv.FormatInt(taskID, 10),
+		Detail: map[string]interface{}{
+			"findings":       len(r.Findings),
+			"risk_summary":   r.RiskSummary,
+			"rule_version":   r.RuleVersion,
+			"prompt_version": r.PromptVersion,
+		},
+	})
+}
+
+// ReportService 将规则引擎评估结果组装为风险报告
+type ReportService struct {
+	rules *ruleengine.Engine
+}
+
+// NewReportService 创建报告服务
+func NewReportService(rules *ruleengine.Engine) *ReportService {
+	return &ReportService{rules: rules}
+}
+
+// Generate 评估抽取结果并组装报告：每个命中主题一条风险发现，经护栏定级后生成说明与建议，
+// 按红、黄、绿排序。报告记录规则集版本与抽取所用提示词版本。
+func (s *ReportService) Generate(ctx context.Context, taskID int64, facts *extract.Output) *Report {
+	report := &Report{
+		RiskSummary: map[string]int{
+			string(domain.RiskLevelRed):    0,
+			string(domain.RiskLevelYellow): 0,
+			string(domain.RiskLevelGreen):  0,
+		},
+		RuleVersion:   s.rules.Version(),
+		PromptVersion: promptVersion(facts),
+	}
+	guardrails := s.rules.Guardrails()
+	for _, result := range s.rules.Evaluate(facts.HealthFacts, facts.PolicyFacts) {
+		topic, ok := s.rules.Topic(result.Topic)
+		if !ok {
+			continue
+		}
+		f := domain.RiskFinding{
+			TaskID:         taskID,
+			Level:          result.Level,
+			Topic:          result.Topic,
+			HealthEvidence: healthEvidence(result),
+			PolicyEvidence: policyEvidence(result.PolicyFacts),
+			Confidence:     findingConfidence(result),
+		}
+		// 先定级，再按最终等级生成说明与建议，最后拦截禁用表述
+		guardrails.Grade(&f)
+		f.Summary = findingSummary(topic, f.Level, result.PolicyFacts)
+		f.Questions = findingQuestions(topic, result.PolicyFacts)
+		f.Actions = findingActions(f.Level, len(f.PolicyEvidence) > 0)
+		guardrails.Sanitize(ctx, &f)
+
+		report.Findings = append(report.Findings, f)
+		report.RiskSummary[string(f.Level)]++
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return levelOrder[report.Findings[i].Level] < levelOrder[report.Findings[j].Level]
+	})
+	return report
+}
+
+// promptVersion 抽取所用提示词版本，多个版本以逗号分隔
+func promptVersion(facts *extract.Output) string {
+	if facts.KeywordFallback {
+		return keywordFallbackPrompt
+	}
+	return strings.Join(facts.Prompts, ",")
+}
+
+// healthEvidence 体检证据，触发条件的事实在前，同一段落只保留一条
+func healthEvidence(result ruleengine.TopicResult) []domain.Evidence {
+	fired := make(map[string]bool, len(result.Fired))
+	for _, f := range result.Fired {
+		fired[f.Loc] = true
+	}
+	facts := append([]domain.HealthFact(nil), result.HealthFacts...)
+	sort.SliceStable(facts, func(i, j int) bool {
+		return fired[facts[i].Evidence.Loc] && !fired[facts[j].Evidence.Loc]
+	})
+
+	var evidence []domain.Evidence
+	seen := make(map[string]bool)
+	for _, f := range facts {
+		if len(evidence) == maxEvidence {
+			break
+		}
+		if seen[f.Evidence.Loc] {
+			continue
+		}
+		seen[f.Evidence.Loc] = true
+		evidence = append(evidence, domain.Evidence{Loc: f.Evidence.Loc, Text: f.Evidence.Text})
+	}
+	return evidence
+}
+
+// policyEvidence 条款证据，沿用规则引擎的排序（提及主题关键词的条款在前）
+func policyEvidence(facts []domain.PolicyFact) []domain.Evidence {
+	var evidence []domain.Evidence
+	seen := make(map[string]bool)
+	for _, f := range facts {
+		if len(evidence) == maxEvidence {
+			break
+		}
+		if seen[f.Loc] {
+			continue
+		}
+		seen[f.Loc] = true
+		evidence = append(evidence, domain.Evidence{Loc: f.Loc, Text: f.Content})
+	}
+	return evidence
+}
+
+// findingConfidence 体检事实的最高置信度；有条款事实时再与条款事实的最高置信度取较小值，
+// 红色结论要求两侧证据都足够可靠
+func findingConfidence(result ruleengine.TopicResult) float64 {
+	var health, policy float64
+	for _, f := range result.HealthFacts {
+		if f.Confidence > health {
+			health = f.Confidence
+		}
+	}
+	if len(result.PolicyFacts) == 0 {
+		return health
+	}
+	for _, f := range result.PolicyFacts {
+		if f.Confidence > policy {
+			policy = f.Confidence
+		}
+	}
+	if policy < health {
+		return policy
+	}
+	return health
+}
+
+// findingSummary 一句话风险说明，不含确定性理赔表述
+func findingSummary(topic *ruleengine.Topic, level domain.RiskLevel, policy []domain.PolicyFact) string {
+	label := topic.Label
+	if label == "" {
+		label = topic.Name
+	}
+	clauses := policyTypeNames(policy)
+	switch level {
+	case domain.RiskLevelRed:
+		return fmt.Sprintf("%s：体检记录与条款中的%s可能存在冲突，建议在投保或理赔前重点核实", label, clauses)
+	case domain.RiskLevelYellow:
+		if len(policy) == 0 {
+			return fmt.Sprintf("%s：体检存在异常或信息不完整，未找到对应的条款原文，需要补充确认", label)
+		}
+		return fmt.Sprintf("%s：指标异常或诊断、时间信息不明确，与%s的关系需要补充确认", label, clauses)
+	default:
+		return fmt.Sprintf("%s：当前证据下暂未发现高风险冲突", label)
+	}
+}
+
+// policyTypeNames 条款事实涉及的前两种条款类型中文名
+func policyTypeNames(policy []domain.PolicyFact) string {
+	var names []string
+	for _, f := range policy {
+		name := extract.PolicyTypeLabel(f.Type)
+		if !containsString(names, name) {
+			names = append(names, name)
+		}
+		if len(names) == 2 {
+			break
+		}
+	}
+	if len(names) == 0 {
+		return "相关约定"
+	}
+	return strings.Join(names, "、")
+}
+
+// findingQuestions 主题配置的追问问题，不足上限时补充条款事实中需向保险公司确认的问题
+func findingQuestions(topic *ruleengine.Topic, policy []domain.PolicyFact) []string {
+	questions := append([]string(nil), topic.Questions...)
+	for _, f := range policy {
+		for _, q := range f.Questions {
+			if len(questions) == maxFindingQuestions {
+				return questions
+			}
+			if !containsString(questions, q) {
+				questions = append(questions, q)
+			}
+		}
+	}
+	return questions
+}
+
+// findingActions 按等级给出建议行动，缺少条款原文时优先提示补充上传
+func findingActions(level domain.RiskLevel, hasPolicy bool) []string {
+	var actions []string
+	if !hasPolicy {
+		actions = append(actions, actionUploadPolicy)
+	}
+	for _, a := range levelActions[level] {
+		if len(actions) == maxFindingActions {
+			break
+		}
+		actions = append(actions, a)
+	}
+	return actions
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/extract"
+	"github.com/zhenglizhi/policy-fit/internal/repository"
+	"github.com/zhenglizhi/policy-fit/internal/ruleengine"
+)
+
+const testRules = `version: "test.1"
+guardrails:
+  min_confidence: 0.6
+  forbidden_phrases: ["必赔"]
+topics:
+  hypertension:
+    label: 高血压
+    hit_keywords: ["血压"]
+    policy_types: ["exclusion"]
+    questions: ["h1", "h2", "h3", "h4"]
+    red_conditions:
+      - "diagnosed == true"
+  diabetes:
+    label: 血糖异常
+    hit_keywords: ["血糖"]
+    policy_types: ["waiting_period"]
+    questions: ["d1", "d2"]
+    red_conditions:
+      - "diagnosed == true"
+  thyroid:
+    label: 甲状腺结节
+    hit_keywords: ["甲状腺"]
+    policy_types: ["waiting_period"]
+    questions: ["t1", "t2"]
+    red_conditions:
+      - "diagnosed == true"
+`
+
+func newReportService(t *testing.T) *ReportService {
+	t.Helper()
+	rules, err := ruleengine.Parse("topics.yaml", []byte(testRules))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return NewReportService(rules)
+}
+
+func fact(category, loc string, diagnosed bool, confidence float64) domain.HealthFact {
+	return domain.HealthFact{
+		Category:   category,
+		Evidence:   domain.EvidenceDetail{Text: category + " " + loc, Date: "2026-05-01", Loc: loc, Source: "report"},
+		Diagnosed:  &diagnosed,
+		Confidence: confidence,
+	}
+}
+
+// testFacts 高血压有条款证据定为红色，血糖缺少条款证据降为黄色，甲状腺未命中条件为绿色
+func testFacts() *extract.Output {
+	return &extract.Output{
+		HealthFacts: []domain.HealthFact{
+			fact("thyroid", "p1/para_9", false, 0.9),
+			fact("diabetes", "p1/para_8", true, 0.9),
+			fact("hypertension", "p1/para_2", true, 0.9),
+			fact("hypertension", "p1/para_2", true, 0.7),
+			fact("hypertension", "p1/para_3", false, 0.7),
+			fact("hypertension", "p1/para_4", false, 0.7),
+			fact("hypertension", "p1/para_5", false, 0.7),
+		},
+		PolicyFacts: []domain.PolicyFact{
+			{Type: "exclusion", Content: "既往症不在保障范围内", Loc: "p2/para_1", Confidence: 0.8, Questions: []string{"h1", "p1", "p2"}},
+		},
+		Prompts: []string{"health_facts.v2", "policy_facts.v1"},
+	}
+}
+
+func TestGenerateReport(t *testing.T) {
+	s := newReportService(t)
+	report := s.Generate(context.Background(), 7, testFacts())
+
+	if report.RuleVersion != s.rules.Version() || report.PromptVersion != "health_facts.v2,policy_facts.v1" {
+		t.Errorf("versions = %s, %s", report.RuleVersion, report.PromptVersion)
+	}
+	if want := map[string]int{"red": 1, "yellow": 1, "green": 1}; !reflect.DeepEqual(report.RiskSummary, want) {
+		t.Errorf("risk_summary = %v, want %v", report.RiskSummary, want)
+	}
+	var topics []string
+	for _, f := range report.Findings {
+		topics = append(topics, string(f.Level)+":"+f.Topic)
+		if f.TaskID != 7 {
+			t.Errorf("%s task_id = %d", f.Topic, f.TaskID)
+		}
+	}
+	if want := []string{"red:hypertension", "yellow:diabetes", "green:thyroid"}; !reflect.DeepEqual(topics, want) {
+		t.Fatalf("findings = %v, want %v", topics, want)
+	}
+
+	red := report.Findings[0]
+	// 触发条件的体检证据在前，同一段落只保留一条，最多三条
+	var locs []string
+	for _, e := range red.HealthEvidence {
+		locs = append(locs, e.Loc)
+	}
+	if want := []string{"p1/para_2", "p1/para_3", "p1/para_4"}; !reflect.DeepEqual(locs, want) {
+		t.Errorf("health evidence = %v, want %v", locs, want)
+	}
+	if len(red.PolicyEvidence) != 1 || red.PolicyEvidence[0].Text != "既往症不在保障范围内" {
+		t.Errorf("policy evidence = %+v", red.PolicyEvidence)
+	}
+	if red.Confidence != 0.8 {
+		t.Errorf("confidence = %v, want the lower of health 0.9 and policy 0.8", red.Confidence)
+	}
+	// 主题问题在前，条款问题去重补足，不超过上限
+	if want := []string{"h1", "h2", "h3", "h4", "p1"}; !reflect.DeepEqual(red.Questions, want) {
+		t.Errorf("questions = %v, want %v", red.Questions, want)
+	}
+	if !reflect.DeepEqual(red.Actions, levelActions[domain.RiskLevelRed]) {
+		t.Errorf("red actions = %v", red.Actions)
+	}
+
+	yellow := report.Findings[1]
+	if len(yellow.Downgrades) != 1 || yellow.Downgrades[0].Rule != ruleengine.RuleMissingEvidence {
+		t.Errorf("downgrades = %+v, want missing evidence", yellow.Downgrades)
+	}
+	// 缺少条款原文时先提示补充上传，建议不超过上限
+	if len(yellow.Actions) != maxFindingActions || yellow.Actions[0] != actionUploadPolicy {
+		t.Errorf("yellow actions = %v", yellow.Actions)
+	}
+	green := report.Findings[2]
+	if want := []string{actionUploadPolicy, levelActions[domain.RiskLevelGreen][0]}; !reflect.DeepEqual(green.Actions, want) {
+		t.Errorf("green actions = %v, want %v", green.Actions, want)
+	}
+}
+
+func TestGenerateReportKeywordFallback(t *testing.T) {
+	facts := &extract.Output{KeywordFallback: true}
+	report := newReportService(t).Generate(context.Background(), 7, facts)
+	if report.PromptVersion != keywordFallbackPrompt {
+		t.Errorf("prompt version = %s, want %s", report.PromptVersion, keywordFallbackPrompt)
+	}
+	// 没有风险发现时摘要仍包含各等级
+	if want := map[string]int{"red": 0, "yellow": 0, "green": 0}; len(report.Findings) != 0 || !reflect.DeepEqual(report.RiskSummary, want) {
+		t.Errorf("findings = %+v, risk_summary = %v", report.Findings, report.RiskSummary)
+	}
+}
+
+func TestReportPersist(t *testing.T) {
+	ctx := context.Background()
+	s, store, _ := newTaskService(t)
+	task := createRunnableTask(t, s, store, 1)
+	for _, status := range []domain.TaskStatus{domain.TaskStatusParsing, domain.TaskStatusExtracting, domain.TaskStatusMatching} {
+		if _, err := s.Transition(ctx, task.ID, status); err != nil {
+			t.Fatalf("Transition(%s): %v", status, err)
+		}
+	}
+
+	// 风险发现、摘要与状态推进在同一事务中提交
+	report := newReportService(t).Generate(ctx, task.ID, testFacts())
+	if _, err := s.CompleteStage(ctx, task.ID, domain.TaskStatusMatching, domain.TaskStatusSuccess, report); err != nil {
+		t.Fatalf("CompleteStage: %v", err)
+	}
+	got, err := s.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.Status != domain.TaskStatusSuccess || !reflect.DeepEqual(got.RiskSummary, report.RiskSummary) ||
+		got.RuleVersion != report.RuleVersion || got.PromptVersion != "health_facts.v2,policy_facts.v1" {
+		t.Errorf("task = %+v", got)
+	}
+	findings, err := store.Findings().ListByTask(ctx, task.ID)
+	if err != nil || len(findings) != 3 {
+		t.Fatalf("findings = %+v, %v, want 3", findings, err)
+	}
+	audits, err := store.Audits().ListByTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("ListByTask: %v", err)
+	}
+	var generated int
+	for _, a := range audits {
+		if a.Action == AuditActionReportGenerated {
+			generated++
+		}
+	}
+	if generated != 1 {
+		t.Errorf("report.generated audits = %d, want 1", generated)
+	}
+
+	// 重新生成时替换原有风险发现
+	regenerated := &Report{Findings: report.Findings[:1], RiskSummary: map[string]int{"red": 1}, RuleVersion: "test.2"}
+	if err := regenerated.Persist(ctx, store, task.ID); err != nil {
+		t.Fatalf("Persist: %v", err)
+	}
+	if findings, _ := store.Findings().ListByTask(ctx, task.ID); len(findings) != 1 {
+		t.Errorf("findings after regenerate = %d, want 1", len(findings))
+	}
+}
+
+func TestReportPersistMissingTask(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	for name, report := range map[string]*Report{
+		"with findings":    newReportService(t).Generate(ctx, 99, testFacts()),
+		"without findings": newReportService(t).Generate(ctx, 99, &extract.Output{}),
+	} {
+		if err := report.Persist(ctx, store, 99); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("%s: err = %v, want ErrTaskNotFound", name, err)
+		}
+	}
+}
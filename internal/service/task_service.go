@@ -0,0 +1,414 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/repository"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+)
+
+// 审计动作
+const (
+	AuditActionTaskCreated        = "task.created"
+	AuditActionTaskStatusChanged  = "task.status_changed"
+	AuditActionTaskRunRequested   = "task.run_requested"
+	AuditActionTaskDeleted        = "task.deleted"
+	AuditActionTaskRetryScheduled = "task.retry_scheduled"
+
+	auditTargetTask = "analysis_task"
+)
+
+// transitions 任务状态机：pending -> parsing -> extracting -> matching -> success，
+// 任一阶段可进入 failed，failed 可重新回到 pending 发起重跑
+var transitions = map[domain.TaskStatus][]domain.TaskStatus{
+	domain.TaskStatusPending:    {domain.TaskStatusParsing, domain.TaskStatusFailed},
+	domain.TaskStatusParsing:    {domain.TaskStatusExtracting, domain.TaskStatusFailed},
+	domain.TaskStatusExtracting: {domain.TaskStatusMatching, domain.TaskStatusFailed},
+	domain.TaskStatusMatching:   {domain.TaskStatusSuccess, domain.TaskStatusFailed},
+	domain.TaskStatusFailed:     {domain.TaskStatusPending},
+}
+
+// requiredDocTypes 启动分析前必须上传的文档类型
+var requiredDocTypes = []domain.DocumentType{domain.DocTypeReport, domain.DocTypePolicy}
+
+// CanTransition 判断状态流转是否合法
+func CanTransition(from, to domain.TaskStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskService 任务服务，负责任务生命周期与所有状态流转
+type TaskService struct {
+	store repository.Store
+	queue JobQueue
+}
+
+// NewTaskService 创建任务服务
+func NewTaskService(store repository.Store, queue JobQueue) *TaskService {
+	return &TaskService{store: store, queue: queue}
+}
+
+// CreateTask 创建任务。idem.Key 非空时写入 analysis_task.request_id，
+// 同一用户重放相同请求返回首次创建的任务，replayed 为 true。
+func (s *TaskService) CreateTask(ctx context.Context, userID int64, idem Idempotency) (task *domain.AnalysisTask, replayed bool, err error) {
+	return s.withIdempotency(ctx, userID, idempotencyScopeCreate, 0, idem, func(tx repository.Store) (*domain.AnalysisTask, error) {
+		task := &domain.AnalysisTask{
+			UserID:    userID,
+			RequestID: idem.Key,
+			Status:    domain.TaskStatusPending,
+		}
+		if err := tx.Tasks().Create(ctx, task); err != nil {
+			return nil, err
+		}
+		err := tx.Audits().Create(ctx, &domain.AuditLog{
+			TaskID:     task.ID,
+			ActorID:    userID,
+			Action:     AuditActionTaskCreated,
+			TargetType: auditTargetTask,
+			TargetID:   strconv.FormatInt(task.ID, 10),
+		})
+		return task, err
+	})
+}
+
+// GetTask 获取任务，不校验归属，供 worker 等内部流程使用
+func (s *TaskService) GetTask(ctx context.Context, taskID int64) (*domain.AnalysisTask, error) {
+	task, err := s.store.Tasks().Get(ctx, taskID)
+	if err != nil {
+		return nil, translateNotFound(err)
+	}
+	return task, nil
+}
+
+// GetTaskForUser 获取属于 userID 的任务。其他用户的任务同样返回 ErrTaskNotFound，不泄露任务是否存在
+func (s *TaskService) GetTaskForUser(ctx context.Context, taskID, userID int64) (*domain.AnalysisTask, error) {
+	task, err := s.store.Tasks().GetForUser(ctx, taskID, userID)
+	if err != nil {
+		return nil, translateNotFound(err)
+	}
+	return task, nil
+}
+
+// ListFindings 获取属于 userID 的任务的风险发现
+func (s *TaskService) ListFindings(ctx context.Context, taskID, userID int64) ([]domain.RiskFinding, error) {
+	if _, err := s.GetTaskForUser(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+	return s.store.Findings().ListByTask(ctx, taskID)
+}
+
+// RunTask 校验必填文档并发起分析。失败任务会先回到 pending 再重跑。
+// idem.Key 非空时同一用户重放相同请求返回首次调用的结果。
+func (s *TaskService) RunTask(ctx context.Context, taskID, actorID int64, idem Idempotency) (task *domain.AnalysisTask, replayed bool, err error) {
+	task, replayed, err = s.withIdempotency(ctx, actorID, idempotencyScopeRun, taskID, idem, func(tx repository.Store) (*domain.AnalysisTask, error) {
+		task, err := tx.Tasks().GetForUser(ctx, taskID, actorID)
+		if err != nil {
+			return nil, translateNotFound(err)
+		}
+		if task.Status != domain.TaskStatusPending && !CanTransition(task.Status, domain.TaskStatusPending) {
+			return nil, &TransitionError{From: task.Status, To: domain.TaskStatusPending}
+		}
+
+		docs, err := tx.Documents().ListByTask(ctx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if missing := missingDocTypes(docs); len(missing) > 0 {
+			return nil, &MissingDocumentsError{Missing: missing}
+		}
+
+		if task.Status != domain.TaskStatusPending {
+			if err := s.transition(ctx, tx, task, domain.TaskStatusPending, actorID, nil); err != nil {
+				return nil, err
+			}
+		}
+		err = tx.Audits().Create(ctx, &domain.AuditLog{
+			TaskID:     taskID,
+			ActorID:    actorID,
+			Action:     AuditActionTaskRunRequested,
+			TargetType: auditTargetTask,
+			TargetID:   strconv.FormatInt(taskID, 10),
+		})
+		return task, err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.enqueueRun(ctx, task, idem, replayed); err != nil {
+		return nil, false, err
+	}
+	return task, replayed, nil
+}
+
+// enqueueRun 在事务提交后投递分析消息。投递失败时调用方可用同一幂等键重试：
+// 重放时任务仍为 pending 会再次投递，重复消息由 worker 按任务状态去重。
+func (s *TaskService) enqueueRun(ctx context.Context, task *domain.AnalysisTask, idem Idempotency, replayed bool) error {
+	if replayed {
+		current, err := s.GetTask(ctx, task.ID)
+		if err != nil {
+			return err
+		}
+		if current.Status != domain.TaskStatusPending {
+			return nil
+		}
+		task = current
+	}
+
+	// 消息携带本次请求的请求 ID，worker 日志据此与 API 日志串联；
+	// 没有请求 ID 时（内部调用）退回幂等键
+	requestID := logger.RequestID(ctx)
+	if requestID == "" {
+		requestID = idem.Key
+	}
+	if requestID == "" {
+		requestID = task.RequestID
+	}
+	// 手动发起的运行从第 0 次重试开始，不继承上次运行耗尽的重试次数
+	err := s.queue.Enqueue(ctx, AnalysisJob{
+		TaskID:    task.ID,
+		RequestID: requestID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task %d: %w", task.ID, err)
+	}
+	return nil
+}
+
+// Transition 将任务推进到下一状态，非法流转返回 *TransitionError
+func (s *TaskService) Transition(ctx context.Context, taskID int64, to domain.TaskStatus) (*domain.AnalysisTask, error) {
+	if to == domain.TaskStatusFailed {
+		return s.MarkFailed(ctx, taskID, "", 0)
+	}
+
+	var task *domain.AnalysisTask
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		var err error
+		task, err = tx.Tasks().Get(ctx, taskID)
+		if err != nil {
+			return translateNotFound(err)
+		}
+		return s.transition(ctx, tx, task, to, 0, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// MarkFailed 将任务置为 failed，并记录失败码与重试次数
+func (s *TaskService) MarkFailed(ctx context.Context, taskID int64, failureCode string, retryCount int) (*domain.AnalysisTask, error) {
+	var task *domain.AnalysisTask
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		var err error
+		task, err = tx.Tasks().Get(ctx, taskID)
+		if err != nil {
+			return translateNotFound(err)
+		}
+
+		from := task.Status
+		if !CanTransition(from, domain.TaskStatusFailed) {
+			return &TransitionError{From: from, To: domain.TaskStatusFailed}
+		}
+		if err := tx.Tasks().UpdateFailure(ctx, taskID, from, failureCode, retryCount); err != nil {
+			return translateStatusErr(err, from, domain.TaskStatusFailed)
+		}
+		task.Status = domain.TaskStatusFailed
+		task.FailureCode = failureCode
+		task.RetryCount = retryCount
+
+		return tx.Audits().Create(ctx, statusAudit(taskID, 0, from, domain.TaskStatusFailed, map[string]interface{}{
+			"failure_code": failureCode,
+			"retry_count":  retryCount,
+		}))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// RecordRetry 记录阶段失败后的自动重试。任务保持在失败的阶段，重试时从该阶段继续。
+func (s *TaskService) RecordRetry(ctx context.Context, taskID int64, retryCount int, failureCode string) (*domain.AnalysisTask, error) {
+	var task *domain.AnalysisTask
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		var err error
+		task, err = tx.Tasks().Get(ctx, taskID)
+		if err != nil {
+			return translateNotFound(err)
+		}
+		if err := tx.Tasks().UpdateRetryCount(ctx, taskID, task.Status, retryCount); err != nil {
+			return translateStatusErr(err, task.Status, task.Status)
+		}
+		task.RetryCount = retryCount
+
+		return tx.Audits().Create(ctx, &domain.AuditLog{
+			TaskID:     taskID,
+			Action:     AuditActionTaskRetryScheduled,
+			TargetType: auditTargetTask,
+			TargetID:   strconv.FormatInt(taskID, 10),
+			Detail: map[string]interface{}{
+				"stage":        task.Status,
+				"failure_code": failureCode,
+				"retry_count":  retryCount,
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// StageResult 可由阶段产出实现，在 CompleteStage 的事务中写入阶段的业务数据（如风险发现），
+// 与检查点和状态推进一同提交
+type StageResult interface {
+	Persist(ctx context.Context, tx repository.Store, taskID int64) error
+}
+
+// CompleteStage 完成流水线阶段：在同一事务中写入阶段产出检查点并推进到 next。
+// 任务不处于 stage 时返回 *TransitionError；output 为 nil 时不写检查点。
+// 检查点与状态同时提交，worker 中断后从 next 继续，不会重做已完成的阶段。
+// output 实现 StageResult 时，其业务数据也在该事务中写入。
+func (s *TaskService) CompleteStage(ctx context.Context, taskID int64, stage, next domain.TaskStatus, output interface{}) (*domain.AnalysisTask, error) {
+	var data []byte
+	if output != nil {
+		var err error
+		if data, err = json.Marshal(output); err != nil {
+			return nil, fmt.Errorf("failed to encode %s output: %w", stage, err)
+		}
+	}
+
+	var task *domain.AnalysisTask
+	err := s.store.WithTx(ctx, func(tx repository.Store) error {
+		var err error
+		task, err = tx.Tasks().Get(ctx, taskID)
+		if err != nil {
+			return translateNotFound(err)
+		}
+		if task.Status != stage {
+			return &TransitionError{From: task.Status, To: next}
+		}
+		if data != nil {
+			err := tx.Checkpoints().Save(ctx, &domain.TaskCheckpoint{TaskID: taskID, Stage: stage, Data: data})
+			if err != nil {
+				return err
+			}
+		}
+		if result, ok := output.(StageResult); ok {
+			if err := result.Persist(ctx, tx, taskID); err != nil {
+				return err
+			}
+		}
+		return s.transition(ctx, tx, task, next, 0, map[string]interface{}{
+			"checkpoint": data != nil,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// StageOutput 读取已完成阶段的产出并解码到 v，阶段未完成时返回 ErrCheckpointNotFound
+func (s *TaskService) StageOutput(ctx context.Context, taskID int64, stage domain.TaskStatus, v interface{}) error {
+	checkpoint, err := s.store.Checkpoints().Get(ctx, taskID, stage)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrCheckpointNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(checkpoint.Data, v); err != nil {
+		return fmt.Errorf("failed to decode %s checkpoint: %w", stage, err)
+	}
+	return nil
+}
+
+// DeleteTask 删除属于 actorID 的任务，文档与风险发现随外键级联删除
+func (s *TaskService) DeleteTask(ctx context.Context, taskID, actorID int64) error {
+	return s.store.WithTx(ctx, func(tx repository.Store) error {
+		if err := tx.Tasks().Delete(ctx, taskID, actorID); err != nil {
+			return translateNotFound(err)
+		}
+		return tx.Audits().Create(ctx, &domain.AuditLog{
+			ActorID:    actorID,
+			Action:     AuditActionTaskDeleted,
+			TargetType: auditTargetTask,
+			TargetID:   strconv.FormatInt(taskID, 10),
+		})
+	})
+}
+
+// transition 在事务内校验并执行状态流转，同时写入审计日志
+func (s *TaskService) transition(ctx context.Context, tx repository.Store, task *domain.AnalysisTask, to domain.TaskStatus, actorID int64, detail map[string]interface{}) error {
+	from := task.Status
+	if !CanTransition(from, to) {
+		return &TransitionError{From: from, To: to}
+	}
+	if from == domain.TaskStatusFailed && to == domain.TaskStatusPending {
+		// 重跑从头开始，清除上次的失败码与重试次数
+		if err := tx.Tasks().ResetForRerun(ctx, task.ID); err != nil {
+			return translateStatusErr(err, from, to)
+		}
+		task.FailureCode = ""
+		task.RetryCount = 0
+	} else if err := tx.Tasks().UpdateStatus(ctx, task.ID, from, to); err != nil {
+		return translateStatusErr(err, from, to)
+	}
+	task.Status = to
+	return tx.Audits().Create(ctx, statusAudit(task.ID, actorID, from, to, detail))
+}
+
+func statusAudit(taskID, actorID int64, from, to domain.TaskStatus, detail map[string]interface{}) *domain.AuditLog {
+	if detail == nil {
+		detail = map[string]interface{}{}
+	}
+	detail["from"] = from
+	detail["to"] = to
+	return &domain.AuditLog{
+		TaskID:     taskID,
+		ActorID:    actorID,
+		Action:     AuditActionTaskStatusChanged,
+		TargetType: auditTargetTask,
+		TargetID:   strconv.FormatInt(taskID, 10),
+		Detail:     detail,
+	}
+}
+
+func missingDocTypes(docs []domain.Document) []domain.DocumentType {
+	present := make(map[domain.DocumentType]bool, len(docs))
+	for _, doc := range docs {
+		present[doc.DocType] = true
+	}
+	var missing []domain.DocumentType
+	for _, t := range requiredDocTypes {
+		if !present[t] {
+			missing = append(missing, t)
+		}
+	}
+	return missing
+}
+
+func translateNotFound(err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrTaskNotFound
+	}
+	return err
+}
+
+// translateStatusErr 并发修改导致的状态冲突同样视为非法流转
+func translateStatusErr(err error, from, to domain.TaskStatus) error {
+	if errors.Is(err, repository.ErrStatusConflict) {
+		return &TransitionError{From: from, To: to}
+	}
+	return translateNotFound(err)
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+	"github.com/zhenglizhi/policy-fit/internal/repository"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("error", "json")
+	os.Exit(m.Run())
+}
+
+// fakeQueue 记录投递的分析消息，err 非空时投递失败
+type fakeQueue struct {
+	mu   sync.Mutex
+	jobs []AnalysisJob
+	err  error
+}
+
+func (q *fakeQueue) Enqueue(ctx context.Context, job AnalysisJob) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err != nil {
+		return q.err
+	}
+	q.jobs = append(q.jobs, job)
+	return nil
+}
+
+func (q *fakeQueue) count() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs)
+}
+
+func newTaskService(t *testing.T) (*TaskService, *repository.MemoryStore, *fakeQueue) {
+	t.Helper()
+	store := repository.NewMemoryStore()
+	queue := &fakeQueue{}
+	return NewTaskService(store, queue), store, queue
+}
+
+// createRunnableTask 创建已上传必填文档的任务
+func createRunnableTask(t *testing.T, s *TaskService, store repository.Store, userID int64) *domain.AnalysisTask {
+	t.Helper()
+	ctx := context.Background()
+	task, _, err := s.CreateTask(ctx, userID, Idempotency{})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	for _, docType := range requiredDocTypes {
+		if err := store.Documents().Create(ctx, &domain.Document{TaskID: task.ID, DocType: docType}); err != nil {
+			t.Fatalf("create %s document: %v", docType, err)
+		}
+	}
+	return task
+}
+
+func TestCanTransition(t *testing.T) {
+	statuses := []domain.TaskStatus{
+		domain.TaskStatusPending,
+		domain.TaskStatusParsing,
+		domain.TaskStatusExtracting,
+		domain.TaskStatusMatching,
+		domain.TaskStatusSuccess,
+		domain.TaskStatusFailed,
+	}
+	allowed := map[[2]domain.TaskStatus]bool{
+		{domain.TaskStatusPending, domain.TaskStatusParsing}:     true,
+		{domain.TaskStatusParsing, domain.TaskStatusExtracting}:  true,
+		{domain.TaskStatusExtracting, domain.TaskStatusMatching}: true,
+		{domain.TaskStatusMatching, domain.TaskStatusSuccess}:    true,
+		{domain.TaskStatusPending, domain.TaskStatusFailed}:      true,
+		{domain.TaskStatusParsing, domain.TaskStatusFailed}:      true,
+		{domain.TaskStatusExtracting, domain.TaskStatusFailed}:   true,
+		{domain.TaskStatusMatching, domain.TaskStatusFailed}:     true,
+		{domain.TaskStatusFailed, domain.TaskStatusPending}:      true,
+	}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			if got := CanTransition(from, to); got != allowed[[2]domain.TaskStatus{from, to}] {
+				t.Errorf("CanTransition(%s, %s) = %v", from, to, got)
+			}
+		}
+	}
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	ctx := context.Background()
+	s, store, queue := newTaskService(t)
+	task := createRunnableTask(t, s, store, 1)
+
+	if _, _, err := s.RunTask(ctx, task.ID, 1, Idempotency{}); err != nil {
+		t.Fatalf("RunTask: %v", err)
+	}
+	if queue.count() != 1 || queue.jobs[0].TaskID != task.ID {
+		t.Fatalf("jobs = %+v, want one job for task %d", queue.jobs, task.ID)
+	}
+	if _, err := s.Transition(ctx, task.ID, domain.TaskStatusParsing); err != nil {
+		t.Fatalf("Transition(parsing): %v", err)
+	}
+
+	// 跳过阶段的流转被拒绝，状态不变
+	_, err := s.Transition(ctx, task.ID, domain.TaskStatusSuccess)
+	var transErr *TransitionError
+	if !errors.As(err, &transErr) || transErr.From != domain.TaskStatusParsing || transErr.To != domain.TaskStatusSuccess {
+		t.Fatalf("Transition(success): err = %v, want parsing -> success TransitionError", err)
+	}
+	if !errors.Is(err, ErrIllegalTransition) {
+		t.Fatalf("TransitionError does not match ErrIllegalTransition")
+	}
+
+	// 完成阶段：检查点与状态一同提交
+	output := []ParsedDocument{{DocumentID: 3, DocType: domain.DocTypeReport, Pages: 2, Paragraphs: 9}}
+	if _, err := s.CompleteStage(ctx, task.ID, domain.TaskStatusParsing, domain.TaskStatusExtracting, output); err != nil {
+		t.Fatalf("CompleteStage: %v", err)
+	}
+	var saved []ParsedDocument
+	if err := s.StageOutput(ctx, task.ID, domain.TaskStatusParsing, &saved); err != nil || len(saved) != 1 || saved[0].Paragraphs != 9 {
+		t.Fatalf("StageOutput = %+v, %v", saved, err)
+	}
+	if err := s.StageOutput(ctx, task.ID, domain.TaskStatusExtracting, &saved); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Fatalf("StageOutput(extracting): err = %v, want ErrCheckpointNotFound", err)
+	}
+	// 重复完成同一阶段被拒绝，不写检查点
+	if _, err := s.CompleteStage(ctx, task.ID, domain.TaskStatusParsing, domain.TaskStatusExtracting, output); !errors.Is(err, ErrIllegalTransition) {
+		t.Fatalf("second CompleteStage: err = %v, want ErrIllegalTransition", err)
+	}
+
+	failed, err := s.MarkFailed(ctx, task.ID, "PFIT-3006", 3)
+	if err != nil || failed.Status != domain.TaskStatusFailed {
+		t.Fatalf("MarkFailed = %+v, %v", failed, err)
+	}
+	if _, err := s.MarkFailed(ctx, task.ID, "PFIT-3006", 3); !errors.Is(err, ErrIllegalTransition) {
+		t.Fatalf("MarkFailed on a failed task: err = %v, want ErrIllegalTransition", err)
+	}
+
+	// 重跑：回到 pending，清除失败码与重试次数
+	rerun, _, err := s.RunTask(ctx, task.ID, 1, Idempotency{})
+	if err != nil {
+		t.Fatalf("rerun: %v", err)
+	}
+	if rerun.Status != domain.TaskStatusPending || rerun.FailureCode != "" || rerun.RetryCount != 0 {
+		t.Fatalf("rerun task = %s %q retry %d, want pending without failure", rerun.Status, rerun.FailureCode, rerun.RetryCount)
+	}
+	stored, err := s.GetTask(ctx, task.ID)
+	if err != nil || stored.Status != domain.TaskStatusPending || stored.FailureCode != "" || stored.RetryCount != 0 {
+		t.Fatalf("stored task = %+v, %v", stored, err)
+	}
+	if queue.count() != 2 || queue.jobs[1].RetryCount != 0 {
+		t.Fatalf("jobs = %+v, want a second job starting at retry 0", queue.jobs)
+	}
+
+	audits, err := store.Audits().ListByTask(ctx, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes int
+	for _, a := range audits {
+		if a.Action == AuditActionTaskStatusChanged {
+			changes++
+		}
+	}
+	// parsing、extracting、failed、pending
+	if changes != 4 {
+		t.Errorf("status change audits = %d, want 4", changes)
+	}
+}
+
+func TestRunTaskRejects(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("missing documents", func(t *testing.T) {
+		s, store, queue := newTaskService(t)
+		task, _, err := s.CreateTask(ctx, 1, Idempotency{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Documents().Create(ctx, &domain.Document{TaskID: task.ID, DocType: domain.DocTypeReport}); err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = s.RunTask(ctx, task.ID, 1, Idempotency{})
+		var missing *MissingDocumentsError
+		if !errors.As(err, &missing) || len(missing.Missing) != 1 || missing.Missing[0] != domain.DocTypePolicy {
+			t.Fatalf("err = %v, want missing policy", err)
+		}
+		if !errors.Is(err, ErrMissingDocuments) || queue.count() != 0 {
+			t.Fatalf("err = %v, jobs = %d", err, queue.count())
+		}
+	})
+
+	t.Run("another user's task", func(t *testing.T) {
+		s, store, _ := newTaskService(t)
+		task := createRunnableTask(t, s, store, 1)
+		if _, _, err := s.RunTask(ctx, task.ID, 2, Idempotency{}); !errors.Is(err, ErrTaskNotFound) {
+			t.Fatalf("err = %v, want ErrTaskNotFound", err)
+		}
+	})
+
+	t.Run("task in progress", func(t *testing.T) {
+		s, store, _ := newTaskService(t)
+		task := createRunnableTask(t, s, store, 1)
+		if _, err := s.Transition(ctx, task.ID, domain.TaskStatusParsing); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := s.RunTask(ctx, task.ID, 1, Idempotency{}); !errors.Is(err, ErrIllegalTransition) {
+			t.Fatalf("err = %v, want ErrIllegalTransition", err)
+		}
+	})
+
+	t.Run("enqueue failure", func(t *testing.T) {
+		s, store, queue := newTaskService(t)
+		task := createRunnableTask(t, s, store, 1)
+		queue.err = errors.New("redis unavailable")
+		if _, _, err := s.RunTask(ctx, task.ID, 1, Idempotency{}); !errors.Is(err, queue.err) {
+			t.Fatalf("err = %v, want the queue error", err)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/config"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+)
+
+// Sender 短信验证码发送
+type Sender interface {
+	Send(ctx context.Context, phone, code string) error
+}
+
+// New 按配置创建短信发送器，appEnv 为 prod 时 log 发送器不输出验证码
+func New(cfg config.OTPConfig, appEnv string) (Sender, error) {
+	switch cfg.SMSProvider {
+	case "log":
+		return &Log{ttl: time.Duration(cfg.TTL) * time.Second, showCode: appEnv != "prod"}, nil
+	default:
+		return nil, fmt.Errorf("unsupported sms provider: %s", cfg.SMSProvider)
+	}
+}
+
+// Log 不实际发送，记录脱敏手机号与有效期，用于本地开发与测试环境，生产环境不可用。
+// 非生产环境在 debug 级别输出验证码，便于离线登录
+type Log struct {
+	ttl      time.Duration
+	showCode bool
+}
+
+func (l *Log) Send(ctx context.Context, phone, code string) error {
+	log := logger.FromContext(ctx)
+	log.Info("SMS code issued (log only, not sent)", "phone", MaskPhone(phone), "ttl", l.ttl.String())
+	if l.showCode {
+		log.Debug("SMS code (log only)", "phone", MaskPhone(phone), "code", code)
+	}
+	return nil
+}
+
+// MaskPhone 隐藏手机号中间四位，如 138****5678
+func MaskPhone(phone string) string {
+	if len(phone) < 7 {
+		return "****"
+	}
+	return phone[:3] + "****" + phone[len(phone)-4:]
+}
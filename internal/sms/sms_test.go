@@ -0,0 +1,83 @@
+package sms
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zhenglizhi/policy-fit/internal/config"
+	"github.com/zhenglizhi/policy-fit/pkg/logger"
+)
+
+// captureLogs 以 debug 级别将日志写入临时文件，返回读取输出的函数
+func captureLogs(t *testing.T) func() string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sms.log")
+	if err := logger.InitWithOutputs("debug", "json", logger.DefaultRules(), path); err != nil {
+		t.Fatalf("InitWithOutputs: %v", err)
+	}
+	t.Cleanup(func() { logger.Init("error", "json") })
+	return func() string {
+		logger.Sync()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read log output: %v", err)
+		}
+		return string(data)
+	}
+}
+
+func TestLogSender(t *testing.T) {
+	cfg := config.OTPConfig{TTL: 300, SMSProvider: "log"}
+	tests := []struct {
+		env      string
+		showCode bool
+	}{
+		{"dev", true},
+		{"test", true},
+		{"prod", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			output := captureLogs(t)
+			sender, err := New(cfg, tt.env)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if err := sender.Send(context.Background(), "13812345678", "246810"); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+
+			logs := output()
+			if !strings.Contains(logs, "138****5678") || !strings.Contains(logs, "5m0s") {
+				t.Errorf("log missing masked phone or ttl:\n%s", logs)
+			}
+			if strings.Contains(logs, "13812345678") {
+				t.Errorf("log contains the full phone number:\n%s", logs)
+			}
+			if got := strings.Contains(logs, "246810"); got != tt.showCode {
+				t.Errorf("code logged = %v, want %v:\n%s", got, tt.showCode, logs)
+			}
+		})
+	}
+}
+
+func TestNewRejectsUnknownProvider(t *testing.T) {
+	if _, err := New(config.OTPConfig{SMSProvider: "aliyun"}, "dev"); err == nil {
+		t.Fatal("New accepted an unsupported provider")
+	}
+}
+
+func TestMaskPhone(t *testing.T) {
+	for phone, want := range map[string]string{
+		"13812345678": "138****5678",
+		"1381234":     "138****1234",
+		"123456":      "****",
+	} {
+		if got := MaskPhone(phone); got != want {
+			t.Errorf("MaskPhone(%q) = %q, want %q", phone, got, want)
+		}
+	}
+}
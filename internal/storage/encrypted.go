@@ -0,0 +1,379 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// envelopeSuffix 数据密钥信封对象后缀，与密文对象同前缀存放
+	envelopeSuffix = ".dek"
+	// envelopeVersion 信封格式版本
+	envelopeVersion = 1
+	// encChunkSize 密文分块的明文大小，逐块加解密以支持流式读写
+	encChunkSize  = 64 << 10
+	aesGCMTagSize = 16
+	dekSize       = 32
+)
+
+// encMagic 密文对象头
+var encMagic = []byte("PFE\x01")
+
+// ErrDecrypt 对象解密失败（密钥不匹配或密文被篡改）
+var ErrDecrypt = errors.New("failed to decrypt object")
+
+// Keyring 主密钥集合，current 用于封装新的数据密钥，其余仅用于解封
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring 创建主密钥集合，keys 必须包含 current，每个密钥 32 字节
+func NewKeyring(current string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("current master key %q not found", current)
+	}
+	kr := &Keyring{current: current, keys: make(map[string]cipher.AEAD, len(keys))}
+	for kid, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid master key %q: %w", kid, err)
+		}
+		kr.keys[kid] = aead
+	}
+	return kr, nil
+}
+
+// envelope 数据密钥信封，WrappedKey 为主密钥 AES-GCM 加密后的数据密钥（nonce||ciphertext）
+type envelope struct {
+	Version    int    `json:"version"`
+	KeyID      string `json:"kid"`
+	WrappedKey []byte `json:"wrapped_key"`
+}
+
+// Encrypted 应用层信封加密存储：每个对象使用独立的数据密钥进行分块 AES-GCM 加密，
+// 数据密钥由主密钥封装后存放在 {key}.dek 信封对象中。轮换主密钥只需重新封装信封，
+// 不必重新加密对象内容。对底层存储透明，本地与 S3 后端行为一致。
+type Encrypted struct {
+	inner Storage
+	keys  *Keyring
+}
+
+// NewEncrypted 为底层存储增加应用层加密
+func NewEncrypted(inner Storage, keys *Keyring) *Encrypted {
+	return &Encrypted{inner: inner, keys: keys}
+}
+
+func (e *Encrypted) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	if err := checkKey(key); err != nil {
+		return 0, err
+	}
+
+	dek := make([]byte, dekSize)
+	if _, err := rand.Read(dek); err != nil {
+		return 0, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return 0, err
+	}
+
+	// 先写密文再写信封：信封存在即代表对象写入完成
+	enc := &encryptReader{r: r, aead: aead, aad: []byte(key), pending: append([]byte(nil), encMagic...)}
+	if _, err := e.inner.Put(ctx, key, enc); err != nil {
+		return enc.plainSize, err
+	}
+	if err := e.putEnvelope(ctx, key, e.keys.current, dek); err != nil {
+		_ = e.inner.Delete(context.WithoutCancel(ctx), key)
+		return enc.plainSize, err
+	}
+	return enc.plainSize, nil
+}
+
+func (e *Encrypted) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	dek, _, err := e.openEnvelope(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := e.inner.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	magic := make([]byte, len(encMagic))
+	if _, err := io.ReadFull(rc, magic); err != nil || !bytes.Equal(magic, encMagic) {
+		rc.Close()
+		return nil, fmt.Errorf("%w: %s: invalid header", ErrDecrypt, key)
+	}
+	return &decryptReader{rc: rc, aead: aead, aad: []byte(key), key: key}, nil
+}
+
+// Delete 先删除信封，使对象立即不可读，再删除密文
+func (e *Encrypted) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	if err := e.inner.Delete(ctx, key+envelopeSuffix); err != nil {
+		return err
+	}
+	return e.inner.Delete(ctx, key)
+}
+
+func (e *Encrypted) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	if _, err := e.inner.Stat(ctx, key+envelopeSuffix); err != nil {
+		return nil, err
+	}
+	info, err := e.inner.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	info.Size = plaintextSize(info.Size)
+	return info, nil
+}
+
+// List 只返回信封已写入的对象，大小为明文大小
+func (e *Encrypted) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	objects, err := e.inner.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := make(map[string]bool)
+	for _, obj := range objects {
+		if isEnvelopeKey(obj.Key) {
+			sealed[strings.TrimSuffix(obj.Key, envelopeSuffix)] = true
+		}
+	}
+
+	result := make([]ObjectInfo, 0, len(sealed))
+	for _, obj := range objects {
+		if sealed[obj.Key] {
+			obj.Size = plaintextSize(obj.Size)
+			result = append(result, obj)
+		}
+	}
+	return result, nil
+}
+
+// Rewrap 使用当前主密钥重新封装对象的数据密钥，返回是否发生了变更。
+// 对象内容不会被重新加密。
+func (e *Encrypted) Rewrap(ctx context.Context, key string) (bool, error) {
+	dek, kid, err := e.openEnvelope(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if kid == e.keys.current {
+		return false, nil
+	}
+	if err := e.putEnvelope(ctx, key, e.keys.current, dek); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RewrapPrefix 重新封装前缀下全部对象的数据密钥，返回变更数量
+func (e *Encrypted) RewrapPrefix(ctx context.Context, prefix string) (int, error) {
+	objects, err := e.List(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	rewrapped := 0
+	for _, obj := range objects {
+		changed, err := e.Rewrap(ctx, obj.Key)
+		if err != nil {
+			return rewrapped, fmt.Errorf("failed to rewrap %s: %w", obj.Key, err)
+		}
+		if changed {
+			rewrapped++
+		}
+	}
+	return rewrapped, nil
+}
+
+func (e *Encrypted) putEnvelope(ctx context.Context, key, kid string, dek []byte) error {
+	master := e.keys.keys[kid]
+	nonce := make([]byte, master.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data, err := json.Marshal(envelope{
+		Version:    envelopeVersion,
+		KeyID:      kid,
+		WrappedKey: master.Seal(nonce, nonce, dek, []byte(key)),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := e.inner.Put(ctx, key+envelopeSuffix, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to write data key envelope: %w", err)
+	}
+	return nil
+}
+
+// openEnvelope 读取并解封数据密钥，返回数据密钥与封装它的主密钥标识
+func (e *Encrypted) openEnvelope(ctx context.Context, key string) ([]byte, string, error) {
+	rc, err := e.inner.Get(ctx, key+envelopeSuffix)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rc.Close()
+
+	var env envelope
+	if err := json.NewDecoder(io.LimitReader(rc, 4<<10)).Decode(&env); err != nil {
+		return nil, "", fmt.Errorf("%w: %s: malformed envelope", ErrDecrypt, key)
+	}
+	if env.Version != envelopeVersion {
+		return nil, "", fmt.Errorf("%w: %s: unsupported envelope version %d", ErrDecrypt, key, env.Version)
+	}
+	master, ok := e.keys.keys[env.KeyID]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %s: unknown master key %q", ErrDecrypt, key, env.KeyID)
+	}
+	if len(env.WrappedKey) < master.NonceSize() {
+		return nil, "", fmt.Errorf("%w: %s: malformed envelope", ErrDecrypt, key)
+	}
+	nonce, sealed := env.WrappedKey[:master.NonceSize()], env.WrappedKey[master.NonceSize():]
+	dek, err := master.Open(nil, nonce, sealed, []byte(key))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s: data key unwrap failed", ErrDecrypt, key)
+	}
+	return dek, env.KeyID, nil
+}
+
+// checkKey 校验对象键，信封对象不可直接读写
+func checkKey(key string) error {
+	if _, err := cleanKey(key); err != nil {
+		return err
+	}
+	if isEnvelopeKey(key) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+func isEnvelopeKey(key string) bool {
+	return strings.HasSuffix(key, envelopeSuffix)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce 分块 nonce：8 字节块序号 + 末块标记。数据密钥每个对象唯一，
+// 因此序号即可保证 nonce 不重复；末块标记防止密文在块边界被截断。
+func chunkNonce(size int, index uint64, last bool) []byte {
+	nonce := make([]byte, size)
+	binary.BigEndian.PutUint64(nonce, index)
+	if last {
+		nonce[size-1] = 1
+	}
+	return nonce
+}
+
+// plaintextSize 由密文大小推算明文大小：每个整块增加一个 tag，末块（可能为空）再增加一个 tag
+func plaintextSize(size int64) int64 {
+	body := size - int64(len(encMagic))
+	fullChunk := int64(encChunkSize + aesGCMTagSize)
+	plain := body/fullChunk*encChunkSize + body%fullChunk - aesGCMTagSize
+	if plain < 0 {
+		return 0
+	}
+	return plain
+}
+
+// encryptReader 将明文流转换为 magic + 分块密文流。
+// 满块按普通块加密，最后一个不满块（可能为空）按末块加密。
+type encryptReader struct {
+	r         io.Reader
+	aead      cipher.AEAD
+	aad       []byte
+	index     uint64
+	pending   []byte
+	done      bool
+	plainSize int64
+}
+
+func (er *encryptReader) Read(p []byte) (int, error) {
+	for len(er.pending) == 0 {
+		if er.done {
+			return 0, io.EOF
+		}
+		chunk := make([]byte, encChunkSize)
+		n, err := io.ReadFull(er.r, chunk)
+		last := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		if err != nil && !last {
+			return 0, err
+		}
+		er.plainSize += int64(n)
+		er.pending = er.aead.Seal(nil, chunkNonce(er.aead.NonceSize(), er.index, last), chunk[:n], er.aad)
+		er.index++
+		er.done = last
+	}
+	n := copy(p, er.pending)
+	er.pending = er.pending[n:]
+	return n, nil
+}
+
+// decryptReader 逐块解密，任何块认证失败或末块缺失都返回 ErrDecrypt
+type decryptReader struct {
+	rc      io.ReadCloser
+	aead    cipher.AEAD
+	aad     []byte
+	key     string
+	index   uint64
+	pending []byte
+	done    bool
+}
+
+func (dr *decryptReader) Read(p []byte) (int, error) {
+	for len(dr.pending) == 0 {
+		if dr.done {
+			return 0, io.EOF
+		}
+		chunk := make([]byte, encChunkSize+aesGCMTagSize)
+		n, err := io.ReadFull(dr.rc, chunk)
+		last := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		if err != nil && !last {
+			return 0, err
+		}
+		plain, openErr := dr.aead.Open(nil, chunkNonce(dr.aead.NonceSize(), dr.index, last), chunk[:n], dr.aad)
+		if openErr != nil {
+			return 0, fmt.Errorf("%w: %s: chunk %d authentication failed", ErrDecrypt, dr.key, dr.index)
+		}
+		dr.pending = plain
+		dr.index++
+		dr.done = last
+	}
+	n := copy(p, dr.pending)
+	dr.pending = dr.pending[n:]
+	return n, nil
+}
+
+func (dr *decryptReader) Close() error {
+	return dr.rc.Close()
+}
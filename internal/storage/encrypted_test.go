@@ -0,0 +1,315 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testMasterKey 生成确定的 32 字节主密钥
+func testMasterKey(seed byte) []byte {
+	return bytes.Repeat([]byte{seed}, 32)
+}
+
+func newKeyring(t *testing.T, current string, kids ...string) *Keyring {
+	t.Helper()
+	keys := make(map[string][]byte)
+	for _, kid := range append(kids, current) {
+		keys[kid] = testMasterKey(kid[len(kid)-1])
+	}
+	kr, err := NewKeyring(current, keys)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return kr
+}
+
+func newEncrypted(t *testing.T) (*Encrypted, *Local) {
+	t.Helper()
+	inner, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	return NewEncrypted(inner, newKeyring(t, "k1")), inner
+}
+
+// readAll 读取对象全部内容，返回读取过程中的错误
+func readAll(st Storage, key string) ([]byte, error) {
+	rc, err := st.Get(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// rawObject 读取底层存储中的密文
+func rawObject(t *testing.T, inner Storage, key string) []byte {
+	t.Helper()
+	return get(t, inner, key)
+}
+
+func TestEncryptedBackend(t *testing.T) {
+	testBackend(t, func(t *testing.T) Storage {
+		st, _ := newEncrypted(t)
+		return st
+	})
+}
+
+func TestEncryptedRoundTripSizes(t *testing.T) {
+	sizes := []int{0, 1, encChunkSize - 1, encChunkSize, encChunkSize + 1, 2 * encChunkSize, 3*encChunkSize + 17}
+	for _, size := range sizes {
+		t.Run(strconv.Itoa(size), func(t *testing.T) {
+			st, inner := newEncrypted(t)
+			data := testData(size)
+			put(t, st, "tasks/1/report/a.pdf", data)
+
+			raw := rawObject(t, inner, "tasks/1/report/a.pdf")
+			if size >= 16 && bytes.Contains(raw, data) {
+				t.Fatal("plaintext stored in the inner object")
+			}
+			if got := plaintextSize(int64(len(raw))); got != int64(size) {
+				t.Errorf("plaintextSize(%d) = %d, want %d", len(raw), got, size)
+			}
+			got, err := readAll(st, "tasks/1/report/a.pdf")
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("decrypted %d bytes differ from the %d written", len(got), size)
+			}
+		})
+	}
+}
+
+func TestEncryptedRejectsEnvelopeKeys(t *testing.T) {
+	st, _ := newEncrypted(t)
+	put(t, st, "tasks/1/report/a.pdf", []byte("data"))
+	ctx := context.Background()
+	if _, err := st.Put(ctx, "tasks/1/report/a.pdf.dek", strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put envelope key: err = %v, want ErrInvalidKey", err)
+	}
+	if _, err := st.Get(ctx, "tasks/1/report/a.pdf.dek"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Get envelope key: err = %v, want ErrInvalidKey", err)
+	}
+	if got := listKeys(t, st, "tasks/1/"); len(got) != 1 || got[0] != "tasks/1/report/a.pdf" {
+		t.Errorf("List = %v, want only the object without its envelope", got)
+	}
+}
+
+func TestEncryptedRewrap(t *testing.T) {
+	ctx := context.Background()
+	inner, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := NewEncrypted(inner, newKeyring(t, "k1"))
+	data := map[string][]byte{
+		"tasks/1/report/a.pdf": testData(encChunkSize + 5),
+		"tasks/1/policy/b.pdf": testData(10),
+		"tasks/2/report/c.pdf": testData(20),
+	}
+	for key, d := range data {
+		put(t, old, key, d)
+	}
+
+	// 轮换：k2 为当前主密钥，k1 保留用于解封
+	rotated := NewEncrypted(inner, newKeyring(t, "k2", "k1"))
+	n, err := rotated.RewrapPrefix(ctx, "tasks/1/")
+	if err != nil || n != 2 {
+		t.Fatalf("RewrapPrefix(tasks/1/) = %d, %v, want 2", n, err)
+	}
+	if n, err := rotated.RewrapPrefix(ctx, "tasks/1/"); err != nil || n != 0 {
+		t.Fatalf("second RewrapPrefix = %d, %v, want 0", n, err)
+	}
+	if changed, err := rotated.Rewrap(ctx, "tasks/2/report/c.pdf"); err != nil || !changed {
+		t.Fatalf("Rewrap = %v, %v, want changed", changed, err)
+	}
+
+	// 旧主密钥移除后，全部对象仍可用新主密钥读取，内容不变
+	current := NewEncrypted(inner, newKeyring(t, "k2"))
+	for key, want := range data {
+		got, err := readAll(current, key)
+		if err != nil {
+			t.Fatalf("read %s after rewrap: %v", key, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("%s changed after rewrap", key)
+		}
+	}
+	// 只持有旧主密钥时无法再解封
+	if _, err := old.Get(ctx, "tasks/1/report/a.pdf"); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("Get with the retired key: err = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestEncryptedRejectsTampering(t *testing.T) {
+	const key = "tasks/1/report/a.pdf"
+	header := len(encMagic)
+	full := encChunkSize + aesGCMTagSize
+
+	tests := []struct {
+		name   string
+		size   int
+		tamper func(raw []byte) []byte
+	}{
+		{
+			name:   "flipped byte in first chunk",
+			size:   2*encChunkSize + 10,
+			tamper: func(raw []byte) []byte { raw[header+100] ^= 0x01; return raw },
+		},
+		{
+			name:   "flipped tag of final chunk",
+			size:   10,
+			tamper: func(raw []byte) []byte { raw[len(raw)-1] ^= 0x80; return raw },
+		},
+		{
+			name:   "truncated inside final chunk",
+			size:   encChunkSize + 100,
+			tamper: func(raw []byte) []byte { return raw[:len(raw)-5] },
+		},
+		{
+			// 截断到块边界：剩余的最后一块以普通块 nonce 加密，按末块解密失败
+			name:   "final chunk dropped at chunk boundary",
+			size:   2*encChunkSize + 10,
+			tamper: func(raw []byte) []byte { return raw[:header+2*full] },
+		},
+		{
+			// 明文恰为整块时末块为空块（只有 tag），去掉它同样被识别
+			name:   "empty final chunk dropped",
+			size:   2 * encChunkSize,
+			tamper: func(raw []byte) []byte { return raw[:len(raw)-aesGCMTagSize] },
+		},
+		{
+			name: "chunks reordered",
+			size: 2*encChunkSize + 10,
+			tamper: func(raw []byte) []byte {
+				out := append([]byte(nil), raw[:header]...)
+				out = append(out, raw[header+full:header+2*full]...)
+				out = append(out, raw[header:header+full]...)
+				return append(out, raw[header+2*full:]...)
+			},
+		},
+		{
+			name: "chunk duplicated",
+			size: 2*encChunkSize + 10,
+			tamper: func(raw []byte) []byte {
+				out := append([]byte(nil), raw[:header+full]...)
+				out = append(out, raw[header:header+full]...)
+				return append(out, raw[header+2*full:]...)
+			},
+		},
+		{
+			name:   "header removed",
+			size:   10,
+			tamper: func(raw []byte) []byte { return raw[header:] },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, inner := newEncrypted(t)
+			put(t, st, key, testData(tt.size))
+			raw := tt.tamper(rawObject(t, inner, key))
+			put(t, inner, key, raw)
+
+			if _, err := readAll(st, key); !errors.Is(err, ErrDecrypt) {
+				t.Fatalf("read tampered object: err = %v, want ErrDecrypt", err)
+			}
+		})
+	}
+}
+
+func TestEncryptedBindsObjectKey(t *testing.T) {
+	st, inner := newEncrypted(t)
+	put(t, st, "tasks/1/report/a.pdf", []byte("report of task 1"))
+	put(t, st, "tasks/2/report/b.pdf", []byte("report of task 2"))
+
+	t.Run("ciphertext moved to another key", func(t *testing.T) {
+		put(t, inner, "tasks/2/report/b.pdf", rawObject(t, inner, "tasks/1/report/a.pdf"))
+		if _, err := readAll(st, "tasks/2/report/b.pdf"); !errors.Is(err, ErrDecrypt) {
+			t.Fatalf("err = %v, want ErrDecrypt", err)
+		}
+	})
+
+	t.Run("envelope moved to another key", func(t *testing.T) {
+		put(t, inner, "tasks/2/report/b.pdf.dek", rawObject(t, inner, "tasks/1/report/a.pdf.dek"))
+		if _, err := readAll(st, "tasks/2/report/b.pdf"); !errors.Is(err, ErrDecrypt) {
+			t.Fatalf("err = %v, want ErrDecrypt", err)
+		}
+	})
+
+	t.Run("malformed envelope", func(t *testing.T) {
+		put(t, inner, "tasks/1/report/a.pdf.dek", []byte(`{"version":1,"kid":"k1","wrapped_key":"AAAA"}`))
+		if _, err := readAll(st, "tasks/1/report/a.pdf"); !errors.Is(err, ErrDecrypt) {
+			t.Fatalf("err = %v, want ErrDecrypt", err)
+		}
+	})
+}
+
+func TestChunkNonce(t *testing.T) {
+	a := chunkNonce(12, 3, false)
+	b := chunkNonce(12, 3, true)
+	if bytes.Equal(a, b) {
+		t.Fatal("final-chunk flag does not change the nonce")
+	}
+	if bytes.Equal(chunkNonce(12, 3, false), chunkNonce(12, 4, false)) {
+		t.Fatal("chunk index does not change the nonce")
+	}
+	if !bytes.Equal(a, chunkNonce(12, 3, false)) {
+		t.Fatal("nonce is not deterministic")
+	}
+}
+
+// TestEncryptedFinalChunkFlag 用对象自身的数据密钥重新构造密文，
+// 只有末块以末块 nonce 加密时才能解密
+func TestEncryptedFinalChunkFlag(t *testing.T) {
+	const key = "tasks/1/report/a.pdf"
+	ctx := context.Background()
+	plain := testData(encChunkSize + 10)
+
+	tests := []struct {
+		name      string
+		lastFlags []bool
+		wantErr   bool
+	}{
+		{name: "final chunk flagged", lastFlags: []bool{false, true}},
+		{name: "final chunk not flagged", lastFlags: []bool{false, false}, wantErr: true},
+		{name: "first chunk flagged", lastFlags: []bool{true, true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, inner := newEncrypted(t)
+			put(t, st, key, plain)
+			dek, _, err := st.openEnvelope(ctx, key)
+			if err != nil {
+				t.Fatalf("openEnvelope: %v", err)
+			}
+			aead, err := newAEAD(dek)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			raw := append([]byte(nil), encMagic...)
+			chunks := [][]byte{plain[:encChunkSize], plain[encChunkSize:]}
+			for i, chunk := range chunks {
+				raw = aead.Seal(raw, chunkNonce(aead.NonceSize(), uint64(i), tt.lastFlags[i]), chunk, []byte(key))
+			}
+			put(t, inner, key, raw)
+
+			got, err := readAll(st, key)
+			if tt.wantErr {
+				if !errors.Is(err, ErrDecrypt) {
+					t.Fatalf("err = %v, want ErrDecrypt", err)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(got, plain) {
+				t.Fatalf("read = %d bytes, %v, want the original plaintext", len(got), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/storage/sigv4"
+)
+
+const (
+	// minPartSize S3 分片上传的最小分片（最后一片除外）
+	minPartSize = 5 << 20
+	// defaultS3Region MinIO 等 S3 兼容服务的默认区域
+	defaultS3Region = "us-east-1"
+)
+
+// S3Options S3 兼容存储配置
+type S3Options struct {
+	// Endpoint 服务地址，如 http://localhost:9000；未带协议时默认 https
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PartSize 分片大小，默认 5MB；Put 在内存中最多缓冲一个分片
+	PartSize   int
+	HTTPClient *http.Client
+}
+
+// S3 基于 SigV4 签名的 S3 兼容存储，使用 path-style 寻址以兼容 MinIO
+type S3 struct {
+	endpoint *url.URL
+	bucket   string
+	signer   *sigv4.Signer
+	client   *http.Client
+	partSize int
+	now      func() time.Time
+}
+
+// S3Error S3 服务端返回的错误
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *S3Error) Error() string {
+	return fmt.Sprintf("s3 error: status=%d code=%s message=%s", e.StatusCode, e.Code, e.Message)
+}
+
+// NewS3 创建 S3 兼容存储
+func NewS3(opts S3Options) (*S3, error) {
+	raw := strings.TrimSpace(opts.Endpoint)
+	if raw == "" || opts.Bucket == "" {
+		return nil, errors.New("s3 endpoint and bucket are required")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(raw, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint %s: %w", opts.Endpoint, err)
+	}
+
+	region := opts.Region
+	if region == "" {
+		region = defaultS3Region
+	}
+	partSize := opts.PartSize
+	if partSize < minPartSize {
+		partSize = minPartSize
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+
+	return &S3{
+		endpoint: endpoint,
+		bucket:   opts.Bucket,
+		signer: &sigv4.Signer{
+			AccessKey: opts.AccessKey,
+			SecretKey: opts.SecretKey,
+			Region:    region,
+			Service:   "s3",
+		},
+		client:   client,
+		partSize: partSize,
+		now:      time.Now,
+	}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	if _, err := cleanKey(key); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, s.partSize)
+	n, err := io.ReadFull(r, buf)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		// 小于一个分片，直接单次上传
+		resp, putErr := s.do(ctx, http.MethodPut, key, nil, buf[:n])
+		if putErr != nil {
+			return 0, fmt.Errorf("failed to put object %s: %w", key, putErr)
+		}
+		resp.Body.Close()
+		return int64(n), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read object %s: %w", key, err)
+	}
+
+	return s.putMultipart(ctx, key, r, buf, n)
+}
+
+func (s *S3) putMultipart(ctx context.Context, key string, r io.Reader, buf []byte, n int) (int64, error) {
+	uploadID, err := s.createMultipartUpload(ctx, key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create multipart upload %s: %w", key, err)
+	}
+
+	var (
+		parts   []completedPart
+		written int64
+	)
+	for partNumber := 1; n > 0; partNumber++ {
+		etag, partErr := s.uploadPart(ctx, key, uploadID, partNumber, buf[:n])
+		if partErr != nil {
+			s.abortMultipartUpload(ctx, key, uploadID)
+			return written, fmt.Errorf("failed to upload part %d of %s: %w", partNumber, key, partErr)
+		}
+		parts = append(parts, completedPart{PartNumber: partNumber, ETag: etag})
+		written += int64(n)
+
+		n, err = io.ReadFull(r, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			s.abortMultipartUpload(ctx, key, uploadID)
+			return written, fmt.Errorf("failed to read object %s: %w", key, err)
+		}
+	}
+
+	if err := s.completeMultipartUpload(ctx, key, uploadID, parts); err != nil {
+		s.abortMultipartUpload(ctx, key, uploadID)
+		return written, fmt.Errorf("failed to complete multipart upload %s: %w", key, err)
+	}
+	return written, nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if _, err := cleanKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if _, err := cleanKey(key); err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	if _, err := cleanKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	info := &ObjectInfo{Key: key, Size: resp.ContentLength}
+	if lastModified, parseErr := http.ParseTime(resp.Header.Get("Last-Modified")); parseErr == nil {
+		info.LastModified = lastModified
+	}
+	return info, nil
+}
+
+func (s *S3) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	prefix, err := cleanPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		objects []ObjectInfo
+		token   string
+	)
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		var result listBucketResult
+		if err := s.doXML(ctx, http.MethodGet, "", query, nil, &result); err != nil {
+			return nil, fmt.Errorf("failed to list objects with prefix %s: %w", prefix, err)
+		}
+		for _, c := range result.Contents {
+			objects = append(objects, ObjectInfo{Key: c.Key, Size: c.Size, LastModified: c.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (s *S3) createMultipartUpload(ctx context.Context, key string) (string, error) {
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := s.doXML(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, &result); err != nil {
+		return "", err
+	}
+	if result.UploadID == "" {
+		return "", errors.New("empty upload id")
+	}
+	return result.UploadID, nil
+}
+
+func (s *S3) uploadPart(ctx context.Context, key, uploadID string, partNumber int, data []byte) (string, error) {
+	query := url.Values{
+		"partNumber": {strconv.Itoa(partNumber)},
+		"uploadId":   {uploadID},
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, query, data)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+func (s *S3) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []completedPart) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	return s.doXML(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, body, nil)
+}
+
+func (s *S3) abortMultipartUpload(ctx context.Context, key, uploadID string) {
+	resp, err := s.do(context.WithoutCancel(ctx), http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil)
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+// doXML 发送请求并解析 XML 响应体，out 为 nil 时丢弃响应体
+func (s *S3) doXML(ctx context.Context, method, key string, query url.Values, body []byte, out interface{}) error {
+	resp, err := s.do(ctx, method, key, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode s3 response: %w", err)
+	}
+	return nil
+}
+
+// do 签名并发送请求，非 2xx 响应转换为 ErrNotFound 或 *S3Error
+func (s *S3) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	u := *s.endpoint
+	u.Path = s.endpoint.Path + "/" + s.bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawQuery = strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	if len(body) == 0 {
+		req.Body = http.NoBody
+	}
+	s.signer.Sign(req, sigv4.HashPayload(body), s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	s3Err := &S3Error{StatusCode: resp.StatusCode}
+	if method != http.MethodHead {
+		_ = xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(s3Err)
+	}
+	if resp.StatusCode == http.StatusNotFound && (s3Err.Code == "" || s3Err.Code == "NoSuchKey") {
+		return nil, ErrNotFound
+	}
+	return nil, s3Err
+}
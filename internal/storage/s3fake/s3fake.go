@@ -0,0 +1,302 @@
+// Package s3fake 提供进程内的 S3 兼容服务，用于在没有 MinIO 的环境下验证 S3 存储后端。
+// 支持 PutObject、GetObject、HeadObject、DeleteObject、ListObjectsV2 与分片上传，
+// 并对每个请求校验 SigV4 签名与载荷摘要。
+package s3fake
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhenglizhi/policy-fit/internal/storage/sigv4"
+)
+
+// defaultMaxKeys ListObjectsV2 单页默认返回数量
+const defaultMaxKeys = 1000
+
+type object struct {
+	data         []byte
+	etag         string
+	lastModified time.Time
+}
+
+type upload struct {
+	key   string
+	parts map[int][]byte
+}
+
+// Server 内存 S3 服务，实现 http.Handler，可配合 httptest.NewServer 使用
+type Server struct {
+	bucket    string
+	accessKey string
+	secretKey string
+
+	// MaxKeys 列举单页最大数量，便于验证分页
+	MaxKeys int
+
+	mu      sync.Mutex
+	objects map[string]object
+	uploads map[string]*upload
+	nextID  int
+}
+
+// New 创建只包含一个 bucket 的内存 S3 服务
+func New(bucket, accessKey, secretKey string) *Server {
+	return &Server{
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		MaxKeys:   defaultMaxKeys,
+		objects:   make(map[string]object),
+		uploads:   make(map[string]*upload),
+	}
+}
+
+// Keys 返回当前全部对象键（已排序）
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedKeys("")
+}
+
+// PendingUploads 返回未完成的分片上传数量
+func (s *Server) PendingUploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.uploads)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != s.bucket {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "the specified bucket does not exist")
+		return
+	}
+
+	err := sigv4.Verify(r, func(accessKey string) (string, bool) {
+		return s.secretKey, accessKey == s.accessKey
+	})
+	if err != nil {
+		writeError(w, http.StatusForbidden, "SignatureDoesNotMatch", err.Error())
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	if sigv4.HashPayload(body) != r.Header.Get(sigv4.HeaderContentSHA256) {
+		writeError(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch", "payload hash does not match")
+		return
+	}
+
+	query := r.URL.Query()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		s.listObjects(w, query.Get("prefix"), query.Get("continuation-token"))
+	case key == "":
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported bucket operation")
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.createUpload(w, key)
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		s.completeUpload(w, key, query.Get("uploadId"), body)
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		s.uploadPart(w, key, query.Get("uploadId"), query.Get("partNumber"), body)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(s.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		obj := newObject(body)
+		s.objects[key] = obj
+		w.Header().Set("ETag", obj.etag)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.getObject(w, r.Method, key)
+	case r.Method == http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "unsupported object operation")
+	}
+}
+
+func (s *Server) getObject(w http.ResponseWriter, method, key string) {
+	obj, ok := s.objects[key]
+	if !ok {
+		if method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeError(w, http.StatusNotFound, "NoSuchKey", "the specified key does not exist")
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+	w.Header().Set("ETag", obj.etag)
+	w.Header().Set("Last-Modified", obj.lastModified.Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+	if method == http.MethodGet {
+		_, _ = w.Write(obj.data)
+	}
+}
+
+func (s *Server) listObjects(w http.ResponseWriter, prefix, token string) {
+	type contents struct {
+		Key          string    `xml:"Key"`
+		Size         int       `xml:"Size"`
+		ETag         string    `xml:"ETag"`
+		LastModified time.Time `xml:"LastModified"`
+	}
+	result := struct {
+		XMLName               xml.Name   `xml:"ListBucketResult"`
+		Name                  string     `xml:"Name"`
+		Prefix                string     `xml:"Prefix"`
+		KeyCount              int        `xml:"KeyCount"`
+		IsTruncated           bool       `xml:"IsTruncated"`
+		NextContinuationToken string     `xml:"NextContinuationToken,omitempty"`
+		Contents              []contents `xml:"Contents"`
+	}{Name: s.bucket, Prefix: prefix}
+
+	for _, key := range s.sortedKeys(prefix) {
+		if token != "" && key <= token {
+			continue
+		}
+		if len(result.Contents) == s.MaxKeys {
+			result.IsTruncated = true
+			result.NextContinuationToken = result.Contents[len(result.Contents)-1].Key
+			break
+		}
+		obj := s.objects[key]
+		result.Contents = append(result.Contents, contents{
+			Key:          key,
+			Size:         len(obj.data),
+			ETag:         obj.etag,
+			LastModified: obj.lastModified,
+		})
+	}
+	result.KeyCount = len(result.Contents)
+	writeXML(w, result)
+}
+
+func (s *Server) createUpload(w http.ResponseWriter, key string) {
+	s.nextID++
+	uploadID := fmt.Sprintf("upload-%d", s.nextID)
+	s.uploads[uploadID] = &upload{key: key, parts: make(map[int][]byte)}
+
+	writeXML(w, struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		UploadID string   `xml:"UploadId"`
+	}{Bucket: s.bucket, Key: key, UploadID: uploadID})
+}
+
+func (s *Server) uploadPart(w http.ResponseWriter, key, uploadID, partNumber string, body []byte) {
+	up, ok := s.uploads[uploadID]
+	if !ok || up.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+		return
+	}
+	n, err := strconv.Atoi(partNumber)
+	if err != nil || n < 1 || n > 10000 {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", "invalid part number")
+		return
+	}
+	up.parts[n] = body
+	w.Header().Set("ETag", etagOf(body))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) completeUpload(w http.ResponseWriter, key, uploadID string, body []byte) {
+	up, ok := s.uploads[uploadID]
+	if !ok || up.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+		return
+	}
+
+	var req struct {
+		Parts []struct {
+			PartNumber int    `xml:"PartNumber"`
+			ETag       string `xml:"ETag"`
+		} `xml:"Part"`
+	}
+	if err := xml.Unmarshal(body, &req); err != nil || len(req.Parts) == 0 {
+		writeError(w, http.StatusBadRequest, "MalformedXML", "invalid complete multipart upload body")
+		return
+	}
+
+	var data []byte
+	for i, part := range req.Parts {
+		chunk, ok := up.parts[part.PartNumber]
+		if !ok || etagOf(chunk) != part.ETag {
+			writeError(w, http.StatusBadRequest, "InvalidPart", "one or more parts could not be found")
+			return
+		}
+		if i > 0 && part.PartNumber <= req.Parts[i-1].PartNumber {
+			writeError(w, http.StatusBadRequest, "InvalidPartOrder", "parts must be in ascending order")
+			return
+		}
+		data = append(data, chunk...)
+	}
+
+	obj := newObject(data)
+	s.objects[key] = obj
+	delete(s.uploads, uploadID)
+
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Bucket  string   `xml:"Bucket"`
+		Key     string   `xml:"Key"`
+		ETag    string   `xml:"ETag"`
+	}{Bucket: s.bucket, Key: key, ETag: obj.etag})
+}
+
+func (s *Server) sortedKeys(prefix string) []string {
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newObject(data []byte) object {
+	return object{data: data, etag: etagOf(data), lastModified: time.Now().UTC().Truncate(time.Second)}
+}
+
+func etagOf(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, xml.Header)
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, xml.Header)
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: code, Message: message})
+}
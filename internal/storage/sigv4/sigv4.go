@@ -0,0 +1,224 @@
+// Package sigv4 实现 AWS Signature Version 4 请求签名与校验，
+// 供 S3 兼容存储客户端以及测试用的内存 S3 服务共用。
+package sigv4
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	algorithm  = "AWS4-HMAC-SHA256"
+	timeFormat = "20060102T150405Z"
+	dateFormat = "20060102"
+
+	// HeaderDate 签名时间请求头
+	HeaderDate = "X-Amz-Date"
+	// HeaderContentSHA256 载荷摘要请求头
+	HeaderContentSHA256 = "X-Amz-Content-Sha256"
+)
+
+// EmptyPayloadHash 空载荷的 SHA-256
+var EmptyPayloadHash = HashPayload(nil)
+
+// Signer 请求签名器
+type Signer struct {
+	AccessKey string
+	SecretKey string
+	Region    string
+	Service   string
+}
+
+// HashPayload 计算载荷的十六进制 SHA-256
+func HashPayload(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// Sign 为请求签名，设置 X-Amz-Date、X-Amz-Content-Sha256 与 Authorization 头
+func (s *Signer) Sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set(HeaderDate, now.Format(timeFormat))
+	req.Header.Set(HeaderContentSHA256, payloadHash)
+
+	signedHeaders := headersToSign(req)
+	signature := s.signature(req, signedHeaders, payloadHash, now)
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm,
+		s.AccessKey,
+		s.scope(now),
+		strings.Join(signedHeaders, ";"),
+		signature,
+	))
+}
+
+// Verify 校验请求签名，secretKey 根据 Authorization 中的 access key 查找
+func Verify(req *http.Request, lookup func(accessKey string) (secretKey string, ok bool)) error {
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, algorithm+" ") {
+		return errors.New("unsupported authorization algorithm")
+	}
+
+	fields := make(map[string]string)
+	for _, part := range strings.Split(strings.TrimPrefix(auth, algorithm+" "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) == 2 {
+			fields[kv[0]] = kv[1]
+		}
+	}
+
+	credential := strings.Split(fields["Credential"], "/")
+	if len(credential) != 5 || credential[4] != "aws4_request" {
+		return errors.New("malformed credential scope")
+	}
+	secretKey, ok := lookup(credential[0])
+	if !ok {
+		return errors.New("unknown access key")
+	}
+
+	now, err := time.Parse(timeFormat, req.Header.Get(HeaderDate))
+	if err != nil {
+		return errors.New("missing or malformed X-Amz-Date")
+	}
+	if now.Format(dateFormat) != credential[1] {
+		return errors.New("credential date does not match X-Amz-Date")
+	}
+
+	signer := &Signer{AccessKey: credential[0], SecretKey: secretKey, Region: credential[2], Service: credential[3]}
+	signedHeaders := strings.Split(fields["SignedHeaders"], ";")
+	expected := signer.signature(req, signedHeaders, req.Header.Get(HeaderContentSHA256), now)
+	if !hmac.Equal([]byte(expected), []byte(fields["Signature"])) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+func (s *Signer) scope(now time.Time) string {
+	return strings.Join([]string{now.Format(dateFormat), s.Region, s.Service, "aws4_request"}, "/")
+}
+
+func (s *Signer) signature(req *http.Request, signedHeaders []string, payloadHash string, now time.Time) string {
+	canonical := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders(req, signedHeaders),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		algorithm,
+		now.Format(timeFormat),
+		s.scope(now),
+		HashPayload([]byte(canonical)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), now.Format(dateFormat))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// headersToSign 签名 host 以及所有 x-amz-*、content-type、content-md5、range 头
+func headersToSign(req *http.Request) []string {
+	headers := []string{"host"}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		switch {
+		case lower == "authorization":
+		case strings.HasPrefix(lower, "x-amz-"), lower == "content-type", lower == "content-md5", lower == "range":
+			headers = append(headers, lower)
+		}
+	}
+	sort.Strings(headers)
+	return headers
+}
+
+func canonicalHeaders(req *http.Request, signedHeaders []string) string {
+	var b strings.Builder
+	for _, name := range signedHeaders {
+		var value string
+		if name == "host" {
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		} else {
+			value = strings.Join(req.Header.Values(name), ",")
+		}
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(strings.Fields(value), " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func canonicalURI(u *url.URL) string {
+	p := u.EscapedPath()
+	if p == "" {
+		return "/"
+	}
+	// 统一按 RFC 3986 重新编码各路径段
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		if unescaped, err := url.PathUnescape(seg); err == nil {
+			segments[i] = escape(unescaped, false)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, escape(k, true)+"="+escape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escape 按 SigV4 规则进行 URI 编码，仅保留非保留字符
+func escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,55 @@
+package sigv4
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	signer := &Signer{AccessKey: "AKID", SecretKey: "secret", Region: "us-east-1", Service: "s3"}
+	lookup := func(accessKey string) (string, bool) {
+		return "secret", accessKey == "AKID"
+	}
+	now := time.Date(2026, 10, 18, 8, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		tamper  func(req *http.Request)
+		wantErr string
+	}{
+		{name: "valid", tamper: func(*http.Request) {}},
+		{name: "path changed", tamper: func(req *http.Request) { req.URL.Path = "/bucket/tasks/2/a.pdf" }, wantErr: "signature does not match"},
+		{name: "query changed", tamper: func(req *http.Request) { req.URL.RawQuery = "partNumber=2&uploadId=u-1" }, wantErr: "signature does not match"},
+		{name: "method changed", tamper: func(req *http.Request) { req.Method = http.MethodDelete }, wantErr: "signature does not match"},
+		{name: "payload hash changed", tamper: func(req *http.Request) { req.Header.Set(HeaderContentSHA256, HashPayload([]byte("other"))) }, wantErr: "signature does not match"},
+		{name: "date changed", tamper: func(req *http.Request) { req.Header.Set(HeaderDate, now.Add(time.Hour).Format(timeFormat)) }, wantErr: "signature does not match"},
+		{name: "date outside credential scope", tamper: func(req *http.Request) { req.Header.Set(HeaderDate, now.Add(24*time.Hour).Format(timeFormat)) }, wantErr: "credential date"},
+		{name: "unknown access key", tamper: func(req *http.Request) {
+			req.Header.Set("Authorization", strings.Replace(req.Header.Get("Authorization"), "Credential=AKID/", "Credential=OTHER/", 1))
+		}, wantErr: "unknown access key"},
+		{name: "missing authorization", tamper: func(req *http.Request) { req.Header.Del("Authorization") }, wantErr: "unsupported authorization"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPut, "http://localhost:9000/bucket/tasks/1/a.pdf?partNumber=1&uploadId=u-1", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signer.Sign(req, HashPayload([]byte("body")), now)
+			tt.tamper(req)
+
+			err = Verify(req, lookup)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Verify: err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zhenglizhi/policy-fit/internal/config"
+	"github.com/zhenglizhi/policy-fit/internal/domain"
+)
+
+var (
+	// ErrNotFound 对象不存在
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidKey 对象键不合法
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// ObjectInfo 对象元信息
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Storage 对象存储
+//
+// 对象键统一使用 "/" 分隔的相对路径，文档对象遵循
+// tasks/{taskId}/{docType}/{uuid}.pdf 规范（见 DocumentKey 与 docs/storage.md）。
+type Storage interface {
+	// Put 以流式方式写入对象，返回写入的字节数
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Get 读取对象，调用方负责关闭；对象不存在返回 ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，对象不存在时不视为错误
+	Delete(ctx context.Context, key string) error
+	// Stat 获取对象元信息；对象不存在返回 ErrNotFound
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// List 按前缀列出对象，结果按键排序
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// New 按配置创建对象存储，配置了 STORAGE_ENCRYPTION_KEY 时启用应用层加密
+func New(cfg config.StorageConfig) (Storage, error) {
+	var (
+		st  Storage
+		err error
+	)
+	switch cfg.Type {
+	case "local":
+		st, err = NewLocal(cfg.Path)
+	case "s3":
+		st, err = NewS3(S3Options{
+			Endpoint:  cfg.Endpoint,
+			Region:    cfg.Region,
+			Bucket:    cfg.Bucket,
+			AccessKey: cfg.AccessKey,
+			SecretKey: cfg.SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported storage type: %s", cfg.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	current, keys, err := cfg.EncryptionKeys()
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		return st, nil
+	}
+	keyring, err := NewKeyring(current, keys)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncrypted(st, keyring), nil
+}
+
+// DocumentKey 生成文档对象键：tasks/{taskId}/{docType}/{uuid}.pdf
+func DocumentKey(taskID int64, docType domain.DocumentType) string {
+	return fmt.Sprintf("%s%s/%s.pdf", TaskPrefix(taskID), docType, uuid.NewString())
+}
+
+// TaskPrefix 任务下所有对象的公共前缀：tasks/{taskId}/
+func TaskPrefix(taskID int64) string {
+	return fmt.Sprintf("tasks/%d/", taskID)
+}
+
+// DeletePrefix 删除前缀下的全部对象，返回删除数量
+func DeletePrefix(ctx context.Context, st Storage, prefix string) (int, error) {
+	objects, err := st.List(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	for i, obj := range objects {
+		if err := st.Delete(ctx, obj.Key); err != nil {
+			return i, err
+		}
+	}
+	return len(objects), nil
+}
+
+// cleanKey 校验对象键，拒绝绝对路径与目录穿越
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || strings.HasPrefix(cleaned, "../") || cleaned == ".." {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return cleaned, nil
+}
+
+// cleanPrefix 校验列举前缀，允许空前缀与以 "/" 结尾的目录前缀
+func cleanPrefix(prefix string) (string, error) {
+	if prefix == "" {
+		return "", nil
+	}
+	if _, err := cleanKey(strings.TrimSuffix(prefix, "/")); err != nil {
+		return "", err
+	}
+	return prefix, nil
+}
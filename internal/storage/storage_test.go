@@ -0,0 +1,361 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zhenglizhi/policy-fit/internal/storage/s3fake"
+)
+
+const (
+	testBucket    = "policy-fit-test"
+	testAccessKey = "test-access-key"
+	testSecretKey = "test-secret-key"
+)
+
+// testData 生成可复现的随机内容
+func testData(n int) []byte {
+	data := make([]byte, n)
+	rand.New(rand.NewSource(int64(n))).Read(data)
+	return data
+}
+
+func put(t *testing.T, st Storage, key string, data []byte) {
+	t.Helper()
+	n, err := st.Put(context.Background(), key, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Put(%s): %v", key, err)
+	}
+	if n != int64(len(data)) {
+		t.Fatalf("Put(%s) wrote %d bytes, want %d", key, n, len(data))
+	}
+}
+
+func get(t *testing.T, st Storage, key string) []byte {
+	t.Helper()
+	rc, err := st.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", key, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read %s: %v", key, err)
+	}
+	return data
+}
+
+func listKeys(t *testing.T, st Storage, prefix string) []string {
+	t.Helper()
+	objects, err := st.List(context.Background(), prefix)
+	if err != nil {
+		t.Fatalf("List(%q): %v", prefix, err)
+	}
+	keys := make([]string, len(objects))
+	for i, obj := range objects {
+		keys[i] = obj.Key
+	}
+	return keys
+}
+
+// testBackend 各存储后端共用的行为约定
+func testBackend(t *testing.T, newStorage func(t *testing.T) Storage) {
+	ctx := context.Background()
+
+	t.Run("put get stat", func(t *testing.T) {
+		st := newStorage(t)
+		data := testData(1024)
+		put(t, st, "tasks/1/report/a.pdf", data)
+
+		if got := get(t, st, "tasks/1/report/a.pdf"); !bytes.Equal(got, data) {
+			t.Fatalf("Get returned %d bytes that differ from the %d written", len(got), len(data))
+		}
+		info, err := st.Stat(ctx, "tasks/1/report/a.pdf")
+		if err != nil {
+			t.Fatalf("Stat: %v", err)
+		}
+		if info.Key != "tasks/1/report/a.pdf" || info.Size != int64(len(data)) {
+			t.Fatalf("Stat = %+v, want key tasks/1/report/a.pdf size %d", info, len(data))
+		}
+	})
+
+	t.Run("put overwrites", func(t *testing.T) {
+		st := newStorage(t)
+		put(t, st, "tasks/1/report/a.pdf", []byte("first"))
+		put(t, st, "tasks/1/report/a.pdf", []byte("second"))
+		if got := get(t, st, "tasks/1/report/a.pdf"); string(got) != "second" {
+			t.Fatalf("Get = %q, want second", got)
+		}
+	})
+
+	t.Run("empty object", func(t *testing.T) {
+		st := newStorage(t)
+		put(t, st, "tasks/1/empty", nil)
+		if got := get(t, st, "tasks/1/empty"); len(got) != 0 {
+			t.Fatalf("Get = %d bytes, want 0", len(got))
+		}
+	})
+
+	t.Run("missing object", func(t *testing.T) {
+		st := newStorage(t)
+		if _, err := st.Get(ctx, "tasks/1/missing.pdf"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get missing: err = %v, want ErrNotFound", err)
+		}
+		if _, err := st.Stat(ctx, "tasks/1/missing.pdf"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Stat missing: err = %v, want ErrNotFound", err)
+		}
+		if err := st.Delete(ctx, "tasks/1/missing.pdf"); err != nil {
+			t.Errorf("Delete missing: %v", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		st := newStorage(t)
+		put(t, st, "tasks/1/report/a.pdf", []byte("data"))
+		if err := st.Delete(ctx, "tasks/1/report/a.pdf"); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := st.Stat(ctx, "tasks/1/report/a.pdf"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Stat after delete: err = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("list by prefix", func(t *testing.T) {
+		st := newStorage(t)
+		for _, key := range []string{
+			"tasks/2/report/b.pdf",
+			"tasks/1/report/a.pdf",
+			"tasks/1/policy/c.pdf",
+			"tasks/10/report/d.pdf",
+			"other/e.pdf",
+		} {
+			put(t, st, key, []byte(key))
+		}
+
+		tests := []struct {
+			prefix string
+			want   []string
+		}{
+			{"tasks/1/", []string{"tasks/1/policy/c.pdf", "tasks/1/report/a.pdf"}},
+			{"tasks/1/report/", []string{"tasks/1/report/a.pdf"}},
+			{"tasks/", []string{"tasks/1/policy/c.pdf", "tasks/1/report/a.pdf", "tasks/10/report/d.pdf", "tasks/2/report/b.pdf"}},
+			{"tasks/3/", []string{}},
+			{"", []string{"other/e.pdf", "tasks/1/policy/c.pdf", "tasks/1/report/a.pdf", "tasks/10/report/d.pdf", "tasks/2/report/b.pdf"}},
+		}
+		for _, tt := range tests {
+			if got := listKeys(t, st, tt.prefix); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List(%q) = %v, want %v", tt.prefix, got, tt.want)
+			}
+		}
+
+		n, err := DeletePrefix(ctx, st, TaskPrefix(1))
+		if err != nil || n != 2 {
+			t.Fatalf("DeletePrefix = %d, %v, want 2", n, err)
+		}
+		if got := listKeys(t, st, "tasks/1/"); len(got) != 0 {
+			t.Fatalf("List after DeletePrefix = %v, want empty", got)
+		}
+	})
+
+	t.Run("invalid keys", func(t *testing.T) {
+		st := newStorage(t)
+		for _, key := range []string{"", "/abs", "../escape", "tasks/../../escape", "tasks//a", "tasks/./a", `tasks\a`} {
+			if _, err := st.Put(ctx, key, strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Put(%q): err = %v, want ErrInvalidKey", key, err)
+			}
+			if _, err := st.Get(ctx, key); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Get(%q): err = %v, want ErrInvalidKey", key, err)
+			}
+		}
+		if _, err := st.List(ctx, "../"); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("List(../): err = %v, want ErrInvalidKey", err)
+		}
+	})
+
+	// 跨越 5MB 分片边界的对象：恰好一个分片、多一个字节、多个分片
+	for _, size := range []int{minPartSize - 1, minPartSize, minPartSize + 1, 2*minPartSize + 123} {
+		size := size
+		t.Run("large object "+strconv.Itoa(size), func(t *testing.T) {
+			st := newStorage(t)
+			data := testData(size)
+			put(t, st, "tasks/1/report/large.pdf", data)
+			if got := get(t, st, "tasks/1/report/large.pdf"); !bytes.Equal(got, data) {
+				t.Fatalf("round trip of %d bytes returned %d differing bytes", size, len(got))
+			}
+			info, err := st.Stat(ctx, "tasks/1/report/large.pdf")
+			if err != nil || info.Size != int64(size) {
+				t.Fatalf("Stat = %+v, %v, want size %d", info, err, size)
+			}
+		})
+	}
+}
+
+func TestLocal(t *testing.T) {
+	testBackend(t, func(t *testing.T) Storage {
+		st, err := NewLocal(t.TempDir())
+		if err != nil {
+			t.Fatalf("NewLocal: %v", err)
+		}
+		return st
+	})
+}
+
+// s3Recorder 记录发往内存 S3 服务的请求
+type s3Recorder struct {
+	next http.Handler
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (r *s3Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	r.requests = append(r.requests, req.Method+" "+req.URL.RawQuery)
+	r.mu.Unlock()
+	r.next.ServeHTTP(w, req)
+}
+
+// count 统计 query 中包含 param 的请求数
+func (r *s3Recorder) count(method, param string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, req := range r.requests {
+		m, query, _ := strings.Cut(req, " ")
+		if m == method && strings.Contains(query, param) {
+			n++
+		}
+	}
+	return n
+}
+
+func newS3(t *testing.T, secretKey string) (*S3, *s3fake.Server, *s3Recorder) {
+	t.Helper()
+	fake := s3fake.New(testBucket, testAccessKey, testSecretKey)
+	rec := &s3Recorder{next: fake}
+	srv := httptest.NewServer(rec)
+	t.Cleanup(srv.Close)
+
+	st, err := NewS3(S3Options{
+		Endpoint:  srv.URL,
+		Bucket:    testBucket,
+		AccessKey: testAccessKey,
+		SecretKey: secretKey,
+	})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	return st, fake, rec
+}
+
+func TestS3(t *testing.T) {
+	testBackend(t, func(t *testing.T) Storage {
+		st, _, _ := newS3(t, testSecretKey)
+		return st
+	})
+}
+
+func TestS3Multipart(t *testing.T) {
+	tests := []struct {
+		size      int
+		wantParts int
+	}{
+		{minPartSize - 1, 0},
+		{minPartSize, 1},
+		{minPartSize + 1, 2},
+		{3*minPartSize + 7, 4},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.size), func(t *testing.T) {
+			st, fake, rec := newS3(t, testSecretKey)
+			data := testData(tt.size)
+			put(t, st, "tasks/1/report/large.pdf", data)
+
+			if got := rec.count(http.MethodPut, "partNumber="); got != tt.wantParts {
+				t.Errorf("uploaded %d parts, want %d", got, tt.wantParts)
+			}
+			if fake.PendingUploads() != 0 {
+				t.Errorf("%d multipart uploads left pending", fake.PendingUploads())
+			}
+			if got := get(t, st, "tasks/1/report/large.pdf"); !bytes.Equal(got, data) {
+				t.Fatal("multipart object differs from the written data")
+			}
+		})
+	}
+}
+
+// failingReader 读出 n 字节后返回错误
+type failingReader struct {
+	r io.Reader
+	n int
+}
+
+var errReadFailed = errors.New("read failed")
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.n <= 0 {
+		return 0, errReadFailed
+	}
+	if len(p) > f.n {
+		p = p[:f.n]
+	}
+	n, err := f.r.Read(p)
+	f.n -= n
+	return n, err
+}
+
+func TestS3MultipartAbortsOnReadError(t *testing.T) {
+	st, fake, rec := newS3(t, testSecretKey)
+	data := testData(2 * minPartSize)
+	_, err := st.Put(context.Background(), "tasks/1/report/large.pdf", &failingReader{r: bytes.NewReader(data), n: minPartSize + 10})
+	if !errors.Is(err, errReadFailed) {
+		t.Fatalf("Put: err = %v, want read error", err)
+	}
+	if rec.count(http.MethodDelete, "uploadId=") != 1 {
+		t.Error("multipart upload was not aborted")
+	}
+	if fake.PendingUploads() != 0 || len(fake.Keys()) != 0 {
+		t.Errorf("pending uploads %d, keys %v, want none", fake.PendingUploads(), fake.Keys())
+	}
+}
+
+func TestS3ListPagination(t *testing.T) {
+	st, fake, _ := newS3(t, testSecretKey)
+	fake.MaxKeys = 2
+	var want []string
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		key := "tasks/1/report/" + name + ".pdf"
+		put(t, st, key, []byte(name))
+		want = append(want, key)
+	}
+	if got := listKeys(t, st, "tasks/1/"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("List = %v, want %v", got, want)
+	}
+}
+
+func TestS3SignatureRejected(t *testing.T) {
+	st, fake, _ := newS3(t, "wrong-secret")
+	ctx := context.Background()
+
+	_, err := st.Put(ctx, "tasks/1/report/a.pdf", strings.NewReader("data"))
+	var s3Err *S3Error
+	if !errors.As(err, &s3Err) || s3Err.StatusCode != http.StatusForbidden || s3Err.Code != "SignatureDoesNotMatch" {
+		t.Fatalf("Put with wrong secret: err = %v, want 403 SignatureDoesNotMatch", err)
+	}
+	if _, err := st.List(ctx, ""); !errors.As(err, &s3Err) || s3Err.StatusCode != http.StatusForbidden {
+		t.Fatalf("List with wrong secret: err = %v, want 403", err)
+	}
+	if len(fake.Keys()) != 0 {
+		t.Fatalf("objects written with a bad signature: %v", fake.Keys())
+	}
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// 请求链路字段，FromContext 按此顺序附加到每条日志（PRD §11.3）
+const (
+	KeyRequestID = "request_id"
+	KeyTaskID    = "task_id"
+	KeyStage     = "stage"
+)
+
+type fieldsKey struct{}
+
+// fields 请求链路字段，零值表示未设置
+type fields struct {
+	requestID string
+	taskID    int64
+	stage     string
+}
+
+func fieldsFrom(ctx context.Context) fields {
+	f, _ := ctx.Value(fieldsKey{}).(fields)
+	return f
+}
+
+// WithRequestID 在 ctx 上记录请求 ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	f := fieldsFrom(ctx)
+	f.requestID = requestID
+	return context.WithValue(ctx, fieldsKey{}, f)
+}
+
+// WithTaskID 在 ctx 上记录任务 ID
+func WithTaskID(ctx context.Context, taskID int64) context.Context {
+	f := fieldsFrom(ctx)
+	f.taskID = taskID
+	return context.WithValue(ctx, fieldsKey{}, f)
+}
+
+// WithStage 在 ctx 上记录流水线阶段
+func WithStage(ctx context.Context, stage string) context.Context {
+	f := fieldsFrom(ctx)
+	f.stage = stage
+	return context.WithValue(ctx, fieldsKey{}, f)
+}
+
+// RequestID 返回 ctx 上记录的请求 ID，未设置时为空
+func RequestID(ctx context.Context) string {
+	return fieldsFrom(ctx).requestID
+}
+
+// Logger 附加了请求链路字段的日志记录器
+type Logger struct {
+	s *zap.SugaredLogger
+}
+
+// FromContext 返回附加 ctx 中请求 ID、任务 ID 与阶段的日志记录器，未设置的字段不输出。
+// 调用方不要再重复传入这些字段。
+func FromContext(ctx context.Context) *Logger {
+	f := fieldsFrom(ctx)
+	var kv []interface{}
+	if f.requestID != "" {
+		kv = append(kv, KeyRequestID, f.requestID)
+	}
+	if f.taskID != 0 {
+		kv = append(kv, KeyTaskID, f.taskID)
+	}
+	if f.stage != "" {
+		kv = append(kv, KeyStage, f.stage)
+	}
+	if len(kv) == 0 {
+		return &Logger{s: log}
+	}
+	return &Logger{s: log.With(kv...)}
+}
+
+// Debug 调试日志
+func (l *Logger) Debug(msg string, keysAndValues ...interface{}) {
+	l.s.Debugw(msg, keysAndValues...)
+}
+
+// Info 信息日志
+func (l *Logger) Info(msg string, keysAndValues ...interface{}) {
+	l.s.Infow(msg, keysAndValues...)
+}
+
+// Warn 警告日志
+func (l *Logger) Warn(msg string, keysAndValues ...interface{}) {
+	l.s.Warnw(msg, keysAndValues...)
+}
+
+// Error 错误日志
+func (l *Logger) Error(msg string, keysAndValues ...interface{}) {
+	l.s.Errorw(msg, keysAndValues...)
+}
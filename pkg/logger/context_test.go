@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureEntries 将全局日志写入临时文件，返回按行解析 JSON 日志的函数
+func captureEntries(t *testing.T) func() []map[string]interface{} {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "out.log")
+	if err := InitWithOutputs("debug", "json", DefaultRules(), path); err != nil {
+		t.Fatalf("InitWithOutputs: %v", err)
+	}
+	t.Cleanup(func() { Init("error", "json") })
+	return func() []map[string]interface{} {
+		Sync()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read log output: %v", err)
+		}
+		var entries []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var entry map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("decode %q: %v", line, err)
+			}
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	entries := captureEntries(t)
+
+	base := WithRequestID(context.Background(), "req-1")
+	stage := WithStage(WithTaskID(base, 42), "parsing")
+	next := WithStage(stage, "extracting")
+
+	FromContext(context.Background()).Info("no fields")
+	FromContext(base).Info("request only")
+	FromContext(stage).Warn("parsing", "pages", 3)
+	FromContext(next).Error("extracting")
+
+	want := []map[string]interface{}{
+		{},
+		{KeyRequestID: "req-1"},
+		{KeyRequestID: "req-1", KeyTaskID: float64(42), KeyStage: "parsing", "pages": float64(3)},
+		// 覆盖阶段不影响父 ctx 上的字段
+		{KeyRequestID: "req-1", KeyTaskID: float64(42), KeyStage: "extracting"},
+	}
+	got := entries()
+	if len(got) != len(want) {
+		t.Fatalf("entries = %v, want %d", got, len(want))
+	}
+	for i, fields := range want {
+		for _, key := range []string{KeyRequestID, KeyTaskID, KeyStage, "pages"} {
+			if got[i][key] != fields[key] {
+				t.Errorf("entry %d (%s): %s = %v, want %v", i, got[i]["msg"], key, got[i][key], fields[key])
+			}
+		}
+	}
+	if RequestID(stage) != "req-1" || RequestID(context.Background()) != "" {
+		t.Errorf("RequestID = %q, %q", RequestID(stage), RequestID(context.Background()))
+	}
+}
+
+func TestFromContextRedactsFields(t *testing.T) {
+	entries := captureEntries(t)
+	ctx := WithTaskID(WithRequestID(context.Background(), "req-2"), 7)
+
+	FromContext(ctx).Info("OTP requested", "phone", testPhone)
+	entry := entries()[0]
+	if entry["phone"] != testPhoneMask || entry[KeyRequestID] != "req-2" {
+		t.Errorf("entry = %v, want masked phone with request id", entry)
+	}
+}
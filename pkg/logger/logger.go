@@ -7,8 +7,34 @@ import (
 
 var log *zap.SugaredLogger
 
-// Init 初始化日志
+// Init 初始化日志，使用默认脱敏规则
 func Init(level, format string) {
+	InitWithRules(level, format, DefaultRules())
+}
+
+// InitWithRules 初始化日志，所有输出在编码前按 rules 脱敏
+func InitWithRules(level, format string, rules Rules) {
+	logger, err := build(level, format, rules)
+	if err != nil {
+		panic(err)
+	}
+
+	log = logger.Sugar()
+}
+
+// InitWithOutputs 初始化日志并写入 outputs（文件路径或 stdout、stderr），用于测试中检查实际输出
+func InitWithOutputs(level, format string, rules Rules, outputs ...string) error {
+	logger, err := build(level, format, rules, outputs...)
+	if err != nil {
+		return err
+	}
+
+	log = logger.Sugar()
+	return nil
+}
+
+// build 按级别与格式创建日志，outputs 为空时输出到标准错误
+func build(level, format string, rules Rules, outputs ...string) (*zap.Logger, error) {
 	var cfg zap.Config
 
 	if format == "json" {
@@ -32,12 +58,15 @@ func Init(level, format string) {
 		cfg.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
 	}
 
-	logger, err := cfg.Build()
-	if err != nil {
-		panic(err)
+	if len(outputs) > 0 {
+		cfg.OutputPaths = outputs
 	}
 
-	log = logger.Sugar()
+	// 跳过本包的封装函数，caller 指向实际调用处
+	redact := newRedactor(rules)
+	return cfg.Build(zap.AddCallerSkip(1), zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &redactCore{Core: core, r: redact}
+	}))
 }
 
 // Debug 调试日志
@@ -0,0 +1,184 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Mode 敏感字段的处理方式
+type Mode string
+
+const (
+	// ModeHash 只输出摘要与长度，可用于比对是否为同一内容
+	ModeHash Mode = "hash"
+	// ModeTruncate 保留前 TruncateLen 个字符与原始长度
+	ModeTruncate Mode = "truncate"
+)
+
+// DefaultTruncateLen truncate 模式默认保留的字符数
+const DefaultTruncateLen = 16
+
+// Rules 日志脱敏规则。手机号与身份证号在所有字段值与日志消息中始终掩码；
+// Keys 中的字段（不区分大小写）按指定方式整体处理，不输出原文。
+type Rules struct {
+	Keys        map[string]Mode
+	TruncateLen int
+}
+
+// DefaultRules 默认规则：解析文本与证据原文只输出摘要，存储对象键保留任务前缀
+func DefaultRules() Rules {
+	return Rules{
+		Keys: map[string]Mode{
+			"parsed_text": ModeHash,
+			"evidence":    ModeHash,
+			"storage_key": ModeTruncate,
+		},
+		TruncateLen: DefaultTruncateLen,
+	}
+}
+
+// ParseRules 解析 key:mode 逗号分隔的规则，如 parsed_text:hash,storage_key:truncate
+func ParseRules(spec string, truncateLen int) (Rules, error) {
+	rules := Rules{Keys: make(map[string]Mode), TruncateLen: truncateLen}
+	if truncateLen <= 0 {
+		return Rules{}, fmt.Errorf("invalid truncate length: %d", truncateLen)
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, mode, ok := strings.Cut(entry, ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return Rules{}, fmt.Errorf("invalid redact rule %q: expected key:mode", entry)
+		}
+		switch m := Mode(strings.TrimSpace(mode)); m {
+		case ModeHash, ModeTruncate:
+			rules.Keys[key] = m
+		default:
+			return Rules{}, fmt.Errorf("invalid redact mode %q for %s (allowed: hash, truncate)", m, key)
+		}
+	}
+	return rules, nil
+}
+
+var (
+	// phonePattern 中国大陆手机号，可带 +86 前缀；前后为数字时不匹配，避免误伤长数字
+	phonePattern = regexp.MustCompile(`\b(?:\+?86)?(1[3-9]\d)\d{4}(\d{4})\b`)
+	// idCardPattern 18 位居民身份证号（含出生日期校验）
+	idCardPattern = regexp.MustCompile(`\b(\d{6})(?:19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\d{3}([\dXx])\b`)
+)
+
+// redactor 按规则改写日志字段
+type redactor struct {
+	keys        map[string]Mode
+	truncateLen int
+}
+
+func newRedactor(rules Rules) *redactor {
+	keys := make(map[string]Mode, len(rules.Keys))
+	for k, m := range rules.Keys {
+		keys[strings.ToLower(k)] = m
+	}
+	n := rules.TruncateLen
+	if n <= 0 {
+		n = DefaultTruncateLen
+	}
+	return &redactor{keys: keys, truncateLen: n}
+}
+
+// mask 掩码手机号（保留前三后四位）与身份证号（保留前六位与校验位）
+func (r *redactor) mask(s string) string {
+	s = phonePattern.ReplaceAllString(s, "$1****$2")
+	return idCardPattern.ReplaceAllString(s, "$1***********$2")
+}
+
+func (r *redactor) apply(mode Mode, s string) string {
+	n := len([]rune(s))
+	if mode == ModeTruncate {
+		runes := []rune(r.mask(s))
+		if len(runes) <= r.truncateLen {
+			return string(runes)
+		}
+		return string(runes[:r.truncateLen]) + "…[len:" + strconv.Itoa(n) + "]"
+	}
+	sum := sha256.Sum256([]byte(s))
+	return "[sha256:" + hex.EncodeToString(sum[:6]) + " len:" + strconv.Itoa(n) + "]"
+}
+
+func (r *redactor) fields(fs []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fs))
+	for i, f := range fs {
+		out[i] = r.field(f)
+	}
+	return out
+}
+
+// field 敏感字段整体处理；其他字段掩码后内容有变化时改写为字符串字段
+func (r *redactor) field(f zapcore.Field) zapcore.Field {
+	if mode, ok := r.keys[strings.ToLower(f.Key)]; ok {
+		return zap.String(f.Key, r.apply(mode, fieldString(f)))
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = r.mask(f.String)
+		return f
+	case zapcore.BoolType, zapcore.DurationType, zapcore.TimeType, zapcore.TimeFullType,
+		zapcore.Float64Type, zapcore.Float32Type, zapcore.SkipType:
+		return f
+	}
+	s := fieldString(f)
+	if masked := r.mask(s); masked != s {
+		return zap.String(f.Key, masked)
+	}
+	return f
+}
+
+// fieldString 字段值的文本形式：字符串取原值，其余按 JSON 编码
+func fieldString(f zapcore.Field) string {
+	if f.Type == zapcore.StringType {
+		return f.String
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	v := enc.Fields[f.Key]
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// redactCore 在编码前改写日志消息与字段，json 与 console 编码器共用同一规则
+type redactCore struct {
+	zapcore.Core
+	r *redactor
+}
+
+func (c *redactCore) With(fs []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.r.fields(fs)), r: c.r}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fs []zapcore.Field) error {
+	ent.Message = c.r.mask(ent.Message)
+	return c.Core.Write(ent, c.r.fields(fs))
+}
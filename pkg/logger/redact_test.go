@@ -0,0 +1,203 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const (
+	testPhone      = "13812345678"
+	testPhoneMask  = "138****5678"
+	testIDCard     = "110101199003071234"
+	testIDCardMask = "110101***********4"
+	testParsedText = "姓名张三 收缩压 150 mmHg 诊断高血压"
+	testEvidence   = "空腹血糖 7.8 mmol/L 建议复查"
+	testStorageKey = "tasks/42/documents/7f3c2a9e-report-original.pdf"
+)
+
+// newTestLogger 按 format 创建写入临时文件的日志，返回日志与读取输出的函数
+func newTestLogger(t *testing.T, format string) (*zap.Logger, func() string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "out.log")
+	l, err := build("debug", format, DefaultRules(), path)
+	if err != nil {
+		t.Fatalf("build logger: %v", err)
+	}
+	return l, func() string {
+		_ = l.Sync()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read log output: %v", err)
+		}
+		return string(data)
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l *zap.Logger)
+		// absent 不得出现在输出中的原文
+		absent []string
+		// present 输出中应有的脱敏结果
+		present []string
+	}{
+		{
+			name:    "bare phone in message and string field",
+			log:     func(l *zap.Logger) { l.Info("sms sent to "+testPhone, zap.String("phone", testPhone)) },
+			absent:  []string{testPhone},
+			present: []string{testPhoneMask},
+		},
+		{
+			name:    "phone with +86 prefix",
+			log:     func(l *zap.Logger) { l.Info("login", zap.String("phone", "+86"+testPhone)) },
+			absent:  []string{testPhone, "86" + testPhone},
+			present: []string{testPhoneMask},
+		},
+		{
+			name:    "phone next to CJK text",
+			log:     func(l *zap.Logger) { l.Info("联系电话" + testPhone + "请回电") },
+			absent:  []string{testPhone},
+			present: []string{"联系电话" + testPhoneMask + "请回电"},
+		},
+		{
+			name:    "ID number in message and field",
+			log:     func(l *zap.Logger) { l.Info("身份证号"+testIDCard, zap.String("note", "id "+testIDCard)) },
+			absent:  []string{testIDCard},
+			present: []string{testIDCardMask},
+		},
+		{
+			name: "phone and ID number inside zap.Any",
+			log: func(l *zap.Logger) {
+				l.Info("user", zap.Any("user", map[string]interface{}{"phone": testPhone, "id_card": testIDCard}))
+			},
+			absent:  []string{testPhone, testIDCard},
+			present: []string{testPhoneMask, testIDCardMask},
+		},
+		{
+			name:    "phone inside error field",
+			log:     func(l *zap.Logger) { l.Error("failed", zap.Error(errors.New("send to "+testPhone+" failed"))) },
+			absent:  []string{testPhone},
+			present: []string{testPhoneMask},
+		},
+		{
+			name: "sensitive keys as strings",
+			log: func(l *zap.Logger) {
+				l.Info("parsed",
+					zap.String("parsed_text", testParsedText),
+					zap.String("evidence", testEvidence),
+					zap.String("storage_key", testStorageKey))
+			},
+			absent:  []string{testParsedText, testEvidence, testStorageKey},
+			present: []string{"[sha256:", "tasks/42/documen…[len:"},
+		},
+		{
+			name: "sensitive keys via zap.Any",
+			log: func(l *zap.Logger) {
+				l.Info("parsed",
+					zap.Any("parsed_text", []string{testParsedText}),
+					zap.Any("evidence", map[string]string{"text": testEvidence}),
+					zap.Any("storage_key", testStorageKey))
+			},
+			absent:  []string{testParsedText, testEvidence, testStorageKey},
+			present: []string{"[sha256:"},
+		},
+		{
+			name: "sensitive keys as errors",
+			log: func(l *zap.Logger) {
+				l.Error("failed",
+					zap.NamedError("parsed_text", errors.New(testParsedText)),
+					zap.NamedError("evidence", errors.New(testEvidence)),
+					zap.NamedError("storage_key", errors.New(testStorageKey)))
+			},
+			absent:  []string{testParsedText, testEvidence, testStorageKey},
+			present: []string{"[sha256:"},
+		},
+		{
+			name: "fields attached with With",
+			log: func(l *zap.Logger) {
+				l.With(
+					zap.String("phone", testPhone),
+					zap.String("parsed_text", testParsedText),
+					zap.Any("evidence", []string{testEvidence}),
+					zap.NamedError("storage_key", errors.New(testStorageKey)),
+				).Info("task loaded", zap.String("id_card", testIDCard))
+			},
+			absent:  []string{testPhone, testParsedText, testEvidence, testStorageKey, testIDCard},
+			present: []string{testPhoneMask, testIDCardMask, "[sha256:"},
+		},
+		{
+			name: "sugared logger key-value pairs",
+			log: func(l *zap.Logger) {
+				l.Sugar().With("phone", testPhone).Infow("parsed", "parsed_text", testParsedText, "Evidence", testEvidence)
+			},
+			absent:  []string{testPhone, testParsedText, testEvidence},
+			present: []string{testPhoneMask},
+		},
+	}
+
+	for _, format := range []string{"json", "console"} {
+		for _, tt := range tests {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				l, output := newTestLogger(t, format)
+				tt.log(l)
+				out := output()
+				if out == "" {
+					t.Fatal("no log output")
+				}
+				for _, raw := range tt.absent {
+					if strings.Contains(out, raw) {
+						t.Errorf("output contains raw value %q:\n%s", raw, out)
+					}
+				}
+				for _, want := range tt.present {
+					if !strings.Contains(out, want) {
+						t.Errorf("output missing %q:\n%s", want, out)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		spec    string
+		n       int
+		want    map[string]Mode
+		wantErr bool
+	}{
+		{spec: "parsed_text:hash, Storage_Key:truncate", n: 8, want: map[string]Mode{"parsed_text": ModeHash, "storage_key": ModeTruncate}},
+		{spec: "", n: 8, want: map[string]Mode{}},
+		{spec: "parsed_text", n: 8, wantErr: true},
+		{spec: "parsed_text:drop", n: 8, wantErr: true},
+		{spec: "parsed_text:hash", n: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			rules, err := ParseRules(tt.spec, tt.n)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRules(%q, %d) = %v, want error", tt.spec, tt.n, rules)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRules(%q, %d): %v", tt.spec, tt.n, err)
+			}
+			if len(rules.Keys) != len(tt.want) {
+				t.Fatalf("keys = %v, want %v", rules.Keys, tt.want)
+			}
+			for k, m := range tt.want {
+				if rules.Keys[k] != m {
+					t.Errorf("keys[%s] = %q, want %q", k, rules.Keys[k], m)
+				}
+			}
+		})
+	}
+}
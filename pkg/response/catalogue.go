@@ -0,0 +1,91 @@
+package response
+
+import (
+	"net/http"
+	"strings"
+)
+
+// 提示语言
+const (
+	LocaleZH = "zh-CN"
+	LocaleEN = "en"
+)
+
+// Entry 错误码目录项：HTTP 状态与面向用户的本地化提示
+type Entry struct {
+	Status   int
+	Messages map[string]string
+}
+
+// Message 返回 locale 对应的提示，缺失时回退到中文
+func (e Entry) Message(locale string) string {
+	if msg, ok := e.Messages[locale]; ok {
+		return msg
+	}
+	return e.Messages[LocaleZH]
+}
+
+func entry(status int, zh, en string) Entry {
+	return Entry{Status: status, Messages: map[string]string{LocaleZH: zh, LocaleEN: en}}
+}
+
+// catalogue 错误码目录，中文提示与 PRD §21.2 一致
+var catalogue = map[string]Entry{
+	CodeOK: entry(http.StatusOK, "成功", "OK"),
+
+	CodeInvalidParam:        entry(http.StatusBadRequest, "请检查输入参数", "Please check the request parameters"),
+	CodeUnauthorized:        entry(http.StatusUnauthorized, "请重新登录", "Please sign in again"),
+	CodeForbidden:           entry(http.StatusForbidden, "无权限访问", "Access denied"),
+	CodeRateLimited:         entry(http.StatusTooManyRequests, "请求过于频繁，请稍后重试", "Too many requests, please try again later"),
+	CodeInternal:            entry(http.StatusInternalServerError, "系统繁忙，请稍后重试", "The service is busy, please try again later"),
+	CodeIdempotencyConflict: entry(http.StatusConflict, "请求重复提交且内容不一致，请刷新后重试", "The request was already submitted with different content, please refresh and retry"),
+	CodeQuotaExceeded:       entry(http.StatusTooManyRequests, "今日可用次数已用完，请明天再试", "Your quota has been used up, please try again tomorrow"),
+
+	CodeUnsupportedFileFormat: entry(http.StatusBadRequest, "仅支持 PDF 格式文件", "Only PDF files are supported"),
+	CodeFileTooLarge:          entry(http.StatusRequestEntityTooLarge, "文件大小超过限制，请压缩后重传", "The file is too large, please compress it and upload again"),
+	CodeDocumentNoText:        entry(http.StatusUnprocessableEntity, "该文件可能为扫描件，暂不支持，请上传文字版 PDF", "The file looks like a scan, please upload a text-based PDF"),
+	CodeDocumentUnreadable:    entry(http.StatusUnprocessableEntity, "文件解析失败，请检查文件是否加密或损坏", "The file could not be parsed, please check whether it is encrypted or damaged"),
+	CodeDocumentTooShort:      entry(http.StatusUnprocessableEntity, "文档内容过少，无法完成分析，请确认上传正确文件", "The document is too short to analyse, please check that the right file was uploaded"),
+	CodeUnsupportedDocType:    entry(http.StatusBadRequest, "文档类型不支持，请选择体检报告、保险条款或投保告知书", "Unsupported document type, choose a health report, policy or disclosure form"),
+
+	CodeLLMTimeout:       entry(http.StatusInternalServerError, "分析超时，系统将自动重试", "Analysis timed out and will be retried automatically"),
+	CodeLLMInvalidJSON:   entry(http.StatusInternalServerError, "结构化分析失败，系统将自动重试", "Structured analysis failed and will be retried automatically"),
+	CodeLLMSchemaInvalid: entry(http.StatusInternalServerError, "分析结果异常，请重新发起分析", "The analysis result was invalid, please run the analysis again"),
+	CodeHealthFactsEmpty: entry(http.StatusUnprocessableEntity, "未在体检报告中识别到支持的异常项，请确认报告内容", "No supported findings were recognised in the health report"),
+	CodePolicyFactsEmpty: entry(http.StatusUnprocessableEntity, "未在条款文档中识别到支持的条款类型，请确认文档内容", "No supported clauses were recognised in the policy document"),
+	CodeLLMUnavailable:   entry(http.StatusServiceUnavailable, "分析服务暂时不可用，请稍后重试", "The analysis service is temporarily unavailable, please try again later"),
+
+	CodeRuleEngineFailed: entry(http.StatusInternalServerError, "风险评分异常，请重试", "Risk scoring failed, please retry"),
+	CodeNoMatchedTopic:   entry(http.StatusUnprocessableEntity, "根据当前文档未发现明显风险冲突", "No obvious risk conflicts were found in the documents"),
+
+	CodeTaskNotFound:         entry(http.StatusNotFound, "任务不存在或已被删除", "The task does not exist or has been deleted"),
+	CodeTaskStateConflict:    entry(http.StatusConflict, "当前任务状态不支持此操作", "This action is not allowed in the current task state"),
+	CodeTaskMissingDocuments: entry(http.StatusBadRequest, "请上传体检报告与保险条款后再开始分析", "Upload the health report and the policy before starting the analysis"),
+}
+
+// Lookup 查询错误码目录，未登记的错误码按 PFIT-1005 处理
+func Lookup(code string) Entry {
+	if e, ok := catalogue[code]; ok {
+		return e
+	}
+	return catalogue[CodeInternal]
+}
+
+// Codes 返回目录中的全部错误码，供文档与前端对照
+func Codes() map[string]Entry {
+	codes := make(map[string]Entry, len(catalogue))
+	for code, e := range catalogue {
+		codes[code] = e
+	}
+	return codes
+}
+
+// localeOf 根据 Accept-Language 选择提示语言，默认中文
+func localeOf(acceptLanguage string) string {
+	tag, _, _ := strings.Cut(acceptLanguage, ",")
+	tag, _, _ = strings.Cut(tag, ";")
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(tag)), "en") {
+		return LocaleEN
+	}
+	return LocaleZH
+}
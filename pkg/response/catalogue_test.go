@@ -0,0 +1,95 @@
+package response
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// published 已发布的错误码与 HTTP 状态，发布后不可删除或变更
+var published = map[string]int{
+	CodeOK: http.StatusOK,
+
+	"PFIT-1001": http.StatusBadRequest,
+	"PFIT-1002": http.StatusUnauthorized,
+	"PFIT-1003": http.StatusForbidden,
+	"PFIT-1004": http.StatusTooManyRequests,
+	"PFIT-1005": http.StatusInternalServerError,
+	"PFIT-1006": http.StatusConflict,
+	"PFIT-1007": http.StatusTooManyRequests,
+
+	"PFIT-2001": http.StatusBadRequest,
+	"PFIT-2002": http.StatusRequestEntityTooLarge,
+	"PFIT-2003": http.StatusUnprocessableEntity,
+	"PFIT-2004": http.StatusUnprocessableEntity,
+	"PFIT-2005": http.StatusUnprocessableEntity,
+	"PFIT-2006": http.StatusBadRequest,
+
+	"PFIT-3001": http.StatusInternalServerError,
+	"PFIT-3002": http.StatusInternalServerError,
+	"PFIT-3003": http.StatusInternalServerError,
+	"PFIT-3004": http.StatusUnprocessableEntity,
+	"PFIT-3005": http.StatusUnprocessableEntity,
+	"PFIT-3006": http.StatusServiceUnavailable,
+
+	"PFIT-4001": http.StatusInternalServerError,
+	"PFIT-4002": http.StatusUnprocessableEntity,
+
+	"PFIT-5001": http.StatusNotFound,
+	"PFIT-5002": http.StatusConflict,
+	"PFIT-5003": http.StatusBadRequest,
+}
+
+func TestCataloguePublishedCodes(t *testing.T) {
+	codes := Codes()
+	for code, status := range published {
+		entry, ok := codes[code]
+		if !ok {
+			t.Errorf("%s removed from the catalogue", code)
+			continue
+		}
+		if entry.Status != status {
+			t.Errorf("%s status = %d, want %d", code, entry.Status, status)
+		}
+		if entry.Messages[LocaleZH] == "" || entry.Messages[LocaleEN] == "" {
+			t.Errorf("%s messages = %v, want zh-CN and en", code, entry.Messages)
+		}
+	}
+	for code := range codes {
+		if _, ok := published[code]; !ok {
+			t.Errorf("%s is not listed as published", code)
+		}
+	}
+}
+
+func TestLookupUnknownCode(t *testing.T) {
+	if got := Lookup("PFIT-9999"); got.Status != http.StatusInternalServerError || got.Message(LocaleZH) != Lookup(CodeInternal).Message(LocaleZH) {
+		t.Fatalf("Lookup(unknown) = %+v, want the PFIT-1005 entry", got)
+	}
+}
+
+func TestLocaleOf(t *testing.T) {
+	tests := map[string]string{
+		"":                          LocaleZH,
+		"zh-CN,zh;q=0.9":            LocaleZH,
+		"en-US,en;q=0.9":            LocaleEN,
+		"EN":                        LocaleEN,
+		"fr-FR,en;q=0.8":            LocaleZH,
+		" en-GB;q=0.9, zh-CN;q=0.8": LocaleEN,
+	}
+	for header, want := range tests {
+		if got := localeOf(header); got != want {
+			t.Errorf("localeOf(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestTerminalCodesDoNotPromiseRetry(t *testing.T) {
+	// worker 不自动重试的失败码，提示不得声称系统将自动重试
+	for _, code := range []string{CodeLLMSchemaInvalid, CodeHealthFactsEmpty, CodePolicyFactsEmpty, CodeDocumentNoText, CodeDocumentUnreadable} {
+		entry := Lookup(code)
+		if strings.Contains(entry.Message(LocaleZH), "自动重试") || strings.Contains(entry.Message(LocaleEN), "automatically") {
+			t.Errorf("%s promises an automatic retry: %v", code, entry.Messages)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package response
+
+// CodeOK 成功响应的业务码
+const CodeOK = "OK"
+
+// 业务错误码，格式：PFIT-{模块码}-{错误序号}，详见 PRD §21。
+// 已发布的错误码含义不可变更，新增错误在模块内顺延序号。
+const (
+	CodeInvalidParam        = "PFIT-1001"
+	CodeUnauthorized        = "PFIT-1002"
+	CodeForbidden           = "PFIT-1003"
+	CodeRateLimited         = "PFIT-1004"
+	CodeInternal            = "PFIT-1005"
+	CodeIdempotencyConflict = "PFIT-1006"
+	CodeQuotaExceeded       = "PFIT-1007"
+
+	CodeUnsupportedFileFormat = "PFIT-2001"
+	CodeFileTooLarge          = "PFIT-2002"
+	CodeDocumentNoText        = "PFIT-2003"
+	CodeDocumentUnreadable    = "PFIT-2004"
+	CodeDocumentTooShort      = "PFIT-2005"
+	CodeUnsupportedDocType    = "PFIT-2006"
+
+	CodeLLMTimeout       = "PFIT-3001"
+	CodeLLMInvalidJSON   = "PFIT-3002"
+	CodeLLMSchemaInvalid = "PFIT-3003"
+	CodeHealthFactsEmpty = "PFIT-3004"
+	CodePolicyFactsEmpty = "PFIT-3005"
+	CodeLLMUnavailable   = "PFIT-3006"
+
+	CodeRuleEngineFailed = "PFIT-4001"
+	CodeNoMatchedTopic   = "PFIT-4002"
+
+	CodeTaskNotFound         = "PFIT-5001"
+	CodeTaskStateConflict    = "PFIT-5002"
+	CodeTaskMissingDocuments = "PFIT-5003"
+)
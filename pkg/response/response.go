@@ -1,39 +1,96 @@
 package response
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-// Response 统一响应结构
+// ContextRequestID gin 上下文中请求 ID 的键
+const ContextRequestID = "request_id"
+
+// Response 统一响应结构，成功与失败共用。
+// Message 为面向用户的本地化提示，Detail 为面向开发者的英文说明（5xx 不返回）。
 type Response struct {
-	Code      string      `json:"code,omitempty"`
-	Message   string      `json:"message,omitempty"`
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Detail    string      `json:"detail,omitempty"`
 	Data      interface{} `json:"data,omitempty"`
-	RequestID string      `json:"request_id,omitempty"`
-	Timestamp string      `json:"timestamp,omitempty"`
+	RequestID string      `json:"request_id"`
+	Timestamp string      `json:"timestamp"`
+}
+
+// Error 业务错误。HTTP 状态与用户提示由错误码目录决定，Detail 为英文说明。
+// 领域错误定义为 *Error 哨兵，或在 Unwrap 链中携带 *Error。
+type Error struct {
+	Code   string
+	Detail string
+}
+
+// NewError 创建业务错误
+func NewError(code, detail string) *Error {
+	return &Error{Code: code, Detail: detail}
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// RetryAfterer 可由错误实现，渲染时写入 Retry-After 响应头
+type RetryAfterer interface {
+	RetryAfter() time.Duration
 }
 
 // Success 成功响应
 func Success(c *gin.Context, data interface{}) {
-	c.JSON(http.StatusOK, gin.H{
-		"data": data,
-	})
+	c.JSON(http.StatusOK, envelope(c, CodeOK, data))
+}
+
+// Fail 记录错误并中止后续处理，由 ErrorHandler 中间件统一渲染
+func Fail(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// Render 渲染错误响应。错误链中没有 *Error 时按 PFIT-1005 处理且不返回 Detail，
+// 调用方应自行记录原始错误。
+func Render(c *gin.Context, err error) {
+	var coded *Error
+	if !errors.As(err, &coded) {
+		coded = NewError(CodeInternal, "")
+	}
+	entry := Lookup(coded.Code)
+	resp := envelope(c, coded.Code, nil)
+	if entry.Status < http.StatusInternalServerError {
+		resp.Detail = err.Error()
+	}
+	var ra RetryAfterer
+	if errors.As(err, &ra) && ra.RetryAfter() > 0 {
+		c.Header("Retry-After", strconv.Itoa(int((ra.RetryAfter()+time.Second-1)/time.Second)))
+	}
+	c.AbortWithStatusJSON(entry.Status, resp)
 }
 
-// Error 错误响应
-func Error(c *gin.Context, code string, message string) {
-	c.JSON(http.StatusBadRequest, Response{
-		Code:    code,
-		Message: message,
-	})
+// RequestID 当前请求 ID，尚未设置时生成并写入上下文
+func RequestID(c *gin.Context) string {
+	if id := c.GetString(ContextRequestID); id != "" {
+		return id
+	}
+	id := uuid.NewString()
+	c.Set(ContextRequestID, id)
+	return id
 }
 
-// ErrorWithStatus 带状态码的错误响应
-func ErrorWithStatus(c *gin.Context, status int, code string, message string) {
-	c.JSON(status, Response{
-		Code:    code,
-		Message: message,
-	})
+func envelope(c *gin.Context, code string, data interface{}) Response {
+	return Response{
+		Code:      code,
+		Message:   Lookup(code).Message(localeOf(c.GetHeader("Accept-Language"))),
+		Data:      data,
+		RequestID: RequestID(c),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
 }